@@ -0,0 +1,364 @@
+package chat
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/XRS0/ToTalkB/chat/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	maxAttachmentSize  = 10 << 20
+	maxAttachmentCount = 10
+	thumbnailSize      = 256
+	// maxImagePixels — сколько пикселей может быть в изображении: маленький файл
+	// может распаковаться в гигабайты, поэтому размер проверяется до декодирования
+	maxImagePixels = 40_000_000
+)
+
+var errImageTooLarge = errors.New("image dimensions are too large")
+
+var allowedMimeTypes = map[string]bool{
+	"image/jpeg":                true,
+	"image/png":                 true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"application/pdf":           true,
+	"application/zip":           true,
+	"text/plain; charset=utf-8": true,
+}
+
+var thumbnailMimeTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+type AttachmentHandler struct {
+	db    *sqlx.DB
+	store storage.BlobStore
+}
+
+func NewAttachmentHandler(db *sqlx.DB, store storage.BlobStore) *AttachmentHandler {
+	return &AttachmentHandler{db: db, store: store}
+}
+
+// Upload принимает файл из multipart-поля "file" и сохраняет его как вложение чата.
+// Сообщение ссылается на вложение по id, переданному в IncomingMessage.Attachments.
+func (h *AttachmentHandler) Upload(c *gin.Context) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return
+	}
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	member, err := isChatMember(h.db, chatId, userId)
+	if err != nil {
+		log.Printf("Failed to check chat membership: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check chat membership"})
+		return
+	}
+	if !member {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a chat member"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAttachmentSize+(1<<20))
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if fh.Size > maxAttachmentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+		return
+	}
+
+	file, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	// MIME-тип определяем по содержимому, заголовку клиента не доверяем
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	mimeType := http.DetectContentType(head[:n])
+	if !allowedMimeTypes[mimeType] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("unsupported file type: %s", mimeType)})
+		return
+	}
+	if thumbnailMimeTypes[mimeType] {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read file"})
+			return
+		}
+		if err := checkImageSize(file); errors.Is(err, errImageTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read file"})
+		return
+	}
+
+	key, err := newStorageKey(chatId, extensionFor(mimeType))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store file"})
+		return
+	}
+	ctx := c.Request.Context()
+	if err := h.store.Put(ctx, key, file); err != nil {
+		log.Printf("Failed to store attachment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store file"})
+		return
+	}
+
+	var thumbnailKey *string
+	if thumbnailMimeTypes[mimeType] {
+		if _, err := file.Seek(0, io.SeekStart); err == nil {
+			if thumb, err := makeThumbnail(file); err != nil {
+				log.Printf("Failed to generate thumbnail: %v", err)
+			} else {
+				tk := "thumbs/" + key
+				if err := h.store.Put(ctx, tk, bytes.NewReader(thumb)); err != nil {
+					log.Printf("Failed to store thumbnail: %v", err)
+				} else {
+					thumbnailKey = &tk
+				}
+			}
+		}
+	}
+
+	attachment := pkg.Attachment{
+		ChatId:       chatId,
+		UploaderId:   userId,
+		FileName:     filepath.Base(fh.Filename),
+		MimeType:     mimeType,
+		Size:         fh.Size,
+		StorageKey:   key,
+		ThumbnailKey: thumbnailKey,
+	}
+	err = h.db.QueryRowx(
+		`INSERT INTO attachments (chat_id, uploader_id, file_name, mime_type, size, storage_key, thumbnail_key)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)
+         RETURNING id, created_at`,
+		attachment.ChatId,
+		attachment.UploaderId,
+		attachment.FileName,
+		attachment.MimeType,
+		attachment.Size,
+		attachment.StorageKey,
+		attachment.ThumbnailKey,
+	).Scan(&attachment.Id, &attachment.CreatedAt)
+	if err != nil {
+		log.Printf("Failed to save attachment: %v", err)
+		h.store.Delete(ctx, key)
+		if thumbnailKey != nil {
+			h.store.Delete(ctx, *thumbnailKey)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save attachment"})
+		return
+	}
+
+	attachment.SetURLs()
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// Download отдаёт исходный файл вложения участнику чата
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	h.serve(c, false)
+}
+
+// Thumbnail отдаёт превью изображения участнику чата
+func (h *AttachmentHandler) Thumbnail(c *gin.Context) {
+	h.serve(c, true)
+}
+
+func (h *AttachmentHandler) serve(c *gin.Context, thumbnail bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment id"})
+		return
+	}
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var attachment pkg.Attachment
+	err = h.db.Get(&attachment, `SELECT * FROM attachments WHERE id = $1`, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load attachment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load attachment"})
+		return
+	}
+
+	member, err := isChatMember(h.db, attachment.ChatId, userId)
+	if err != nil {
+		log.Printf("Failed to check chat membership: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check chat membership"})
+		return
+	}
+	if !member {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not a chat member"})
+		return
+	}
+
+	key, mimeType := attachment.StorageKey, attachment.MimeType
+	if thumbnail {
+		if attachment.ThumbnailKey == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "attachment has no thumbnail"})
+			return
+		}
+		key, mimeType = *attachment.ThumbnailKey, "image/jpeg"
+	}
+
+	rc, err := h.store.Get(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment content not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to open attachment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open attachment"})
+		return
+	}
+	defer rc.Close()
+
+	extraHeaders := map[string]string{}
+	if !thumbnail {
+		extraHeaders["Content-Disposition"] = mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName})
+	}
+	c.DataFromReader(http.StatusOK, -1, mimeType, rc, extraHeaders)
+}
+
+func isChatMember(db sqlx.Queryer, chatId, userId int) (bool, error) {
+	var exists bool
+	err := sqlx.Get(db, &exists,
+		"SELECT EXISTS(SELECT 1 FROM chat_members WHERE chat_id = $1 AND user_id = $2)",
+		chatId, userId,
+	)
+	return exists, err
+}
+
+func newStorageKey(chatId int, ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d/%s%s", chatId, hex.EncodeToString(b), ext), nil
+}
+
+func extensionFor(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "application/pdf":
+		return ".pdf"
+	case "application/zip":
+		return ".zip"
+	case "text/plain; charset=utf-8":
+		return ".txt"
+	}
+	return ""
+}
+
+// checkImageSize читает из заголовка изображения его размеры и отклоняет слишком большие
+func checkImageSize(r io.Reader) error {
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return fmt.Errorf("%w: %dx%d", errImageTooLarge, config.Width, config.Height)
+	}
+	return nil
+}
+
+// makeThumbnail уменьшает изображение так, чтобы оно вписалось в thumbnailSize×thumbnailSize
+func makeThumbnail(r io.ReadSeeker) ([]byte, error) {
+	if err := checkImageSize(r); err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+	tw, th := w, h
+	if w > thumbnailSize || h > thumbnailSize {
+		if w >= h {
+			tw, th = thumbnailSize, max(1, h*thumbnailSize/w)
+		} else {
+			tw, th = max(1, w*thumbnailSize/h), thumbnailSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		sy := b.Min.Y + y*h/th
+		for x := 0; x < tw; x++ {
+			sx := b.Min.X + x*w/tw
+			dst.Set(x, y, src.At(sx, sy))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,123 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/repository"
+)
+
+// pngWithSize возвращает PNG 1×1, в заголовке которого записаны размеры width×height
+func pngWithSize(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	data := buf.Bytes()
+	// IHDR идёт сразу за сигнатурой: длина, тип, ширина, высота, ..., CRC типа и данных
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestCheckImageSize(t *testing.T) {
+	if err := checkImageSize(bytes.NewReader(pngWithSize(t, 1, 1))); err != nil {
+		t.Fatalf("expected small image to pass, got %v", err)
+	}
+	err := checkImageSize(bytes.NewReader(pngWithSize(t, 100_000, 100_000)))
+	if !errors.Is(err, errImageTooLarge) {
+		t.Fatalf("expected errImageTooLarge, got %v", err)
+	}
+	// огромное изображение отклоняется без декодирования пикселей
+	if _, err := makeThumbnail(bytes.NewReader(pngWithSize(t, 100_000, 100_000))); !errors.Is(err, errImageTooLarge) {
+		t.Fatalf("expected thumbnail of a huge image to fail, got %v", err)
+	}
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1024, 512))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	thumb, err := makeThumbnail(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("make thumbnail: %v", err)
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if format != "jpeg" || config.Width != thumbnailSize || config.Height != thumbnailSize/2 {
+		t.Fatalf("expected %dx%d jpeg, got %dx%d %s", thumbnailSize, thumbnailSize/2, config.Width, config.Height, format)
+	}
+}
+
+var attachmentColumns = []string{"id", "chat_id", "message_id", "uploader_id", "file_name", "mime_type", "size", "storage_key", "thumbnail_key", "created_at"}
+
+func TestCreateMessageWithAttachments(t *testing.T) {
+	// вложения 10 и 11 загрузил пользователь 3 в чат 1, 12 — чужое
+	var committed bool
+	query := func(query string, args []driver.Value) (*fakeRows, error) {
+		switch {
+		case query == "BEGIN", query == "ROLLBACK":
+		case query == "COMMIT":
+			committed = true
+		case strings.HasPrefix(query, "UPDATE chats SET last_seq"):
+			return fakeRow([]string{"last_seq"}, int64(1)), nil
+		case strings.HasPrefix(query, "WITH m AS"):
+			return fakeRow(messageColumns, int64(100), args[0], args[1], args[2], "eve", args[4], args[3], nil), nil
+		case strings.HasPrefix(query, "UPDATE attachments"):
+			rows := noRows(attachmentColumns...)
+			for _, id := range strings.Split(strings.Trim(args[1].(string), "{}"), ",") {
+				if id == "10" || id == "11" {
+					n, _ := strconv.ParseInt(id, 10, 64)
+					rows.values = append(rows.values, []driver.Value{n, int64(1), args[0], int64(3), "photo.png", "image/png", int64(1024), "key", nil, time.Now()})
+				}
+			}
+			return rows, nil
+		default:
+			return nil, fmt.Errorf("unexpected query %q", query)
+		}
+		return nil, nil
+	}
+	messages := repository.NewMessagePostgres(newFakeDB(t, query))
+
+	tests := []struct {
+		name string
+		ids  []int
+		want int
+		err  error
+	}{
+		{"own attachments", []int{10, 11}, 2, nil},
+		{"repeated id", []int{10, 10, 11}, 2, nil},
+		{"foreign attachment", []int{10, 12}, 0, repository.ErrInvalidAttachments},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			committed = false
+			msg, err := messages.Create(context.Background(), repository.NewMessage{ChatId: 1, SenderId: 3, AttachmentIds: tt.ids})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+			if committed != (tt.err == nil) {
+				t.Fatalf("expected commit %v, got %v", tt.err == nil, committed)
+			}
+			if err == nil && len(msg.Attachments) != tt.want {
+				t.Fatalf("expected %d attachments, got %+v", tt.want, msg.Attachments)
+			}
+		})
+	}
+}
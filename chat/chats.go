@@ -0,0 +1,106 @@
+package chat
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/XRS0/ToTalkB/chat/repository"
+	"github.com/gin-gonic/gin"
+)
+
+type ChatHandler struct {
+	chats repository.ChatRepository
+}
+
+func NewChatHandler(chats repository.ChatRepository) *ChatHandler {
+	return &ChatHandler{chats: chats}
+}
+
+// Create создаёт чат, текущий пользователь становится его владельцем
+func (h *ChatHandler) Create(c *gin.Context) {
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var input pkg.Chat
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.OwnerId = userId
+
+	if err := h.chats.Create(c.Request.Context(), &input); err != nil {
+		repositoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, input)
+}
+
+// Get возвращает чат, если текущий пользователь в нём состоит
+func (h *ChatHandler) Get(c *gin.Context) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return
+	}
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	chat, err := h.chats.GetById(c.Request.Context(), chatId)
+	if err != nil {
+		repositoryError(c, err)
+		return
+	}
+	member, err := h.chats.IsMember(c.Request.Context(), chatId, userId)
+	if err != nil {
+		repositoryError(c, err)
+		return
+	}
+	if !member {
+		repositoryError(c, repository.ErrNotMember)
+		return
+	}
+	c.JSON(http.StatusOK, chat)
+}
+
+// List возвращает чаты текущего пользователя
+func (h *ChatHandler) List(c *gin.Context) {
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	chats, err := h.chats.ListByMember(c.Request.Context(), userId)
+	if err != nil {
+		repositoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, chats)
+}
+
+// repositoryError переводит ошибки репозиториев в HTTP-ответ
+func repositoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrChatNotFound), errors.Is(err, repository.ErrNotScheduled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrNotMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrAlreadyMember):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrInvalidChat), errors.Is(err, repository.ErrEmptyMessage), errors.Is(err, repository.ErrInvalidExpiry),
+		errors.Is(err, repository.ErrInvalidAttachments):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Repository error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,106 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/XRS0/ToTalkB/chat/repository"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRepositoryError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{repository.ErrChatNotFound, http.StatusNotFound},
+		{repository.ErrNotScheduled, http.StatusNotFound},
+		{repository.ErrNotMember, http.StatusForbidden},
+		{repository.ErrAlreadyMember, http.StatusConflict},
+		{repository.ErrInvalidChat, http.StatusBadRequest},
+		{repository.ErrEmptyMessage, http.StatusBadRequest},
+		{repository.ErrInvalidExpiry, http.StatusBadRequest},
+		{fmt.Errorf("add member: %w", repository.ErrAlreadyMember), http.StatusConflict},
+		{fmt.Errorf("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			repositoryError(c, tt.err)
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestChatHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	chats := repository.NewChatMemory()
+	handler := NewChatHandler(chats)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userId", c.GetHeader("X-User"))
+	})
+	router.POST("/chat", handler.Create)
+	router.GET("/chat/:chatId", handler.Get)
+
+	do := func(method, path, user, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := do(http.MethodPost, "/chat", "1", `{"name":"Команда"}`); code != http.StatusOK {
+		t.Fatalf("expected chat to be created, got %d", code)
+	}
+	chat, err := chats.GetById(context.Background(), 1)
+	if err != nil || chat.OwnerId != 1 {
+		t.Fatalf("expected chat owned by 1, got %+v, %v", chat, err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		user   string
+		body   string
+		want   int
+	}{
+		{"blank name", http.MethodPost, "/chat", "1", `{"name":"  "}`, http.StatusBadRequest},
+		{"no user", http.MethodPost, "/chat", "", `{"name":"chat"}`, http.StatusUnauthorized},
+		{"owner", http.MethodGet, "/chat/1", "1", "", http.StatusOK},
+		{"not a member", http.MethodGet, "/chat/1", "2", "", http.StatusForbidden},
+		{"unknown chat", http.MethodGet, "/chat/100", "1", "", http.StatusNotFound},
+		{"invalid chat id", http.MethodGet, "/chat/abc", "1", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := do(tt.method, tt.path, tt.user, tt.body); code != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, code)
+			}
+		})
+	}
+
+	// неудачное создание не оставляет чата без владельца
+	if list, _ := chats.ListByMember(context.Background(), 1); len(list) != 1 {
+		t.Fatalf("expected one chat for the owner, got %v", list)
+	}
+	if err := chats.AddMember(context.Background(), 1, 2, pkg.RoleMember); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	if code := do(http.MethodGet, "/chat/1", "2", ""); code != http.StatusOK {
+		t.Fatalf("expected member to see the chat, got %d", code)
+	}
+}
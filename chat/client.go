@@ -1,24 +1,69 @@
 package chat
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/XRS0/ToTalkB/chat/command"
+	"github.com/XRS0/ToTalkB/chat/filter"
 	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/XRS0/ToTalkB/chat/repository"
+	"github.com/XRS0/ToTalkB/wsqueue"
 	"github.com/gorilla/websocket"
 	"github.com/jmoiron/sqlx"
 )
 
+// MaxMessageLength — сколько символов может быть в тексте сообщения
+const MaxMessageLength = 400
+
 const (
-	writeWait      = 10 * time.Second
-	pongWait       = 60 * time.Second
-	pingPeriod     = (pongWait * 9) / 10
-	maxMessageSize = 512
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = (pongWait * 9) / 10
+	// maxMessageSize покрывает MaxMessageLength: символ в JSON занимает до 6 байт (\uXXXX),
+	// остальное — запас на прочие поля входящего сообщения
+	maxMessageSize = MaxMessageLength*6 + 1024
+	sendQueueSize  = 256
 )
 
+// Типы фреймов, по ним выбирается политика переполнения очереди клиента
+const (
+	frameMessage   = "message"
+	frameHistory   = "history"
+	framePins      = "pins"
+	framePoll      = "poll"
+	frameCommand   = "command"
+	frameError     = "error"
+	frameSync      = "sync"
+	frameReceipt   = "receipt"
+	frameDeleted   = "deleted"
+	frameScheduled = "scheduled"
+)
+
+// sendPolicies: пропускать сообщения чата нельзя, поэтому медленный клиент отключается
+// и при переподключении получает историю; служебные фреймы устаревают и объединяются
+var sendPolicies = wsqueue.Policies{
+	Default: wsqueue.Disconnect,
+	ByKind: map[string]wsqueue.Policy{
+		framePins:      wsqueue.Coalesce,
+		framePoll:      wsqueue.Coalesce,
+		frameReceipt:   wsqueue.Coalesce,
+		frameCommand:   wsqueue.DropNewest,
+		frameError:     wsqueue.DropNewest,
+		frameScheduled: wsqueue.DropNewest,
+	},
+}
+
+var sendMetrics = wsqueue.NewMetrics("chat_ws")
+
 var (
 	newline = []byte{'\n'}
 	space   = []byte{' '}
@@ -33,23 +78,53 @@ var upgrader = websocket.Upgrader{
 }
 
 type Client struct {
-	hub    *Hub
-	conn   *websocket.Conn
-	send   chan []byte
-	db     *sqlx.DB
-	userId string
-	chatId string
+	hub      *Hub
+	conn     *websocket.Conn
+	queue    *wsqueue.Queue
+	db       *sqlx.DB
+	messages repository.MessageRepository
+	filters  *filter.Chain
+	commands *command.Registry
+	notifier *OfflineNotifier
+	userId   string
+	chatId   string
+
+	// пока клиенту досылается история, живые фреймы чата копятся в pending,
+	// чтобы не обогнать её
+	mu        sync.Mutex
+	replaying bool
+	pending   []wsqueue.Message
 }
 
 type IncomingMessage struct {
-	Message string `json:"message"`
-	Sender  string `json:"sender"`
+	Message     string     `json:"message"`
+	Sender      string     `json:"sender"`
+	Attachments []int      `json:"attachments,omitempty"`
+	Vote        *VoteInput `json:"vote,omitempty"`
+	// Resume — последний seq, который видел клиент; сервер досылает пропущенное
+	Resume *int64 `json:"resume,omitempty"`
+	// Delivered и Read — отметки доставки и прочтения до seq включительно
+	Delivered *int64 `json:"delivered,omitempty"`
+	Read      *int64 `json:"read,omitempty"`
+	// SendAt откладывает публикацию сообщения, ExpiresAt — когда сообщение удалится из чата
+	SendAt    *time.Time `json:"send_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// SyncMessage завершает досылку истории: клиент получил всё до LastSeq
+type SyncMessage struct {
+	Type    string `json:"type"`
+	LastSeq int64  `json:"last_seq"`
 }
 
 type OutgoingMessage struct {
-	Content string `json:"content"`
-	Sender  string `json:"sender"`
-	Time    string `json:"time"`
+	Id          int              `json:"id,omitempty"`
+	Seq         int64            `json:"seq,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	Content     string           `json:"content"`
+	Sender      string           `json:"sender"`
+	Time        string           `json:"time"`
+	Attachments []pkg.Attachment `json:"attachments,omitempty"`
 }
 
 func (c *Client) readPump() {
@@ -80,25 +155,63 @@ func (c *Client) readPump() {
 		}
 		log.Println(incomingMsg)
 
+		if incomingMsg.Resume != nil {
+			c.replay(*incomingMsg.Resume)
+			continue
+		}
+		if incomingMsg.Delivered != nil || incomingMsg.Read != nil {
+			c.acknowledge(incomingMsg.Delivered, incomingMsg.Read)
+			continue
+		}
+		if incomingMsg.Vote != nil {
+			c.castVote(incomingMsg.Vote)
+			continue
+		}
+
 		content := strings.TrimSpace(incomingMsg.Message)
+		// неизвестная команда уходит в чат обычным текстом
+		if name, args, ok := command.Parse(content); ok && c.commands != nil && c.commands.Has(name) {
+			c.runCommand(name, args, incomingMsg.Sender)
+			continue
+		}
+		if content == "" && len(incomingMsg.Attachments) == 0 {
+			continue
+		}
+		if len(incomingMsg.Attachments) > maxAttachmentCount {
+			log.Printf("Too many attachments: %d", len(incomingMsg.Attachments))
+			continue
+		}
+		result, err := c.checkMessage(content)
+		if err != nil {
+			c.sendError(err)
+			continue
+		}
+		content = result.Content
 		now := time.Now()
 
-		_, err = c.db.Exec(
-			"INSERT INTO messages (chat_id, sender_id, created_at, content) VALUES ($1, $2, $3, $4)",
-			c.chatId,
-			c.userId,
-			now,
-			content,
-		)
+		if incomingMsg.SendAt != nil && incomingMsg.SendAt.After(now) {
+			c.scheduleMessage(content, *incomingMsg.SendAt, incomingMsg.ExpiresAt, len(incomingMsg.Attachments) > 0, result.Flags)
+			continue
+		}
+
+		message, err := c.saveMessage(content, now, incomingMsg.ExpiresAt, incomingMsg.Attachments, result.Flags)
+		if errors.Is(err, repository.ErrInvalidExpiry) || errors.Is(err, repository.ErrInvalidAttachments) {
+			c.sendError(err)
+			continue
+		}
 		if err != nil {
 			log.Printf("Failed to save message to DB: %v", err)
 			continue
 		}
 
 		outMsg := OutgoingMessage{
-			Content: content,
-			Sender:  incomingMsg.Sender,
-			Time:    now.Format("15:04"),
+			Id:          message.Id,
+			Seq:         message.Seq,
+			ExpiresAt:   message.ExpiresAt,
+			Content:     content,
+			Sender:      incomingMsg.Sender,
+			Time:        now.Format("15:04"),
+			Attachments: message.Attachments,
 		}
 
 		jsonMsg, err := json.Marshal(outMsg)
@@ -107,7 +220,230 @@ func (c *Client) readPump() {
 			continue
 		}
 
-		c.hub.broadcast <- jsonMsg
+		c.hub.Broadcast(c.chatId, jsonMsg)
+		if chatId, userId, err := c.ids(); err == nil {
+			c.notifier.MessagePosted(chatId, userId, content)
+		}
+	}
+}
+
+func (c *Client) ids() (chatId, userId int, err error) {
+	if chatId, err = strconv.Atoi(c.chatId); err != nil {
+		return 0, 0, err
+	}
+	if userId, err = strconv.Atoi(c.userId); err != nil {
+		return 0, 0, err
+	}
+	return chatId, userId, nil
+}
+
+// checkMessage проверяет права автора и прогоняет текст через цепочку фильтров
+func (c *Client) checkMessage(content string) (*filter.Result, error) {
+	chatId, userId, err := c.ids()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCanPost(c.db, chatId, userId); err != nil {
+		return nil, err
+	}
+	if content == "" {
+		return &filter.Result{}, nil
+	}
+	return c.filters.Run(context.Background(), filter.Message{ChatId: chatId, UserId: userId, Content: content})
+}
+
+// castVote принимает голос в опросе, пришедший по сокету
+func (c *Client) castVote(v *VoteInput) {
+	chatId, userId, err := c.ids()
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+	if _, err := vote(c.db, c.hub, chatId, v.PollId, userId, v.OptionIds); err != nil {
+		c.sendError(err)
+	}
+}
+
+// sendError отправляет служебный фрейм с ошибкой только этому клиенту
+func (c *Client) sendError(err error) {
+	var muted *mutedError
+	var rejected *filter.RejectedError
+	var failure *commandFailure
+	switch {
+	case errors.As(err, &muted), errors.As(err, &rejected), errors.As(err, &failure):
+	case errors.Is(err, errNotMember), errors.Is(err, errPollClosed), errors.Is(err, errAlreadyVoted), errors.Is(err, errInvalidVote):
+	case errors.Is(err, errForbidden), errors.Is(err, repository.ErrInvalidExpiry), errors.Is(err, errScheduledAttachments),
+		errors.Is(err, repository.ErrInvalidAttachments):
+	case errors.Is(err, sql.ErrNoRows):
+		err = errors.New("not found")
+	default:
+		log.Printf("Failed to check message: %v", err)
+		err = errors.New("internal server error")
+	}
+
+	jsonMsg, mErr := json.Marshal(ErrorMessage{Type: frameError, Error: err.Error()})
+	if mErr != nil {
+		log.Printf("Failed to marshal json: %v", mErr)
+		return
+	}
+	c.queue.Push(wsqueue.Message{Kind: frameError, Data: jsonMsg})
+}
+
+func (c *Client) saveMessage(content string, now time.Time, expiresAt *time.Time, attachmentIds []int, flags []filter.Flag) (*pkg.Message, error) {
+	chatId, userId, err := c.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.messages.Create(context.Background(), repository.NewMessage{
+		ChatId:        chatId,
+		SenderId:      userId,
+		Content:       content,
+		CreatedAt:     now,
+		ExpiresAt:     expiresAt,
+		AttachmentIds: attachmentIds,
+		Flags:         flagReasons(flags),
+	})
+}
+
+// scheduleMessage откладывает сообщение до sendAt; откладывать публикацию могут только администраторы
+func (c *Client) scheduleMessage(content string, sendAt time.Time, expiresAt *time.Time, hasAttachments bool, flags []filter.Flag) {
+	chatId, userId, err := c.ids()
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+	if hasAttachments {
+		c.sendError(errScheduledAttachments)
+		return
+	}
+	if err := requireAdmin(c.db, chatId, userId); err != nil {
+		c.sendError(err)
+		return
+	}
+
+	scheduled, err := c.messages.Schedule(context.Background(), repository.NewMessage{
+		ChatId:    chatId,
+		SenderId:  userId,
+		Content:   content,
+		ExpiresAt: expiresAt,
+		Flags:     flagReasons(flags),
+	}, sendAt)
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+
+	jsonMsg, err := json.Marshal(ScheduledFrame{Type: frameScheduled, Message: *scheduled})
+	if err != nil {
+		log.Printf("Failed to marshal json: %v", err)
+		return
+	}
+	c.queue.Push(wsqueue.Message{Kind: frameScheduled, Data: jsonMsg})
+}
+
+func flagReasons(flags []filter.Flag) []string {
+	reasons := make([]string, len(flags))
+	for i, flag := range flags {
+		reasons[i] = flag.Filter + ": " + flag.Reason
+	}
+	return reasons
+}
+
+// replay досылает клиенту сообщения после afterSeq и фрейм sync в конце,
+// возвращает seq последнего досланного сообщения
+func (c *Client) replay(afterSeq int64) (int64, bool) {
+	chatId, _, err := c.ids()
+	if err != nil {
+		return afterSeq, false
+	}
+	history, err := c.messages.History(context.Background(), chatId, afterSeq)
+	if err != nil {
+		log.Printf("Failed to load chat history: %v", err)
+		return afterSeq, true
+	}
+
+	lastSeq := afterSeq
+	for _, msg := range history {
+		jsonMsg, err := json.Marshal(OutgoingMessage{
+			Id:          msg.Id,
+			Seq:         msg.Seq,
+			ExpiresAt:   msg.ExpiresAt,
+			Content:     msg.Content,
+			Sender:      msg.Sender,
+			Time:        msg.CreatedAt.Format("15:04"),
+			Attachments: msg.Attachments,
+		})
+		if err != nil {
+			log.Printf("Failed to marshal history message: %v", err)
+			continue
+		}
+		if !c.queue.PushWait(wsqueue.Message{Kind: frameHistory, Data: jsonMsg}) {
+			return lastSeq, false
+		}
+		lastSeq = msg.Seq
+	}
+
+	jsonMsg, err := json.Marshal(SyncMessage{Type: frameSync, LastSeq: lastSeq})
+	if err != nil {
+		log.Printf("Failed to marshal json: %v", err)
+		return lastSeq, true
+	}
+	return lastSeq, c.queue.PushWait(wsqueue.Message{Kind: frameSync, Data: jsonMsg})
+}
+
+// deliver ставит в очередь живой фрейм чата, а во время replay откладывает его.
+// false — клиент не успевает читать и его нужно отключить
+func (c *Client) deliver(msg wsqueue.Message) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.replaying {
+		if len(c.pending) >= sendQueueSize {
+			return false
+		}
+		c.pending = append(c.pending, msg)
+		return true
+	}
+	return c.queue.Push(msg)
+}
+
+// goLive отправляет отложенные за время replay фреймы и дальше пропускает живые сразу.
+// Сообщения, уже попавшие в историю до lastSeq, второй раз не отправляются
+func (c *Client) goLive(lastSeq int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, msg := range c.pending {
+		if msg.Kind == frameMessage {
+			var out OutgoingMessage
+			if err := json.Unmarshal(msg.Data, &out); err == nil && out.Seq != 0 && out.Seq <= lastSeq {
+				continue
+			}
+		}
+		if !c.queue.Push(msg) {
+			return false
+		}
+	}
+	c.pending = nil
+	c.replaying = false
+	return true
+}
+
+// acknowledge сохраняет отметки доставки и прочтения и сообщает о них участникам чата
+func (c *Client) acknowledge(delivered, read *int64) {
+	chatId, userId, err := c.ids()
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+	var deliveredSeq, readSeq int64
+	if delivered != nil {
+		deliveredSeq = *delivered
+	}
+	if read != nil {
+		readSeq = *read
+	}
+	if _, err := acknowledge(c.db, c.hub, chatId, userId, deliveredSeq, readSeq); err != nil {
+		c.sendError(err)
 	}
 }
 
@@ -119,25 +455,29 @@ func (c *Client) writePump() {
 	}()
 	for {
 		select {
-		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
+		case <-c.queue.Ready():
+			messages := c.queue.Drain()
+			if len(messages) == 0 {
+				continue
 			}
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			w, err := c.conn.NextWriter(websocket.TextMessage)
 			if err != nil {
 				return
 			}
-			w.Write(message)
-			n := len(c.send)
-			for range n {
-				w.Write(newline)
-				w.Write(<-c.send)
+			for i, message := range messages {
+				if i > 0 {
+					w.Write(newline)
+				}
+				w.Write(message.Data)
 			}
 			if err := w.Close(); err != nil {
 				return
 			}
+		case <-c.queue.Done():
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
@@ -147,51 +487,67 @@ func (c *Client) writePump() {
 	}
 }
 
-func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, db *sqlx.DB, userId, chatId string) {
+func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, db *sqlx.DB, messages repository.MessageRepository, filters *filter.Chain, commands *command.Registry, notifier *OfflineNotifier, userId, chatId string) {
+	// сокет открывается только участникам чата, забаненных не пускаем ещё до апгрейда
+	if status, err := checkCanConnect(db, chatId, userId); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println(err)
 		return
 	}
-	client := &Client{hub: hub, conn: conn, send: make(chan []byte, 256), db: db, userId: userId, chatId: chatId}
+	client := &Client{hub: hub, conn: conn, queue: wsqueue.New(sendQueueSize, sendPolicies, sendMetrics), db: db, messages: messages, filters: filters, commands: commands, notifier: notifier, userId: userId, chatId: chatId, replaying: true}
 
+	// клиент регистрируется до загрузки истории, чтобы не потерять сообщения между ними;
+	// живые фреймы до sync копятся в pending
 	client.hub.register <- client
 	go client.writePump()
 	go client.readPump()
 
-	messages, err := loadChatHistory(db, chatId)
-	if err != nil {
-		log.Printf("Failed to load chat history: %v", err)
+	id, _ := strconv.Atoi(chatId)
+
+	// last_seq — последний seq, который клиент уже видел; без него отдаётся вся история
+	afterSeq, _ := strconv.ParseInt(r.URL.Query().Get("last_seq"), 10, 64)
+	lastSeq, ok := client.replay(afterSeq)
+	if !ok || !client.goLive(lastSeq) {
+		return
+	}
+
+	if jsonMsg, err := pinsFrame(db, id); err != nil {
+		log.Printf("Failed to load pinned messages: %v", err)
 	} else {
-		for _, msg := range messages {
-			outMsg := OutgoingMessage{
-				Content: msg.Content,
-				Sender:  msg.Sender,
-				Time:    msg.CreatedAt.Format("15:04"),
-			}
-			log.Println(outMsg)
-			jsonMsg, err := json.Marshal(outMsg)
-			if err != nil {
-				log.Printf("Failed to marshal history message: %v", err)
-				continue
-			}
-			client.send <- jsonMsg
-		}
+		client.queue.Push(wsqueue.Message{Kind: framePins, Key: framePins, Data: jsonMsg})
 	}
 }
 
-func loadChatHistory(db *sqlx.DB, chatId string) ([]pkg.Message, error) {
-	var messages []pkg.Message
-	query := `
-        SELECT m.content, u.name AS sender, m.created_at
-        FROM messages m
-        JOIN users u ON m.sender_id = u.id
-        WHERE m.chat_id = $1
-        ORDER BY m.created_at ASC
-    `
-	err := db.Select(&messages, query, chatId)
+// checkCanConnect проверяет, что пользователь состоит в чате и не забанен в нём,
+// и возвращает HTTP-статус отказа
+func checkCanConnect(db sqlx.Queryer, chatId, userId string) (int, error) {
+	chat, err := strconv.Atoi(chatId)
 	if err != nil {
-		return nil, err
+		return http.StatusBadRequest, errors.New("invalid chat id")
+	}
+	user, err := strconv.Atoi(userId)
+	if err != nil {
+		return http.StatusUnauthorized, errors.New("invalid user id")
+	}
+
+	var banned bool
+	if err := sqlx.Get(db, &banned, "SELECT EXISTS(SELECT 1 FROM chat_bans WHERE chat_id = $1 AND user_id = $2)", chat, user); err != nil {
+		log.Printf("Failed to check chat ban: %v", err)
+		return http.StatusInternalServerError, errors.New("internal server error")
+	}
+	if banned {
+		return http.StatusForbidden, errBanned
+	}
+	if _, err := getMember(db, chat, user); err != nil {
+		if errors.Is(err, errNotMember) {
+			return http.StatusForbidden, err
+		}
+		log.Printf("Failed to check chat membership: %v", err)
+		return http.StatusInternalServerError, errors.New("internal server error")
 	}
-	return messages, nil
+	return 0, nil
 }
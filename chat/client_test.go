@@ -0,0 +1,53 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/XRS0/ToTalkB/chat/repository"
+	"github.com/XRS0/ToTalkB/wsqueue"
+)
+
+func TestReplayBeforeLiveFrames(t *testing.T) {
+	messages := scheduledChat(t)
+	post := func(content string) []byte {
+		t.Helper()
+		msg, err := messages.Create(context.Background(), repository.NewMessage{ChatId: 1, SenderId: 1, Content: content})
+		if err != nil {
+			t.Fatalf("create %q: %v", content, err)
+		}
+		data, _ := json.Marshal(OutgoingMessage{Id: msg.Id, Seq: msg.Seq, Content: msg.Content, Sender: msg.Sender})
+		return data
+	}
+
+	hub := NewHub()
+	go hub.Run()
+	client := &Client{hub: hub, chatId: "1", userId: "2", messages: messages, replaying: true, queue: wsqueue.New(sendQueueSize, sendPolicies, nil)}
+	hub.register <- client
+
+	// сообщение отправлено после регистрации, но до загрузки истории — придёт и живым, и в истории
+	hub.Broadcast("1", post("привет"))
+	hub.Publish("1", wsqueue.Message{Kind: frameReceipt, Key: "2", Data: []byte(`{"type":"receipt"}`)})
+	hub.Online("1")
+	if got := client.queue.Drain(); len(got) != 0 {
+		t.Fatalf("expected live frames to wait for the history, got %v", got)
+	}
+
+	lastSeq, ok := client.replay(0)
+	if !ok || lastSeq != 1 || !client.goLive(lastSeq) {
+		t.Fatalf("expected replay up to seq 1, got %d, %v", lastSeq, ok)
+	}
+	hub.Broadcast("1", post("как дела?"))
+	hub.Online("1")
+
+	var kinds []string
+	for _, frame := range client.queue.Drain() {
+		kinds = append(kinds, frame.Kind)
+	}
+	want := []string{frameHistory, frameSync, frameReceipt, frameMessage}
+	if !slices.Equal(kinds, want) {
+		t.Fatalf("expected frames %v, got %v", want, kinds)
+	}
+}
@@ -1,22 +1,51 @@
 package main
 
 import (
+	"context"
+	"expvar"
+	"fmt"
 	"log"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/XRS0/ToTalkB/auth/db"
 	"github.com/XRS0/ToTalkB/auth/middleware"
 	"github.com/XRS0/ToTalkB/chat"
-	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/XRS0/ToTalkB/chat/command"
+	"github.com/XRS0/ToTalkB/chat/config"
+	"github.com/XRS0/ToTalkB/chat/filter"
+	"github.com/XRS0/ToTalkB/chat/gen"
+	"github.com/XRS0/ToTalkB/chat/repository"
+	"github.com/XRS0/ToTalkB/chat/storage"
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func serveHome(c *gin.Context) {
 	http.ServeFile(c.Writer, c.Request, "home.html")
 }
 
+// serveInvite отдаёт страницу, на которую ведёт ссылка приглашения: она вступает в чат от имени пользователя
+func serveInvite(c *gin.Context) {
+	http.ServeFile(c.Writer, c.Request, "invite.html")
+}
+
+// wsToken переносит токен из параметра token в заголовок Authorization:
+// браузер не умеет передавать заголовки при открытии WebSocket
+func wsToken(c *gin.Context) {
+	if token := c.Query("token"); token != "" && c.GetHeader("Authorization") == "" {
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+	}
+	c.Next()
+}
+
 func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load config: %s\n", err.Error())
+	}
+
 	hub := chat.NewHub()
 	go hub.Run()
 
@@ -26,72 +55,106 @@ func main() {
 	}
 	defer db.Close()
 
-	r := gin.Default()
-	r.Use(middleware.CORSMiddleware())
-
-	r.GET("/chat/:chatId", serveHome)
-	r.GET("/ws/:chatId", func(c *gin.Context) {
-		userId := "1" // !!!
-		chatId := c.Param("chatId")
-		chat.ServeWs(hub, c.Writer, c.Request, db, userId, chatId)
-	})
-	api := r.Group("/api", middleware.UserIdentity)
-	api.POST("/chat", func(c *gin.Context) {
-		var input pkg.Chat
-
-		uid, err := strconv.Atoi(c.GetString("userId"))
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, err)
-		}
-		input.OwnerId = uid
-
-		if err := c.ShouldBindJSON(&input); err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, err)
-			return
-		}
+	store, err := storage.NewLocalStore("./uploads")
+	if err != nil {
+		log.Fatalf("failed to init attachment storage: %s\n", err.Error())
+	}
+	attachments := chat.NewAttachmentHandler(db, store)
+	search := chat.NewSearchHandler(db)
+	moderation := chat.NewModerationHandler(db, hub)
 
-		tx, err := db.Begin()
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, err)
-		}
-		defer tx.Rollback()
+	blocklist, err := filter.LoadWordList("./blocklist.txt")
+	if err != nil {
+		log.Fatalf("failed to load blocklist: %s\n", err.Error())
+	}
+	filterSettings := chat.NewFilterSettingsStore(db)
+	filters := filter.NewChain(filterSettings,
+		filter.NewFloodFilter(5, 10*time.Second),
+		filter.NewLengthFilter(chat.MaxMessageLength),
+		filter.NewBlocklistFilter(blocklist),
+		filter.NewLinkFilter(),
+	)
+	filterHandler := chat.NewFilterHandler(db, filters, filterSettings)
+	notifyConn, err := grpc.NewClient(cfg.NotificationService.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("failed to connect to notification service: %s\n", err.Error())
+	}
+	defer notifyConn.Close()
+	notifier := chat.NewOfflineNotifier(db, hub, gen.NewNotificationServiceClient(notifyConn))
+	go notifier.Run(context.Background())
+	notificationSettings := chat.NewNotificationSettingsHandler(db)
+	chatRepo := repository.NewChatPostgres(db)
+	chats := chat.NewChatHandler(chatRepo)
+	messages := repository.NewMessagePostgres(db)
+	export := chat.NewExportHandler(db)
+	polls := chat.NewPollHandler(db, hub)
+	receipts := chat.NewReceiptHandler(db, hub)
+	scheduled := chat.NewScheduledHandler(db, messages, filters)
+	go chat.NewMessageScheduler(messages, hub, store, notifier).Run(context.Background())
 
-		var chatID int
-		err = db.QueryRowx(
-			`INSERT INTO chats (name, created_by) 
-         VALUES ($1, $2) 
-         RETURNING id`,
-			input.Name,
-			input.OwnerId,
-		).Scan(&chatID)
-		if err != nil {
-			log.Printf("Failed to create chat: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create chat"})
-			return
-		}
+	eventConn, err := grpc.NewClient(cfg.EventManager.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("failed to connect to event manager: %s\n", err.Error())
+	}
+	defer eventConn.Close()
+	commands := command.NewRegistry()
+	commands.Register(chat.PollCommand(db, hub))
+	commands.Register(command.QueueCommand(gen.NewEventQueueServiceClient(eventConn), chat.ChatEventResolver(chatRepo)))
 
-		_, err = db.Exec(
-			`INSERT INTO chat_members (user_id, chat_id) 
-         VALUES ($1, $2)`,
-			input.OwnerId,
-			chatID,
-		)
-		if err != nil {
-			log.Printf("Failed to add owner to chat members: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add chat member"})
-			return
-		}
+	pins := chat.NewPinHandler(db, hub, notifier)
+	invites := chat.NewInviteHandler(db, cfg.Invite.BaseURL)
 
-		if err := tx.Commit(); err != nil {
-			log.Fatal(err)
-		}
+	r := gin.Default()
+	r.Use(middleware.CORSMiddleware())
 
-		c.JSON(http.StatusOK, gin.H{
-			"id":       chatID,
-			"name":     input.Name,
-			"owner_id": input.OwnerId,
-		})
+	r.GET("/chat/:chatId", serveHome)
+	r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	r.GET("/invite/:token", serveInvite)
+	r.GET("/invite/:token/qr", invites.QR)
+	r.GET("/ws/:chatId", wsToken, middleware.UserIdentity, func(c *gin.Context) {
+		chat.ServeWs(hub, c.Writer, c.Request, db, messages, filters, commands, notifier, c.GetString("userId"), c.Param("chatId"))
 	})
+	api := r.Group("/api", middleware.UserIdentity)
+	api.POST("/chat/:chatId/attachments", attachments.Upload)
+	api.GET("/attachments/:id", attachments.Download)
+	api.GET("/attachments/:id/thumbnail", attachments.Thumbnail)
+	api.GET("/messages/search", search.Search)
+	api.POST("/chat/:chatId/members/:userId/mute", moderation.Mute)
+	api.DELETE("/chat/:chatId/members/:userId/mute", moderation.Unmute)
+	api.POST("/chat/:chatId/members/:userId/ban", moderation.Ban)
+	api.DELETE("/chat/:chatId/bans/:userId", moderation.Unban)
+	api.PUT("/chat/:chatId/members/:userId/role", moderation.SetRole)
+	api.POST("/chat/:chatId/messages/:messageId/report", moderation.Report)
+	api.GET("/chat/:chatId/reports", moderation.Reports)
+	api.POST("/chat/:chatId/reports/:reportId/resolve", moderation.ResolveReport)
+	api.GET("/chat/:chatId/moderation/log", moderation.Log)
+	api.GET("/chat/:chatId/filters", filterHandler.Get)
+	api.PUT("/chat/:chatId/filters", filterHandler.Update)
+	api.GET("/chat/:chatId/pins", pins.List)
+	api.POST("/chat/:chatId/messages/:messageId/pin", pins.Pin)
+	api.DELETE("/chat/:chatId/messages/:messageId/pin", pins.Unpin)
+	api.POST("/chat/:chatId/announcements", pins.Announce)
+	api.GET("/chat/:chatId/invites", invites.List)
+	api.POST("/chat/:chatId/invites", invites.Create)
+	api.DELETE("/chat/:chatId/invites/:inviteId", invites.Revoke)
+	api.POST("/invites/:token/join", invites.Join)
+	api.GET("/chat/:chatId/notifications", notificationSettings.Get)
+	api.PUT("/chat/:chatId/notifications", notificationSettings.Update)
+	api.GET("/chats", chats.List)
+	api.POST("/chat", chats.Create)
+	api.GET("/chat/:chatId", chats.Get)
+	api.GET("/chat/:chatId/export", export.Export)
+	api.GET("/chat/:chatId/polls", polls.List)
+	api.POST("/chat/:chatId/polls", polls.Create)
+	api.GET("/chat/:chatId/polls/:pollId", polls.Get)
+	api.POST("/chat/:chatId/polls/:pollId/votes", polls.Vote)
+	api.POST("/chat/:chatId/polls/:pollId/close", polls.Close)
+	api.GET("/chat/:chatId/receipts", receipts.List)
+	api.PUT("/chat/:chatId/receipts", receipts.Update)
+	api.GET("/chat/:chatId/messages/:messageId/status", receipts.Status)
+	api.GET("/chat/:chatId/scheduled", scheduled.List)
+	api.POST("/chat/:chatId/scheduled", scheduled.Create)
+	api.DELETE("/chat/:chatId/scheduled/:scheduledId", scheduled.Cancel)
 
-	r.Run(":8081")
+	r.Run(fmt.Sprintf(":%d", cfg.Server.Port))
 }
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Prefix — признак команды в начале сообщения
+const Prefix = "/"
+
+var ErrUnknownCommand = errors.New("unknown command")
+
+// UsageError — команда вызвана с неверными аргументами
+type UsageError struct {
+	Usage string
+}
+
+func (e *UsageError) Error() string {
+	return "usage: " + e.Usage
+}
+
+// Call — вызов команды участником чата
+type Call struct {
+	ChatId int
+	UserId int
+	Sender string
+	Name   string
+	Args   []string
+}
+
+// Reply — ответ команды: только отправителю или всему чату
+type Reply struct {
+	Text string
+	Room bool
+}
+
+// Private создаёт ответ, который увидит только отправитель команды
+func Private(format string, args ...any) *Reply {
+	return &Reply{Text: fmt.Sprintf(format, args...)}
+}
+
+// Public создаёт ответ, который увидят все участники чата
+func Public(format string, args ...any) *Reply {
+	return &Reply{Text: fmt.Sprintf(format, args...), Room: true}
+}
+
+type HandlerFunc func(ctx context.Context, call *Call) (*Reply, error)
+
+type Command struct {
+	Name        string
+	Usage       string
+	Description string
+	Handler     HandlerFunc
+}
+
+// Registry хранит зарегистрированные команды и направляет им вызовы
+type Registry struct {
+	commands map[string]Command
+}
+
+// NewRegistry создаёт реестр со встроенной командой /help
+func NewRegistry() *Registry {
+	r := &Registry{commands: make(map[string]Command)}
+	r.Register(Command{
+		Name:        "help",
+		Usage:       "/help",
+		Description: "list available commands",
+		Handler:     r.help,
+	})
+	return r
+}
+
+// Register добавляет команду, повторная регистрация заменяет предыдущую
+func (r *Registry) Register(cmd Command) {
+	r.commands[cmd.Name] = cmd
+}
+
+// Has сообщает, зарегистрирована ли команда
+func (r *Registry) Has(name string) bool {
+	_, ok := r.commands[name]
+	return ok
+}
+
+// Parse выделяет имя команды и аргументы; ok == false, если сообщение не команда.
+// Имя должно идти сразу за префиксом, а двойной префикс («//») означает обычный текст
+func Parse(content string) (name string, args []string, ok bool) {
+	rest, found := strings.CutPrefix(content, Prefix)
+	if !found || rest == "" || strings.HasPrefix(rest, Prefix) {
+		return "", nil, false
+	}
+	if r, _ := utf8.DecodeRuneInString(rest); unicode.IsSpace(r) {
+		return "", nil, false
+	}
+	fields := strings.Fields(rest)
+	return strings.ToLower(fields[0]), fields[1:], true
+}
+
+func (r *Registry) Dispatch(ctx context.Context, call *Call) (*Reply, error) {
+	cmd, ok := r.commands[call.Name]
+	if !ok {
+		return nil, ErrUnknownCommand
+	}
+	return cmd.Handler(ctx, call)
+}
+
+func (r *Registry) help(ctx context.Context, call *Call) (*Reply, error) {
+	names := make([]string, 0, len(r.commands))
+	for name := range r.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("Available commands:")
+	for _, name := range names {
+		cmd := r.commands[name]
+		fmt.Fprintf(&b, "\n%s — %s", cmd.Usage, cmd.Description)
+	}
+	return Private("%s", b.String()), nil
+}
@@ -0,0 +1,83 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		content string
+		name    string
+		args    []string
+		ok      bool
+	}{
+		{content: "/help", name: "help", ok: true},
+		{content: "/Queue join", name: "queue", args: []string{"join"}, ok: true},
+		{content: "/queue   join  now", name: "queue", args: []string{"join", "now"}, ok: true},
+		{content: "/очередь", name: "очередь", ok: true},
+		{content: "hello /help", ok: false},
+		{content: "", ok: false},
+		{content: "/", ok: false},
+		{content: "/ help", ok: false},
+		{content: "//help", ok: false},
+		{content: "// comment", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			name, args, ok := Parse(tt.content)
+			if ok != tt.ok || name != tt.name || !slices.Equal(args, tt.args) {
+				t.Fatalf("expected (%q, %v, %v), got (%q, %v, %v)", tt.name, tt.args, tt.ok, name, args, ok)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry()
+	r.Register(Command{
+		Name:        "echo",
+		Usage:       "/echo text",
+		Description: "repeat the text",
+		Handler: func(ctx context.Context, call *Call) (*Reply, error) {
+			if len(call.Args) == 0 {
+				return nil, &UsageError{Usage: "/echo text"}
+			}
+			return Public("%s: %s", call.Sender, strings.Join(call.Args, " ")), nil
+		},
+	})
+
+	if !r.Has("echo") || !r.Has("help") || r.Has("unknown") {
+		t.Fatal("unexpected registered commands")
+	}
+
+	reply, err := r.Dispatch(ctx, &Call{Sender: "ann", Name: "echo", Args: []string{"hi", "all"}})
+	if err != nil {
+		t.Fatalf("dispatch echo: %v", err)
+	}
+	if !reply.Room || reply.Text != "ann: hi all" {
+		t.Fatalf("unexpected reply %+v", reply)
+	}
+
+	_, err = r.Dispatch(ctx, &Call{Name: "echo"})
+	var usage *UsageError
+	if !errors.As(err, &usage) || usage.Error() != "usage: /echo text" {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+	if _, err := r.Dispatch(ctx, &Call{Name: "unknown"}); !errors.Is(err, ErrUnknownCommand) {
+		t.Fatalf("expected ErrUnknownCommand, got %v", err)
+	}
+
+	help, err := r.Dispatch(ctx, &Call{Name: "help"})
+	if err != nil {
+		t.Fatalf("dispatch help: %v", err)
+	}
+	want := "Available commands:\n/echo text — repeat the text\n/help — list available commands"
+	if help.Room || help.Text != want {
+		t.Fatalf("expected private help %q, got %+v", want, help)
+	}
+}
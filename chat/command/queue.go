@@ -0,0 +1,95 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/gen"
+)
+
+const queueTimeout = 5 * time.Second
+
+// queueWaitlisted — статус записи, которой не хватило места в живой очереди
+const queueWaitlisted = "waitlisted"
+
+var ErrNoEvent = errors.New("this chat is not linked to an event")
+
+// EventResolver возвращает id события, к которому привязан чат
+type EventResolver func(ctx context.Context, chatId int) (string, error)
+
+// QueueCommand — команда /queue, управляющая местом в очереди события через EventQueueService
+func QueueCommand(client gen.EventQueueServiceClient, resolve EventResolver) Command {
+	q := &queueCommand{client: client, resolve: resolve}
+	return Command{
+		Name:        "queue",
+		Usage:       "/queue join|leave|position|status",
+		Description: "manage your place in the event queue",
+		Handler:     q.handle,
+	}
+}
+
+type queueCommand struct {
+	client  gen.EventQueueServiceClient
+	resolve EventResolver
+}
+
+func (q *queueCommand) handle(ctx context.Context, call *Call) (*Reply, error) {
+	if len(call.Args) != 1 {
+		return nil, &UsageError{Usage: "/queue join|leave|position|status"}
+	}
+	eventId, err := q.resolve(ctx, call.ChatId)
+	if err != nil {
+		return nil, err
+	}
+	userId := strconv.Itoa(call.UserId)
+
+	ctx, cancel := context.WithTimeout(ctx, queueTimeout)
+	defer cancel()
+
+	switch call.Args[0] {
+	case "join":
+		resp, err := q.client.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventId, UserId: userId})
+		if err != nil {
+			return nil, err
+		}
+		if resp.Status == queueWaitlisted {
+			return Private("The queue is full, you are number %d on the waitlist", resp.Position), nil
+		}
+		return Private("You joined the queue, your position is %d", resp.Position), nil
+	case "leave":
+		if _, err := q.client.LeaveQueue(ctx, &gen.LeaveQueueRequest{EventId: eventId, UserId: userId}); err != nil {
+			return nil, err
+		}
+		return Private("You left the queue"), nil
+	case "position":
+		resp, err := q.client.GetUserPosition(ctx, &gen.GetUserPositionRequest{EventId: eventId, UserId: userId})
+		if err != nil {
+			return nil, err
+		}
+		if resp.Status == queueWaitlisted {
+			return Private("You are number %d on the waitlist", resp.Position), nil
+		}
+		return Private("Your position in the queue is %d", resp.Position), nil
+	case "status":
+		resp, err := q.client.GetQueueStatus(ctx, &gen.GetQueueStatusRequest{EventId: eventId})
+		if err != nil {
+			return nil, err
+		}
+		waiting, waitlisted := 0, 0
+		for _, entry := range resp.Queues {
+			switch entry.Status {
+			case "waiting":
+				waiting++
+			case queueWaitlisted:
+				waitlisted++
+			}
+		}
+		if waitlisted > 0 {
+			return Private("%d people are waiting in the queue and %d on the waitlist", waiting, waitlisted), nil
+		}
+		return Private("%d people are waiting in the queue", waiting), nil
+	}
+	return nil, &UsageError{Usage: "/queue join|leave|position|status"}
+}
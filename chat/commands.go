@@ -0,0 +1,118 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/command"
+	"github.com/XRS0/ToTalkB/chat/repository"
+	"github.com/XRS0/ToTalkB/wsqueue"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const commandTimeout = 10 * time.Second
+
+// CommandReply — ответ на команду, отправляется автору команды или всему чату
+type CommandReply struct {
+	Type    string `json:"type"`
+	Command string `json:"command"`
+	Sender  string `json:"sender"`
+	Text    string `json:"text"`
+	Time    string `json:"time"`
+}
+
+// ChatEventResolver находит событие, к которому привязан чат
+func ChatEventResolver(chats repository.ChatRepository) command.EventResolver {
+	return func(ctx context.Context, chatId int) (string, error) {
+		chat, err := chats.GetById(ctx, chatId)
+		if err != nil {
+			return "", err
+		}
+		if chat.EventId == nil {
+			return "", command.ErrNoEvent
+		}
+		return *chat.EventId, nil
+	}
+}
+
+// runCommand выполняет команду участника чата; команды не сохраняются в историю.
+// Команды доступны тем же, кто может писать в чат: заглушённый их не запустит
+func (c *Client) runCommand(name string, args []string, sender string) {
+	chatId, userId, err := c.ids()
+	if err != nil {
+		c.sendError(err)
+		return
+	}
+	if err := checkCanPost(c.db, chatId, userId); err != nil {
+		c.sendError(err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	result, err := c.commands.Dispatch(ctx, &command.Call{
+		ChatId: chatId,
+		UserId: userId,
+		Sender: sender,
+		Name:   name,
+		Args:   args,
+	})
+	if err != nil {
+		c.sendError(commandError(err))
+		return
+	}
+	if result == nil {
+		return
+	}
+
+	jsonMsg, err := json.Marshal(CommandReply{
+		Type:    frameCommand,
+		Command: name,
+		Sender:  sender,
+		Text:    result.Text,
+		Time:    time.Now().Format("15:04"),
+	})
+	if err != nil {
+		log.Printf("Failed to marshal json: %v", err)
+		return
+	}
+	if result.Room {
+		c.hub.Publish(c.chatId, wsqueue.Message{Kind: frameCommand, Data: jsonMsg})
+		return
+	}
+	c.queue.Push(wsqueue.Message{Kind: frameCommand, Data: jsonMsg})
+}
+
+// commandError оставляет понятные пользователю ошибки команд как есть
+func commandError(err error) error {
+	var usage *command.UsageError
+	switch {
+	case errors.As(err, &usage), errors.Is(err, command.ErrUnknownCommand), errors.Is(err, command.ErrNoEvent):
+		return &commandFailure{msg: err.Error()}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &commandFailure{msg: "command timed out"}
+	}
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.FailedPrecondition, codes.ResourceExhausted:
+			return &commandFailure{msg: st.Message()}
+		case codes.Unavailable, codes.DeadlineExceeded:
+			return &commandFailure{msg: "service is temporarily unavailable"}
+		}
+	}
+	log.Printf("Command failed: %v", err)
+	return &commandFailure{msg: "command failed"}
+}
+
+// commandFailure — ошибка команды, текст которой можно показать пользователю
+type commandFailure struct {
+	msg string
+}
+
+func (e *commandFailure) Error() string {
+	return e.msg
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	Server              Server  `mapstructure:"server"`
+	Invite              Invite  `mapstructure:"invite"`
+	NotificationService Service `mapstructure:"notification_service"`
+	EventManager        Service `mapstructure:"event_manager"`
+}
+
+type Server struct {
+	Port int `mapstructure:"port"`
+}
+
+// Invite — публичный адрес, по которому открываются ссылки приглашений: к нему дописывается токен.
+// На каждом стенде свой, поэтому его можно переопределить переменной окружения INVITE_BASE_URL
+type Invite struct {
+	BaseURL string `mapstructure:"base_url"`
+}
+
+// Service — gRPC-адрес соседнего сервиса
+type Service struct {
+	Address string `mapstructure:"address"`
+}
+
+func Load() (*Config, error) {
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(".")
+
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	if err := viper.BindEnv("invite.base_url", "INVITE_BASE_URL"); err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := viper.Unmarshal(&config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
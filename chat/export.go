@@ -0,0 +1,260 @@
+package chat
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// exportFlushEvery — через сколько сообщений сбрасывать буфер ответа клиенту
+const exportFlushEvery = 100
+
+// exportWriter пишет выгрузку по одному сообщению, не держа историю в памяти
+type exportWriter interface {
+	Begin(chat *pkg.Chat) error
+	Write(msg *pkg.ExportedMessage) error
+	End() error
+}
+
+var exportFormats = map[string]struct {
+	contentType string
+	extension   string
+	new         func(w io.Writer) exportWriter
+}{
+	"jsonl": {"application/x-ndjson; charset=utf-8", "jsonl", newJSONLinesWriter},
+	"csv":   {"text/csv; charset=utf-8", "csv", newCSVWriter},
+	"html":  {"text/html; charset=utf-8", "html", newHTMLWriter},
+}
+
+type ExportHandler struct {
+	db *sqlx.DB
+}
+
+func NewExportHandler(db *sqlx.DB) *ExportHandler {
+	return &ExportHandler{db: db}
+}
+
+// Export отдаёт всю историю чата потоком. Параметр format — jsonl (по умолчанию), csv или html
+func (h *ExportHandler) Export(c *gin.Context) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return
+	}
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	format, ok := exportFormats[c.DefaultQuery("format", "jsonl")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported format"})
+		return
+	}
+	if err := requireAdmin(h.db, chatId, userId); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	var chat pkg.Chat
+	if err := h.db.Get(&chat, "SELECT id, name, created_by FROM chats WHERE id = $1", chatId); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	rows, err := h.db.QueryxContext(c.Request.Context(),
+		`SELECT m.id, m.sender_id, u.name AS sender, m.content, m.created_at,
+                COALESCE((
+                    SELECT json_agg(json_build_object(
+                               'id', a.id, 'file_name', a.file_name, 'mime_type', a.mime_type, 'size', a.size
+                           ) ORDER BY a.id)
+                    FROM attachments a WHERE a.message_id = m.id
+                ), '[]') AS attachments
+         FROM messages m
+         JOIN users u ON u.id = m.sender_id
+         WHERE m.chat_id = $1 AND (m.expires_at IS NULL OR m.expires_at > now())
+         ORDER BY m.seq ASC`,
+		chatId,
+	)
+	if err != nil {
+		log.Printf("Failed to export chat: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export chat"})
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", format.contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="chat-%d.%s"`, chatId, format.extension))
+	c.Status(http.StatusOK)
+
+	// после начала ответа статус уже не поменять, поэтому ошибки только логируются
+	if err := writeExport(c.Writer, format.new(c.Writer), &chat, rows); err != nil {
+		log.Printf("Failed to export chat %d: %v", chatId, err)
+	}
+}
+
+func writeExport(w gin.ResponseWriter, ew exportWriter, chat *pkg.Chat, rows *sqlx.Rows) error {
+	if err := ew.Begin(chat); err != nil {
+		return err
+	}
+
+	var row struct {
+		pkg.ExportedMessage
+		RawAttachments []byte `db:"attachments"`
+	}
+	for n := 1; rows.Next(); n++ {
+		row.ExportedMessage = pkg.ExportedMessage{}
+		if err := rows.StructScan(&row); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(row.RawAttachments, &row.Attachments); err != nil {
+			return err
+		}
+		for i := range row.Attachments {
+			row.Attachments[i].URL = fmt.Sprintf("/api/attachments/%d", row.Attachments[i].Id)
+		}
+
+		if err := ew.Write(&row.ExportedMessage); err != nil {
+			return err
+		}
+		if n%exportFlushEvery == 0 {
+			w.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return ew.End()
+}
+
+type jsonLinesWriter struct {
+	enc *json.Encoder
+}
+
+func newJSONLinesWriter(w io.Writer) exportWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &jsonLinesWriter{enc: enc}
+}
+
+func (w *jsonLinesWriter) Begin(chat *pkg.Chat) error { return nil }
+
+func (w *jsonLinesWriter) Write(msg *pkg.ExportedMessage) error { return w.enc.Encode(msg) }
+
+func (w *jsonLinesWriter) End() error { return nil }
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) exportWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (w *csvWriter) Begin(chat *pkg.Chat) error {
+	return w.w.Write([]string{"id", "created_at", "sender_id", "sender", "content", "attachments"})
+}
+
+func (w *csvWriter) Write(msg *pkg.ExportedMessage) error {
+	attachments := make([]string, len(msg.Attachments))
+	for i, a := range msg.Attachments {
+		attachments[i] = fmt.Sprintf("%s (%s, %d bytes, %s)", a.FileName, a.MimeType, a.Size, a.URL)
+	}
+	err := w.w.Write([]string{
+		strconv.Itoa(msg.Id),
+		msg.CreatedAt.Format(time.RFC3339),
+		strconv.Itoa(msg.SenderId),
+		csvCell(msg.Sender),
+		csvCell(msg.Content),
+		csvCell(strings.Join(attachments, "; ")),
+	})
+	if err != nil {
+		return err
+	}
+	// csv.Writer буферизует сам, сбрасываем его, чтобы данные доходили до клиента
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvWriter) End() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// csvCell экранирует текст, который табличный редактор принял бы за формулу
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+var transcriptTemplate = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"iso": func(t time.Time) string { return t.Format(time.RFC3339) },
+}).Parse(`
+{{- define "begin" -}}
+<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+body { font-family: sans-serif; max-width: 800px; margin: 2em auto; color: #222; }
+.message { padding: .5em 0; border-bottom: 1px solid #eee; }
+.sender { font-weight: bold; }
+time { color: #888; font-size: .85em; margin-left: .5em; }
+.content { white-space: pre-wrap; margin-top: .25em; }
+.attachments { margin: .25em 0 0; padding-left: 1.2em; font-size: .9em; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+{{end}}
+{{- define "message" -}}
+<div class="message" id="m{{.Id}}">
+<span class="sender">{{.Sender}}</span><time datetime="{{iso .CreatedAt}}">{{iso .CreatedAt}}</time>
+<div class="content">{{.Content}}</div>
+{{- if .Attachments}}
+<ul class="attachments">
+{{- range .Attachments}}
+<li>{{.FileName}} ({{.MimeType}}, {{.Size}} bytes)</li>
+{{- end}}
+</ul>
+{{- end}}
+</div>
+{{end}}
+{{- define "end" -}}
+</body>
+</html>
+{{end}}`))
+
+type htmlWriter struct {
+	w io.Writer
+}
+
+func newHTMLWriter(w io.Writer) exportWriter {
+	return &htmlWriter{w: w}
+}
+
+func (w *htmlWriter) Begin(chat *pkg.Chat) error {
+	return transcriptTemplate.ExecuteTemplate(w.w, "begin", chat)
+}
+
+func (w *htmlWriter) Write(msg *pkg.ExportedMessage) error {
+	return transcriptTemplate.ExecuteTemplate(w.w, "message", msg)
+}
+
+func (w *htmlWriter) End() error {
+	return transcriptTemplate.ExecuteTemplate(w.w, "end", nil)
+}
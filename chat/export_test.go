@@ -0,0 +1,175 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+)
+
+// exportOne прогоняет одно сообщение через писатель выгрузки
+func exportOne(t *testing.T, newWriter func(w io.Writer) exportWriter, chat *pkg.Chat, msg *pkg.ExportedMessage) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	if err := w.Begin(chat); err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := w.Write(msg); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := w.End(); err != nil {
+		t.Fatalf("end: %v", err)
+	}
+	return buf.String()
+}
+
+func exportedMessage(sender, content string, attachments ...pkg.ExportedAttachment) *pkg.ExportedMessage {
+	return &pkg.ExportedMessage{
+		Id:          7,
+		SenderId:    3,
+		Sender:      sender,
+		Content:     content,
+		CreatedAt:   time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC),
+		Attachments: attachments,
+	}
+}
+
+func TestJSONLinesExport(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *pkg.ExportedMessage
+	}{
+		{"plain message", exportedMessage("ann", "привет")},
+		{"html is not escaped", exportedMessage("ann", "<b>a & b</b>")},
+		{"line breaks stay inside one line", exportedMessage("ann", "первая\nвторая")},
+		{"attachments", exportedMessage("ann", "", pkg.ExportedAttachment{Id: 1, FileName: "a.png", MimeType: "image/png", Size: 10, URL: "/api/attachments/1"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := exportOne(t, newJSONLinesWriter, &pkg.Chat{Name: "chat"}, tt.msg)
+			lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+			if len(lines) != 1 {
+				t.Fatalf("expected one line, got %q", out)
+			}
+			var got pkg.ExportedMessage
+			if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+				t.Fatalf("decode %q: %v", lines[0], err)
+			}
+			if got.Content != tt.msg.Content || got.Sender != tt.msg.Sender || !got.CreatedAt.Equal(tt.msg.CreatedAt) ||
+				!slices.Equal(got.Attachments, tt.msg.Attachments) {
+				t.Fatalf("expected %+v, got %+v", tt.msg, got)
+			}
+			if strings.Contains(tt.msg.Content, "<") && !strings.Contains(out, tt.msg.Content) {
+				t.Fatalf("expected html to be kept as is, got %q", out)
+			}
+		})
+	}
+}
+
+func TestCSVExport(t *testing.T) {
+	attachment := pkg.ExportedAttachment{Id: 1, FileName: "a.png", MimeType: "image/png", Size: 10, URL: "/api/attachments/1"}
+	tests := []struct {
+		name        string
+		msg         *pkg.ExportedMessage
+		sender      string
+		content     string
+		attachments string
+	}{
+		{"plain message", exportedMessage("ann", "привет"), "ann", "привет", ""},
+		{"quotes and commas", exportedMessage("ann", `say "hi", bye`), "ann", `say "hi", bye`, ""},
+		{"line breaks", exportedMessage("ann", "первая\nвторая"), "ann", "первая\nвторая", ""},
+		{"formula", exportedMessage("ann", "=HYPERLINK(\"http://evil.io\")"), "ann", "'=HYPERLINK(\"http://evil.io\")", ""},
+		{"plus", exportedMessage("ann", "+1+1"), "ann", "'+1+1", ""},
+		{"minus", exportedMessage("ann", "-2+3"), "ann", "'-2+3", ""},
+		{"at sign", exportedMessage("ann", "@SUM(A1)"), "ann", "'@SUM(A1)", ""},
+		{"tab", exportedMessage("ann", "\t=1"), "ann", "'\t=1", ""},
+		{"formula in the middle", exportedMessage("ann", "1=1"), "ann", "1=1", ""},
+		{"sender name", exportedMessage("=cmd", "привет"), "'=cmd", "привет", ""},
+		{
+			"attachment name",
+			exportedMessage("ann", "", pkg.ExportedAttachment{Id: 2, FileName: "=a.png", MimeType: "image/png", Size: 1, URL: "/api/attachments/2"}),
+			"ann", "", "'=a.png (image/png, 1 bytes, /api/attachments/2)",
+		},
+		{
+			"several attachments",
+			exportedMessage("ann", "", attachment, attachment),
+			"ann", "", "a.png (image/png, 10 bytes, /api/attachments/1); a.png (image/png, 10 bytes, /api/attachments/1)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := exportOne(t, newCSVWriter, &pkg.Chat{Name: "chat"}, tt.msg)
+			records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+			if err != nil {
+				t.Fatalf("read csv %q: %v", out, err)
+			}
+			if len(records) != 2 {
+				t.Fatalf("expected header and one row, got %q", records)
+			}
+			want := []string{"7", "2025-05-01T12:00:00Z", "3", tt.sender, tt.content, tt.attachments}
+			if !slices.Equal(records[1], want) {
+				t.Fatalf("expected %q, got %q", want, records[1])
+			}
+		})
+	}
+}
+
+func TestHTMLExport(t *testing.T) {
+	tests := []struct {
+		name    string
+		chat    string
+		msg     *pkg.ExportedMessage
+		want    []string
+		missing []string
+	}{
+		{
+			name: "plain message",
+			chat: "Команда",
+			msg:  exportedMessage("ann", "привет"),
+			want: []string{"<title>Команда</title>", `<span class="sender">ann</span>`, `<div class="content">привет</div>`, `datetime="2025-05-01T12:00:00Z"`},
+		},
+		{
+			name:    "content is escaped",
+			chat:    "chat",
+			msg:     exportedMessage("ann", `<script>alert("x")</script>`),
+			want:    []string{"&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;"},
+			missing: []string{"<script>"},
+		},
+		{
+			name:    "sender and chat name are escaped",
+			chat:    "<i>chat</i>",
+			msg:     exportedMessage("<b>ann</b>", "привет"),
+			want:    []string{"<title>&lt;i&gt;chat&lt;/i&gt;</title>", "&lt;b&gt;ann&lt;/b&gt;"},
+			missing: []string{"<b>", "<i>"},
+		},
+		{
+			name:    "attachment name is escaped",
+			chat:    "chat",
+			msg:     exportedMessage("ann", "", pkg.ExportedAttachment{FileName: "<img src=x>", MimeType: "image/png", Size: 1}),
+			want:    []string{"<li>&lt;img src=x&gt; (image/png, 1 bytes)</li>"},
+			missing: []string{"<img"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := exportOne(t, newHTMLWriter, &pkg.Chat{Name: tt.chat}, tt.msg)
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Fatalf("expected %q in %q", s, out)
+				}
+			}
+			for _, s := range tt.missing {
+				if strings.Contains(out, s) {
+					t.Fatalf("unexpected %q in %q", s, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// fakeQuery отвечает на запрос к тестовой базе. Для Exec из ответа берётся только число
+// затронутых строк, без ответа считается, что затронута одна строка.
+// Начало и конец транзакции приходят запросами BEGIN, COMMIT и ROLLBACK
+type fakeQuery func(query string, args []driver.Value) (*fakeRows, error)
+
+// newFakeDB открывает базу, все запросы к которой обрабатывает fn
+func newFakeDB(t *testing.T, fn fakeQuery) *sqlx.DB {
+	t.Helper()
+	db := sqlx.NewDb(sql.OpenDB(fakeConnector{fn}), "postgres")
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// fakeRow возвращает одну строку с колонками columns
+func fakeRow(columns []string, values ...driver.Value) *fakeRows {
+	return &fakeRows{columns: columns, values: [][]driver.Value{values}}
+}
+
+// noRows — пустой результат, sqlx.Get вернёт sql.ErrNoRows
+func noRows(columns ...string) *fakeRows {
+	return &fakeRows{columns: columns}
+}
+
+// affectedRows — ответ на Exec, затронувший n строк
+func affectedRows(n int64) *fakeRows {
+	return &fakeRows{affected: n}
+}
+
+type fakeConnector struct {
+	fn fakeQuery
+}
+
+func (c fakeConnector) Connect(ctx context.Context) (driver.Conn, error) { return &fakeConn{fn: c.fn}, nil }
+
+func (c fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("fake driver is opened through a connector")
+}
+
+type fakeConn struct {
+	fn fakeQuery
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{fn: c.fn, query: query}, nil }
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	if _, err := c.fn("BEGIN", nil); err != nil {
+		return nil, err
+	}
+	return fakeTx{fn: c.fn}, nil
+}
+
+type fakeTx struct {
+	fn fakeQuery
+}
+
+func (tx fakeTx) Commit() error {
+	_, err := tx.fn("COMMIT", nil)
+	return err
+}
+
+func (tx fakeTx) Rollback() error {
+	_, err := tx.fn("ROLLBACK", nil)
+	return err
+}
+
+type fakeStmt struct {
+	fn    fakeQuery
+	query string
+}
+
+func (s *fakeStmt) Close() error { return nil }
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	rows, err := s.fn(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		return driver.RowsAffected(1), nil
+	}
+	return driver.RowsAffected(rows.affected), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, err := s.fn(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	if rows == nil {
+		rows = noRows()
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns  []string
+	values   [][]driver.Value
+	affected int64
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,75 @@
+package filter
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// BlocklistFilter ищет в сообщении запрещённые слова без учёта регистра
+type BlocklistFilter struct {
+	words map[string]bool
+}
+
+func NewBlocklistFilter(words []string) *BlocklistFilter {
+	f := &BlocklistFilter{words: make(map[string]bool, len(words))}
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			f.words[w] = true
+		}
+	}
+	return f
+}
+
+// LoadWordList читает список слов по одному в строке, строки с # пропускаются.
+// Отсутствующий файл означает пустой список.
+func LoadWordList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
+
+func (f *BlocklistFilter) Name() string {
+	return "blocklist"
+}
+
+func (f *BlocklistFilter) DefaultAction() Action {
+	return ActionMask
+}
+
+func (f *BlocklistFilter) Check(msg Message) *Hit {
+	found := false
+	masked := wordPattern.ReplaceAllStringFunc(msg.Content, func(word string) string {
+		if !f.words[strings.ToLower(word)] {
+			return word
+		}
+		found = true
+		return strings.Repeat("*", utf8.RuneCountInString(word))
+	})
+	if !found {
+		return nil
+	}
+	return &Hit{Reason: "message contains blocked words", Masked: masked}
+}
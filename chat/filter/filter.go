@@ -0,0 +1,122 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+)
+
+// Action определяет, что делать с сообщением, на котором сработал фильтр
+type Action string
+
+const (
+	ActionAllow  Action = "allow"
+	ActionReject Action = "reject"
+	ActionMask   Action = "mask"
+	ActionFlag   Action = "flag"
+)
+
+func (a Action) Valid() bool {
+	switch a {
+	case ActionAllow, ActionReject, ActionMask, ActionFlag:
+		return true
+	}
+	return false
+}
+
+type Message struct {
+	ChatId  int
+	UserId  int
+	Content string
+}
+
+// Hit описывает срабатывание фильтра
+type Hit struct {
+	Reason string
+	// Masked — текст с замаскированным нарушением, пустой, если фильтр не умеет маскировать
+	Masked string
+}
+
+type Filter interface {
+	Name() string
+	DefaultAction() Action
+	// Check возвращает nil, если сообщение не нарушает правило фильтра
+	Check(msg Message) *Hit
+}
+
+// Settings отдаёт действия фильтров, настроенные для конкретного чата
+type Settings interface {
+	Actions(ctx context.Context, chatId int) (map[string]Action, error)
+}
+
+type Flag struct {
+	Filter string
+	Reason string
+}
+
+type Result struct {
+	Content string
+	Flags   []Flag
+}
+
+type RejectedError struct {
+	Filter string
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("message rejected by %s filter: %s", e.Filter, e.Reason)
+}
+
+// Chain прогоняет сообщение через фильтры по порядку.
+// Замаскированный текст передаётся следующим фильтрам, первый reject прерывает цепочку.
+type Chain struct {
+	filters  []Filter
+	settings Settings
+}
+
+func NewChain(settings Settings, filters ...Filter) *Chain {
+	return &Chain{filters: filters, settings: settings}
+}
+
+func (c *Chain) Filters() []Filter {
+	return c.filters
+}
+
+func (c *Chain) Run(ctx context.Context, msg Message) (*Result, error) {
+	actions, err := c.settings.Actions(ctx, msg.ChatId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load filter settings: %w", err)
+	}
+
+	result := &Result{Content: msg.Content}
+	for _, f := range c.filters {
+		action, ok := actions[f.Name()]
+		if !ok {
+			action = f.DefaultAction()
+		}
+		if action == ActionAllow {
+			continue
+		}
+
+		msg.Content = result.Content
+		hit := f.Check(msg)
+		if hit == nil {
+			continue
+		}
+
+		switch action {
+		case ActionMask:
+			if hit.Masked != "" {
+				result.Content = hit.Masked
+				continue
+			}
+			return nil, &RejectedError{Filter: f.Name(), Reason: hit.Reason}
+		case ActionFlag:
+			result.Flags = append(result.Flags, Flag{Filter: f.Name(), Reason: hit.Reason})
+		default:
+			return nil, &RejectedError{Filter: f.Name(), Reason: hit.Reason}
+		}
+	}
+
+	return result, nil
+}
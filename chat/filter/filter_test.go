@@ -0,0 +1,220 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// staticSettings — настройки фильтров, одинаковые для всех чатов
+type staticSettings map[string]Action
+
+func (s staticSettings) Actions(ctx context.Context, chatId int) (map[string]Action, error) {
+	return s, nil
+}
+
+func TestBlocklistMasking(t *testing.T) {
+	f := NewBlocklistFilter([]string{" Спам ", "scam", ""})
+	tests := []struct {
+		content string
+		masked  string
+	}{
+		{"привет всем", ""},
+		{"это СПАМ, точно спам!", "это ****, точно ****!"},
+		{"scam-bot", "****-bot"},
+		{"scammer", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			hit := f.Check(Message{Content: tt.content})
+			if tt.masked == "" {
+				if hit != nil {
+					t.Fatalf("expected no hit, got %+v", hit)
+				}
+				return
+			}
+			if hit == nil || hit.Masked != tt.masked {
+				t.Fatalf("expected %q, got %+v", tt.masked, hit)
+			}
+		})
+	}
+}
+
+func TestLinkMasking(t *testing.T) {
+	f := NewLinkFilter("example.com")
+	tests := []struct {
+		content string
+		masked  string
+	}{
+		{"без ссылок", ""},
+		{"см. https://example.com/docs и docs.example.com", ""},
+		{"заходи на https://evil.io/x сегодня", "заходи на [link removed] сегодня"},
+		{"www.shop.ru и example.com", "[link removed] и example.com"},
+		{"notexample.com", "[link removed]"},
+		{"сайт.рф", "[link removed]"},
+		{"зайди на Пример.РФ/акции и смотри", "зайди на [link removed] и смотри"},
+		{"пиши на почта.рф, отвечу", "пиши на [link removed], отвечу"},
+		{"example.рф и example.com", "[link removed] и example.com"},
+		{"слово.рфия и example.community", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			hit := f.Check(Message{Content: tt.content})
+			if tt.masked == "" {
+				if hit != nil {
+					t.Fatalf("expected no hit, got %+v", hit)
+				}
+				return
+			}
+			if hit == nil || hit.Masked != tt.masked {
+				t.Fatalf("expected %q, got %+v", tt.masked, hit)
+			}
+		})
+	}
+}
+
+func TestLengthFilter(t *testing.T) {
+	f := NewLengthFilter(5)
+	if hit := f.Check(Message{Content: "12345"}); hit != nil {
+		t.Fatalf("expected message at the limit to pass, got %+v", hit)
+	}
+	// длина считается в символах, а не в байтах
+	if hit := f.Check(Message{Content: "пятьб"}); hit != nil {
+		t.Fatalf("expected 5 Cyrillic characters to pass, got %+v", hit)
+	}
+	hit := f.Check(Message{Content: "шестьб"})
+	if hit == nil || hit.Masked != "шест…" {
+		t.Fatalf("expected message cut to the limit, got %+v", hit)
+	}
+	if hit := NewLengthFilter(1).Check(Message{Content: "ab"}); hit == nil || hit.Masked != "…" {
+		t.Fatalf("expected message cut to an ellipsis, got %+v", hit)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a zero limit to panic")
+		}
+	}()
+	NewLengthFilter(0)
+}
+
+func TestFloodFilter(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	f := NewFloodFilter(2, 10*time.Second)
+	f.now = func() time.Time { return now }
+
+	msg := Message{ChatId: 1, UserId: 1}
+	for i := 0; i < 2; i++ {
+		if hit := f.Check(msg); hit != nil {
+			t.Fatalf("message %d: expected no hit, got %+v", i, hit)
+		}
+	}
+	if f.Check(msg) == nil {
+		t.Fatal("expected third message in the window to hit")
+	}
+	// лимит считается отдельно для каждого пользователя и чата
+	if hit := f.Check(Message{ChatId: 2, UserId: 1}); hit != nil {
+		t.Fatalf("expected other chat to pass, got %+v", hit)
+	}
+	if hit := f.Check(Message{ChatId: 1, UserId: 2}); hit != nil {
+		t.Fatalf("expected other user to pass, got %+v", hit)
+	}
+	now = now.Add(11 * time.Second)
+	if hit := f.Check(msg); hit != nil {
+		t.Fatalf("expected window to slide, got %+v", hit)
+	}
+}
+
+func TestChain(t *testing.T) {
+	chain := func(settings staticSettings) *Chain {
+		return NewChain(settings,
+			NewLengthFilter(20),
+			NewBlocklistFilter([]string{"спам"}),
+			NewLinkFilter(),
+		)
+	}
+	tests := []struct {
+		name     string
+		settings staticSettings
+		content  string
+		want     string
+		flags    []string
+		rejected string
+	}{
+		{
+			name:    "clean message passes unchanged",
+			content: "привет",
+			want:    "привет",
+		},
+		{
+			name:    "default actions mask and flag",
+			content: "спам на evil.io",
+			want:    "**** на evil.io",
+			flags:   []string{"links"},
+		},
+		{
+			name:     "masks of several filters add up",
+			settings: staticSettings{"links": ActionMask},
+			content:  "спам на evil.io",
+			want:     "**** на [link removed]",
+		},
+		{
+			name:     "allow skips the filter",
+			settings: staticSettings{"blocklist": ActionAllow},
+			content:  "спам",
+			want:     "спам",
+		},
+		{
+			name:     "reject stops the chain",
+			settings: staticSettings{"blocklist": ActionReject},
+			content:  "спам на evil.io",
+			rejected: "blocklist",
+		},
+		{
+			name:     "default reject",
+			content:  strings.Repeat("я", 21),
+			rejected: "max_length",
+		},
+		{
+			name:     "mask cuts a long message",
+			settings: staticSettings{"max_length": ActionMask, "blocklist": ActionAllow},
+			content:  strings.Repeat("я", 21),
+			want:     strings.Repeat("я", 19) + "…",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := chain(tt.settings).Run(context.Background(), Message{ChatId: 1, UserId: 1, Content: tt.content})
+			if tt.rejected != "" {
+				var rejected *RejectedError
+				if !errors.As(err, &rejected) || rejected.Filter != tt.rejected {
+					t.Fatalf("expected rejection by %s, got %v", tt.rejected, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if result.Content != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, result.Content)
+			}
+			var flags []string
+			for _, flag := range result.Flags {
+				flags = append(flags, flag.Filter)
+			}
+			if strings.Join(flags, ",") != strings.Join(tt.flags, ",") {
+				t.Fatalf("expected flags %v, got %v", tt.flags, flags)
+			}
+		})
+	}
+
+	// фильтр без маски отклоняет сообщение, даже если для него настроено маскирование
+	flood := NewChain(staticSettings{"flood": ActionMask}, NewFloodFilter(0, time.Second))
+	_, err := flood.Run(context.Background(), Message{ChatId: 1, UserId: 1, Content: "привет"})
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || rejected.Filter != "flood" {
+		t.Fatalf("expected rejection by flood, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package filter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type floodKey struct {
+	chatId int
+	userId int
+}
+
+// FloodFilter ограничивает число сообщений пользователя в чате за скользящее окно
+type FloodFilter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	sent      map[floodKey][]time.Time
+	lastSweep time.Time
+}
+
+func NewFloodFilter(limit int, window time.Duration) *FloodFilter {
+	return &FloodFilter{
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+		sent:   make(map[floodKey][]time.Time),
+	}
+}
+
+func (f *FloodFilter) Name() string {
+	return "flood"
+}
+
+func (f *FloodFilter) DefaultAction() Action {
+	return ActionReject
+}
+
+func (f *FloodFilter) Check(msg Message) *Hit {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	cutoff := now.Add(-f.window)
+	f.sweep(now, cutoff)
+
+	key := floodKey{chatId: msg.ChatId, userId: msg.UserId}
+	recent := f.sent[key][:0]
+	for _, t := range f.sent[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= f.limit {
+		f.sent[key] = recent
+		return &Hit{Reason: fmt.Sprintf("too many messages, limit is %d per %s", f.limit, f.window)}
+	}
+	f.sent[key] = append(recent, now)
+	return nil
+}
+
+// sweep раз в окно удаляет счётчики пользователей, которые давно не писали
+func (f *FloodFilter) sweep(now, cutoff time.Time) {
+	if now.Sub(f.lastSweep) < f.window {
+		return
+	}
+	f.lastSweep = now
+	for key, times := range f.sent {
+		if len(times) == 0 || !times[len(times)-1].After(cutoff) {
+			delete(f.sent, key)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package filter
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// LengthFilter ограничивает длину сообщения в символах
+type LengthFilter struct {
+	max int
+}
+
+// NewLengthFilter паникует при max < 1: такой лимит отклонял бы любое сообщение
+func NewLengthFilter(max int) *LengthFilter {
+	if max < 1 {
+		panic(fmt.Sprintf("filter: max length must be at least 1, got %d", max))
+	}
+	return &LengthFilter{max: max}
+}
+
+func (f *LengthFilter) Name() string {
+	return "max_length"
+}
+
+func (f *LengthFilter) DefaultAction() Action {
+	return ActionReject
+}
+
+func (f *LengthFilter) Check(msg Message) *Hit {
+	if utf8.RuneCountInString(msg.Content) <= f.max {
+		return nil
+	}
+	runes := []rune(msg.Content)
+	return &Hit{
+		Reason: fmt.Sprintf("message is longer than %d characters", f.max),
+		Masked: string(runes[:f.max-1]) + "…",
+	}
+}
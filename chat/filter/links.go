@@ -0,0 +1,67 @@
+package filter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// linkPattern находит ссылку в первой группе. \b в Go понимает только ASCII и не сработал бы
+// после .рф, поэтому конец домена проверяется за группой: дальше не должно идти буквы,
+// цифры или дефиса. Этот символ попадает в совпадение, но не маскируется
+var linkPattern = regexp.MustCompile(`(?i)(\b(?:https?://|www\.)[^\s]+|[\p{L}\p{N}-]+(?:\.[\p{L}\p{N}-]+)*\.(?:com|net|org|ru|su|io|me|gg|ly|info|xyz|рф)(?:/[^\s]*)?)(?:$|[^\p{L}\p{N}-])`)
+
+// LinkFilter находит ссылки на домены, не входящие в список разрешённых
+type LinkFilter struct {
+	allowed []string
+}
+
+func NewLinkFilter(allowedDomains ...string) *LinkFilter {
+	return &LinkFilter{allowed: allowedDomains}
+}
+
+func (f *LinkFilter) Name() string {
+	return "links"
+}
+
+func (f *LinkFilter) DefaultAction() Action {
+	return ActionFlag
+}
+
+func (f *LinkFilter) Check(msg Message) *Hit {
+	var (
+		masked strings.Builder
+		last   int
+		found  bool
+	)
+	for _, match := range linkPattern.FindAllStringSubmatchIndex(msg.Content, -1) {
+		start, end := match[2], match[3]
+		if f.isAllowed(msg.Content[start:end]) {
+			continue
+		}
+		masked.WriteString(msg.Content[last:start])
+		masked.WriteString("[link removed]")
+		last = end
+		found = true
+	}
+	if !found {
+		return nil
+	}
+	masked.WriteString(msg.Content[last:])
+	return &Hit{Reason: "message contains links", Masked: masked.String()}
+}
+
+func (f *LinkFilter) isAllowed(link string) bool {
+	host := strings.ToLower(link)
+	for _, prefix := range []string{"https://", "http://", "www."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+	if i := strings.IndexAny(host, "/?#:"); i >= 0 {
+		host = host[:i]
+	}
+	for _, domain := range f.allowed {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package chat
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/filter"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const filterSettingsTTL = 30 * time.Second
+
+type cachedActions struct {
+	actions  map[string]filter.Action
+	loadedAt time.Time
+}
+
+// FilterSettingsStore хранит действия фильтров по чатам в chat_filter_settings
+// и кэширует их, чтобы не ходить в базу на каждое сообщение
+type FilterSettingsStore struct {
+	db    *sqlx.DB
+	mu    sync.Mutex
+	cache map[int]cachedActions
+}
+
+func NewFilterSettingsStore(db *sqlx.DB) *FilterSettingsStore {
+	return &FilterSettingsStore{db: db, cache: make(map[int]cachedActions)}
+}
+
+func (s *FilterSettingsStore) Actions(ctx context.Context, chatId int) (map[string]filter.Action, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[chatId]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < filterSettingsTTL {
+		return cached.actions, nil
+	}
+
+	var rows []struct {
+		Filter string `db:"filter"`
+		Action string `db:"action"`
+	}
+	err := s.db.SelectContext(ctx, &rows, "SELECT filter, action FROM chat_filter_settings WHERE chat_id = $1", chatId)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := make(map[string]filter.Action, len(rows))
+	for _, row := range rows {
+		actions[row.Filter] = filter.Action(row.Action)
+	}
+
+	s.mu.Lock()
+	s.cache[chatId] = cachedActions{actions: actions, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return actions, nil
+}
+
+func (s *FilterSettingsStore) Set(ctx context.Context, chatId int, actions map[string]filter.Action) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for name, action := range actions {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO chat_filter_settings (chat_id, filter, action) VALUES ($1, $2, $3)
+             ON CONFLICT (chat_id, filter) DO UPDATE SET action = $3`,
+			chatId, name, action,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.cache, chatId)
+	s.mu.Unlock()
+	return nil
+}
+
+type FilterHandler struct {
+	db       *sqlx.DB
+	chain    *filter.Chain
+	settings *FilterSettingsStore
+}
+
+func NewFilterHandler(db *sqlx.DB, chain *filter.Chain, settings *FilterSettingsStore) *FilterHandler {
+	return &FilterHandler{db: db, chain: chain, settings: settings}
+}
+
+// Get возвращает действующие действия всех фильтров чата с учётом значений по умолчанию
+func (h *FilterHandler) Get(c *gin.Context) {
+	chatId, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	actions, err := h.settings.Actions(c.Request.Context(), chatId)
+	if err != nil {
+		log.Printf("Failed to load filter settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load filter settings"})
+		return
+	}
+
+	result := make(map[string]filter.Action, len(h.chain.Filters()))
+	for _, f := range h.chain.Filters() {
+		result[f.Name()] = f.DefaultAction()
+		if action, ok := actions[f.Name()]; ok {
+			result[f.Name()] = action
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Update задаёт действия фильтров чата, тело запроса — {"<filter>": "reject|mask|flag|allow"}
+func (h *FilterHandler) Update(c *gin.Context) {
+	chatId, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	var input map[string]filter.Action
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	known := make(map[string]bool)
+	for _, f := range h.chain.Filters() {
+		known[f.Name()] = true
+	}
+	for name, action := range input {
+		if !known[name] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown filter: " + name})
+			return
+		}
+		if !action.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid action: " + string(action)})
+			return
+		}
+	}
+
+	if err := h.settings.Set(c.Request.Context(), chatId, input); err != nil {
+		log.Printf("Failed to save filter settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save filter settings"})
+		return
+	}
+
+	h.Get(c)
+}
+
+func (h *FilterHandler) authorize(c *gin.Context) (int, bool) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return 0, false
+	}
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return 0, false
+	}
+	if err := requireAdmin(h.db, chatId, userId); err != nil {
+		moderationError(c, err)
+		return 0, false
+	}
+	return chatId, true
+}
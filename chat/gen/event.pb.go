@@ -0,0 +1,3418 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.21.12
+// source: proto/event.proto
+
+package gen
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Статусы очереди
+type QueueStatus int32
+
+const (
+	QueueStatus_QUEUE_STATUS_UNSPECIFIED QueueStatus = 0
+	QueueStatus_QUEUE_STATUS_WAITING     QueueStatus = 1
+	QueueStatus_QUEUE_STATUS_ACTIVE      QueueStatus = 2
+	QueueStatus_QUEUE_STATUS_COMPLETED   QueueStatus = 3
+	QueueStatus_QUEUE_STATUS_CANCELLED   QueueStatus = 4
+)
+
+// Enum value maps for QueueStatus.
+var (
+	QueueStatus_name = map[int32]string{
+		0: "QUEUE_STATUS_UNSPECIFIED",
+		1: "QUEUE_STATUS_WAITING",
+		2: "QUEUE_STATUS_ACTIVE",
+		3: "QUEUE_STATUS_COMPLETED",
+		4: "QUEUE_STATUS_CANCELLED",
+	}
+	QueueStatus_value = map[string]int32{
+		"QUEUE_STATUS_UNSPECIFIED": 0,
+		"QUEUE_STATUS_WAITING":     1,
+		"QUEUE_STATUS_ACTIVE":      2,
+		"QUEUE_STATUS_COMPLETED":   3,
+		"QUEUE_STATUS_CANCELLED":   4,
+	}
+)
+
+func (x QueueStatus) Enum() *QueueStatus {
+	p := new(QueueStatus)
+	*p = x
+	return p
+}
+
+func (x QueueStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QueueStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_event_proto_enumTypes[0].Descriptor()
+}
+
+func (QueueStatus) Type() protoreflect.EnumType {
+	return &file_proto_event_proto_enumTypes[0]
+}
+
+func (x QueueStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QueueStatus.Descriptor instead.
+func (QueueStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{0}
+}
+
+// Запрос на обработку события
+type ProcessEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Source        string                 `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessEventRequest) Reset() {
+	*x = ProcessEventRequest{}
+	mi := &file_proto_event_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessEventRequest) ProtoMessage() {}
+
+func (x *ProcessEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessEventRequest.ProtoReflect.Descriptor instead.
+func (*ProcessEventRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ProcessEventRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ProcessEventRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *ProcessEventRequest) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+// Ответ на обработку события
+type ProcessEventResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessEventResponse) Reset() {
+	*x = ProcessEventResponse{}
+	mi := &file_proto_event_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessEventResponse) ProtoMessage() {}
+
+func (x *ProcessEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessEventResponse.ProtoReflect.Descriptor instead.
+func (*ProcessEventResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ProcessEventResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ProcessEventResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// Запрос на получение статуса события
+type GetEventStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventStatusRequest) Reset() {
+	*x = GetEventStatusRequest{}
+	mi := &file_proto_event_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventStatusRequest) ProtoMessage() {}
+
+func (x *GetEventStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetEventStatusRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetEventStatusRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Ответ с статусом события
+type GetEventStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     string                 `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventStatusResponse) Reset() {
+	*x = GetEventStatusResponse{}
+	mi := &file_proto_event_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventStatusResponse) ProtoMessage() {}
+
+func (x *GetEventStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetEventStatusResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetEventStatusResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetEventStatusResponse) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *GetEventStatusResponse) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *GetEventStatusResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GetEventStatusResponse) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *GetEventStatusResponse) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+// Запись в очереди событий
+type EventQueue struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	EventId   string                 `protobuf:"bytes,2,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId    string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status    string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	Position  int32                  `protobuf:"varint,5,opt,name=position,proto3" json:"position,omitempty"`
+	CreatedAt string                 `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt string                 `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// срок, до которого вызванный пользователь должен подойти; пусто, если срока нет
+	ExpiresAt string `protobuf:"bytes,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// полоса приоритета, в которой стоит запись
+	Lane string `protobuf:"bytes,9,opt,name=lane,proto3" json:"lane,omitempty"`
+	// стойка, к которой вызван пользователь, и время вызова
+	CounterId string `protobuf:"bytes,10,opt,name=counter_id,json=counterId,proto3" json:"counter_id,omitempty"`
+	CalledAt  string `protobuf:"bytes,11,opt,name=called_at,json=calledAt,proto3" json:"called_at,omitempty"`
+	// оценка ожидания до вызова по недавним обслуживаниям и числу стоек
+	EstimatedWaitSeconds int64 `protobuf:"varint,12,opt,name=estimated_wait_seconds,json=estimatedWaitSeconds,proto3" json:"estimated_wait_seconds,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *EventQueue) Reset() {
+	*x = EventQueue{}
+	mi := &file_proto_event_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventQueue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventQueue) ProtoMessage() {}
+
+func (x *EventQueue) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventQueue.ProtoReflect.Descriptor instead.
+func (*EventQueue) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *EventQueue) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *EventQueue) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *EventQueue) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *EventQueue) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *EventQueue) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *EventQueue) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *EventQueue) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+func (x *EventQueue) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *EventQueue) GetLane() string {
+	if x != nil {
+		return x.Lane
+	}
+	return ""
+}
+
+func (x *EventQueue) GetCounterId() string {
+	if x != nil {
+		return x.CounterId
+	}
+	return ""
+}
+
+func (x *EventQueue) GetCalledAt() string {
+	if x != nil {
+		return x.CalledAt
+	}
+	return ""
+}
+
+func (x *EventQueue) GetEstimatedWaitSeconds() int64 {
+	if x != nil {
+		return x.EstimatedWaitSeconds
+	}
+	return 0
+}
+
+// Запрос на добавление в очередь
+type JoinQueueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinQueueRequest) Reset() {
+	*x = JoinQueueRequest{}
+	mi := &file_proto_event_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinQueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinQueueRequest) ProtoMessage() {}
+
+func (x *JoinQueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinQueueRequest.ProtoReflect.Descriptor instead.
+func (*JoinQueueRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *JoinQueueRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *JoinQueueRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// Ответ на добавление в очередь
+type JoinQueueResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	QueueId string                 `protobuf:"bytes,1,opt,name=queue_id,json=queueId,proto3" json:"queue_id,omitempty"`
+	// место в живой очереди, а для статуса waitlisted — в листе ожидания
+	Position int32  `protobuf:"varint,2,opt,name=position,proto3" json:"position,omitempty"`
+	Status   string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	// оценка ожидания до вызова; 0, пока не было ни одного обслуживания
+	EstimatedWaitSeconds int64 `protobuf:"varint,4,opt,name=estimated_wait_seconds,json=estimatedWaitSeconds,proto3" json:"estimated_wait_seconds,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *JoinQueueResponse) Reset() {
+	*x = JoinQueueResponse{}
+	mi := &file_proto_event_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinQueueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinQueueResponse) ProtoMessage() {}
+
+func (x *JoinQueueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinQueueResponse.ProtoReflect.Descriptor instead.
+func (*JoinQueueResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *JoinQueueResponse) GetQueueId() string {
+	if x != nil {
+		return x.QueueId
+	}
+	return ""
+}
+
+func (x *JoinQueueResponse) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *JoinQueueResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *JoinQueueResponse) GetEstimatedWaitSeconds() int64 {
+	if x != nil {
+		return x.EstimatedWaitSeconds
+	}
+	return 0
+}
+
+// Запрос на выход из очереди
+type LeaveQueueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaveQueueRequest) Reset() {
+	*x = LeaveQueueRequest{}
+	mi := &file_proto_event_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaveQueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaveQueueRequest) ProtoMessage() {}
+
+func (x *LeaveQueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaveQueueRequest.ProtoReflect.Descriptor instead.
+func (*LeaveQueueRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LeaveQueueRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *LeaveQueueRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// Ответ на выход из очереди
+type LeaveQueueResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaveQueueResponse) Reset() {
+	*x = LeaveQueueResponse{}
+	mi := &file_proto_event_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaveQueueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaveQueueResponse) ProtoMessage() {}
+
+func (x *LeaveQueueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaveQueueResponse.ProtoReflect.Descriptor instead.
+func (*LeaveQueueResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LeaveQueueResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+// Запрос на получение статуса очереди
+type GetQueueStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetQueueStatusRequest) Reset() {
+	*x = GetQueueStatusRequest{}
+	mi := &file_proto_event_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetQueueStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetQueueStatusRequest) ProtoMessage() {}
+
+func (x *GetQueueStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetQueueStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetQueueStatusRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetQueueStatusRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+// Ответ с статусом очереди
+type GetQueueStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Queues        []*EventQueue          `protobuf:"bytes,1,rep,name=queues,proto3" json:"queues,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetQueueStatusResponse) Reset() {
+	*x = GetQueueStatusResponse{}
+	mi := &file_proto_event_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetQueueStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetQueueStatusResponse) ProtoMessage() {}
+
+func (x *GetQueueStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetQueueStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetQueueStatusResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetQueueStatusResponse) GetQueues() []*EventQueue {
+	if x != nil {
+		return x.Queues
+	}
+	return nil
+}
+
+// Запрос на получение позиции пользователя
+type GetUserPositionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserPositionRequest) Reset() {
+	*x = GetUserPositionRequest{}
+	mi := &file_proto_event_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserPositionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserPositionRequest) ProtoMessage() {}
+
+func (x *GetUserPositionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserPositionRequest.ProtoReflect.Descriptor instead.
+func (*GetUserPositionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetUserPositionRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *GetUserPositionRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// Ответ с позицией пользователя
+type GetUserPositionResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Position int32                  `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`
+	Status   string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	// оценка ожидания до вызова; 0, пока не было ни одного обслуживания
+	EstimatedWaitSeconds int64 `protobuf:"varint,3,opt,name=estimated_wait_seconds,json=estimatedWaitSeconds,proto3" json:"estimated_wait_seconds,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *GetUserPositionResponse) Reset() {
+	*x = GetUserPositionResponse{}
+	mi := &file_proto_event_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserPositionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserPositionResponse) ProtoMessage() {}
+
+func (x *GetUserPositionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserPositionResponse.ProtoReflect.Descriptor instead.
+func (*GetUserPositionResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetUserPositionResponse) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *GetUserPositionResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GetUserPositionResponse) GetEstimatedWaitSeconds() int64 {
+	if x != nil {
+		return x.EstimatedWaitSeconds
+	}
+	return 0
+}
+
+// Запрос на обработку следующего в очереди
+type ProcessNextRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	EventId string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	// стойка, к которой вызывается пользователь; одна стойка обслуживает одного человека за раз
+	CounterId string `protobuf:"bytes,2,opt,name=counter_id,json=counterId,proto3" json:"counter_id,omitempty"`
+	// организатор, который вызывает следующего
+	UserId        string `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessNextRequest) Reset() {
+	*x = ProcessNextRequest{}
+	mi := &file_proto_event_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessNextRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessNextRequest) ProtoMessage() {}
+
+func (x *ProcessNextRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessNextRequest.ProtoReflect.Descriptor instead.
+func (*ProcessNextRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ProcessNextRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *ProcessNextRequest) GetCounterId() string {
+	if x != nil {
+		return x.CounterId
+	}
+	return ""
+}
+
+func (x *ProcessNextRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// Ответ на обработку следующего в очереди
+type ProcessNextResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Queue         *EventQueue            `protobuf:"bytes,1,opt,name=queue,proto3" json:"queue,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessNextResponse) Reset() {
+	*x = ProcessNextResponse{}
+	mi := &file_proto_event_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessNextResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessNextResponse) ProtoMessage() {}
+
+func (x *ProcessNextResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessNextResponse.ProtoReflect.Descriptor instead.
+func (*ProcessNextResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ProcessNextResponse) GetQueue() *EventQueue {
+	if x != nil {
+		return x.Queue
+	}
+	return nil
+}
+
+// Запрос на закрытие очереди
+type CloseQueueRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	EventId string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	// организатор мероприятия
+	UserId        string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseQueueRequest) Reset() {
+	*x = CloseQueueRequest{}
+	mi := &file_proto_event_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseQueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseQueueRequest) ProtoMessage() {}
+
+func (x *CloseQueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseQueueRequest.ProtoReflect.Descriptor instead.
+func (*CloseQueueRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CloseQueueRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *CloseQueueRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// Ответ на закрытие очереди
+type CloseQueueResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Success bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	// closed или drained, если в очереди уже никого нет
+	State         string `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseQueueResponse) Reset() {
+	*x = CloseQueueResponse{}
+	mi := &file_proto_event_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseQueueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseQueueResponse) ProtoMessage() {}
+
+func (x *CloseQueueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseQueueResponse.ProtoReflect.Descriptor instead.
+func (*CloseQueueResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CloseQueueResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *CloseQueueResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+// Запрос на подписку на изменения записи пользователя в очереди
+type WatchQueueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchQueueRequest) Reset() {
+	*x = WatchQueueRequest{}
+	mi := &file_proto_event_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchQueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchQueueRequest) ProtoMessage() {}
+
+func (x *WatchQueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchQueueRequest.ProtoReflect.Descriptor instead.
+func (*WatchQueueRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WatchQueueRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *WatchQueueRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// Ограничения очереди мероприятия, 0 — без ограничения
+type QueueSettings struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// сколько человек стоит в живой очереди, остальные попадают в лист ожидания
+	MaxActive int32 `protobuf:"varint,1,opt,name=max_active,json=maxActive,proto3" json:"max_active,omitempty"`
+	// сколько человек всего ждёт в живой очереди и в листе ожидания
+	MaxTotal int32 `protobuf:"varint,2,opt,name=max_total,json=maxTotal,proto3" json:"max_total,omitempty"`
+	// сколько раз один пользователь может встать в очередь
+	MaxPerUser int32 `protobuf:"varint,3,opt,name=max_per_user,json=maxPerUser,proto3" json:"max_per_user,omitempty"`
+	// сколько секунд вызванный пользователь может не подходить, прежде чем его пропустят
+	TurnTimeoutSeconds int32 `protobuf:"varint,4,opt,name=turn_timeout_seconds,json=turnTimeoutSeconds,proto3" json:"turn_timeout_seconds,omitempty"`
+	// чередование полос: strict — строгий приоритет, weighted — взвешенный круговой обход
+	Policy string `protobuf:"bytes,5,opt,name=policy,proto3" json:"policy,omitempty"`
+	// полосы приоритета по убыванию; без них очередь обслуживается по порядку вступления
+	Lanes []*QueueLane `protobuf:"bytes,6,rep,name=lanes,proto3" json:"lanes,omitempty"`
+	// стойки, к которым вызывают пользователей; пустой список — подходит любая стойка
+	Counters      []string `protobuf:"bytes,7,rep,name=counters,proto3" json:"counters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueueSettings) Reset() {
+	*x = QueueSettings{}
+	mi := &file_proto_event_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueueSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueueSettings) ProtoMessage() {}
+
+func (x *QueueSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueueSettings.ProtoReflect.Descriptor instead.
+func (*QueueSettings) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *QueueSettings) GetMaxActive() int32 {
+	if x != nil {
+		return x.MaxActive
+	}
+	return 0
+}
+
+func (x *QueueSettings) GetMaxTotal() int32 {
+	if x != nil {
+		return x.MaxTotal
+	}
+	return 0
+}
+
+func (x *QueueSettings) GetMaxPerUser() int32 {
+	if x != nil {
+		return x.MaxPerUser
+	}
+	return 0
+}
+
+func (x *QueueSettings) GetTurnTimeoutSeconds() int32 {
+	if x != nil {
+		return x.TurnTimeoutSeconds
+	}
+	return 0
+}
+
+func (x *QueueSettings) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *QueueSettings) GetLanes() []*QueueLane {
+	if x != nil {
+		return x.Lanes
+	}
+	return nil
+}
+
+func (x *QueueSettings) GetCounters() []string {
+	if x != nil {
+		return x.Counters
+	}
+	return nil
+}
+
+// Полоса приоритета, например staff, accessibility или vip
+type QueueLane struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// сколько человек подряд вызывается из полосы при взвешенном обходе
+	Weight        int32 `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueueLane) Reset() {
+	*x = QueueLane{}
+	mi := &file_proto_event_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueueLane) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueueLane) ProtoMessage() {}
+
+func (x *QueueLane) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueueLane.ProtoReflect.Descriptor instead.
+func (*QueueLane) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *QueueLane) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *QueueLane) GetWeight() int32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+type UpdateQueueSettingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Settings      *QueueSettings         `protobuf:"bytes,3,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateQueueSettingsRequest) Reset() {
+	*x = UpdateQueueSettingsRequest{}
+	mi := &file_proto_event_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateQueueSettingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateQueueSettingsRequest) ProtoMessage() {}
+
+func (x *UpdateQueueSettingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateQueueSettingsRequest.ProtoReflect.Descriptor instead.
+func (*UpdateQueueSettingsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *UpdateQueueSettingsRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *UpdateQueueSettingsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UpdateQueueSettingsRequest) GetSettings() *QueueSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+// Текущее состояние записи пользователя в очереди
+type QueueUpdate struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	EventId              string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId               string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status               string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Position             int32                  `protobuf:"varint,4,opt,name=position,proto3" json:"position,omitempty"`
+	EstimatedWaitSeconds int64                  `protobuf:"varint,5,opt,name=estimated_wait_seconds,json=estimatedWaitSeconds,proto3" json:"estimated_wait_seconds,omitempty"`
+	UpdatedAt            string                 `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	ExpiresAt            string                 `protobuf:"bytes,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	CounterId            string                 `protobuf:"bytes,8,opt,name=counter_id,json=counterId,proto3" json:"counter_id,omitempty"`
+	// состояние очереди: на паузе вызовов не будет
+	QueueState    string `protobuf:"bytes,9,opt,name=queue_state,json=queueState,proto3" json:"queue_state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueueUpdate) Reset() {
+	*x = QueueUpdate{}
+	mi := &file_proto_event_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueueUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueueUpdate) ProtoMessage() {}
+
+func (x *QueueUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueueUpdate.ProtoReflect.Descriptor instead.
+func (*QueueUpdate) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *QueueUpdate) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *QueueUpdate) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *QueueUpdate) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *QueueUpdate) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *QueueUpdate) GetEstimatedWaitSeconds() int64 {
+	if x != nil {
+		return x.EstimatedWaitSeconds
+	}
+	return 0
+}
+
+func (x *QueueUpdate) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+func (x *QueueUpdate) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *QueueUpdate) GetCounterId() string {
+	if x != nil {
+		return x.CounterId
+	}
+	return ""
+}
+
+func (x *QueueUpdate) GetQueueState() string {
+	if x != nil {
+		return x.QueueState
+	}
+	return ""
+}
+
+type AssignLaneRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	EventId string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId  string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// полоса приоритета из настроек очереди; пусто — общая, наименее приоритетная
+	Lane string `protobuf:"bytes,3,opt,name=lane,proto3" json:"lane,omitempty"`
+	// организатор, который назначает полосу
+	OperatorId    string `protobuf:"bytes,4,opt,name=operator_id,json=operatorId,proto3" json:"operator_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssignLaneRequest) Reset() {
+	*x = AssignLaneRequest{}
+	mi := &file_proto_event_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssignLaneRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssignLaneRequest) ProtoMessage() {}
+
+func (x *AssignLaneRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssignLaneRequest.ProtoReflect.Descriptor instead.
+func (*AssignLaneRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *AssignLaneRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *AssignLaneRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *AssignLaneRequest) GetLane() string {
+	if x != nil {
+		return x.Lane
+	}
+	return ""
+}
+
+func (x *AssignLaneRequest) GetOperatorId() string {
+	if x != nil {
+		return x.OperatorId
+	}
+	return ""
+}
+
+type AssignLaneResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Queue         *EventQueue            `protobuf:"bytes,1,opt,name=queue,proto3" json:"queue,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssignLaneResponse) Reset() {
+	*x = AssignLaneResponse{}
+	mi := &file_proto_event_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssignLaneResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssignLaneResponse) ProtoMessage() {}
+
+func (x *AssignLaneResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssignLaneResponse.ProtoReflect.Descriptor instead.
+func (*AssignLaneResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *AssignLaneResponse) GetQueue() *EventQueue {
+	if x != nil {
+		return x.Queue
+	}
+	return nil
+}
+
+type CompleteEntryRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	EventId string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId  string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// организатор, который завершает обслуживание
+	OperatorId    string `protobuf:"bytes,3,opt,name=operator_id,json=operatorId,proto3" json:"operator_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompleteEntryRequest) Reset() {
+	*x = CompleteEntryRequest{}
+	mi := &file_proto_event_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompleteEntryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteEntryRequest) ProtoMessage() {}
+
+func (x *CompleteEntryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteEntryRequest.ProtoReflect.Descriptor instead.
+func (*CompleteEntryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CompleteEntryRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *CompleteEntryRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CompleteEntryRequest) GetOperatorId() string {
+	if x != nil {
+		return x.OperatorId
+	}
+	return ""
+}
+
+type CompleteEntryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Queue         *EventQueue            `protobuf:"bytes,1,opt,name=queue,proto3" json:"queue,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompleteEntryResponse) Reset() {
+	*x = CompleteEntryResponse{}
+	mi := &file_proto_event_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompleteEntryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteEntryResponse) ProtoMessage() {}
+
+func (x *CompleteEntryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteEntryResponse.ProtoReflect.Descriptor instead.
+func (*CompleteEntryResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *CompleteEntryResponse) GetQueue() *EventQueue {
+	if x != nil {
+		return x.Queue
+	}
+	return nil
+}
+
+type SkipEntryRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	EventId string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId  string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// организатор, который пропускает неявившегося
+	OperatorId    string `protobuf:"bytes,3,opt,name=operator_id,json=operatorId,proto3" json:"operator_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SkipEntryRequest) Reset() {
+	*x = SkipEntryRequest{}
+	mi := &file_proto_event_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SkipEntryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SkipEntryRequest) ProtoMessage() {}
+
+func (x *SkipEntryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SkipEntryRequest.ProtoReflect.Descriptor instead.
+func (*SkipEntryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *SkipEntryRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *SkipEntryRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SkipEntryRequest) GetOperatorId() string {
+	if x != nil {
+		return x.OperatorId
+	}
+	return ""
+}
+
+// Ответ с пропущенной записью и вызванным вместо неё пользователем, если он есть
+type SkipEntryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Skipped       *EventQueue            `protobuf:"bytes,1,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	Next          *EventQueue            `protobuf:"bytes,2,opt,name=next,proto3" json:"next,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SkipEntryResponse) Reset() {
+	*x = SkipEntryResponse{}
+	mi := &file_proto_event_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SkipEntryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SkipEntryResponse) ProtoMessage() {}
+
+func (x *SkipEntryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SkipEntryResponse.ProtoReflect.Descriptor instead.
+func (*SkipEntryResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *SkipEntryResponse) GetSkipped() *EventQueue {
+	if x != nil {
+		return x.Skipped
+	}
+	return nil
+}
+
+func (x *SkipEntryResponse) GetNext() *EventQueue {
+	if x != nil {
+		return x.Next
+	}
+	return nil
+}
+
+type GetCheckInTokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCheckInTokenRequest) Reset() {
+	*x = GetCheckInTokenRequest{}
+	mi := &file_proto_event_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCheckInTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCheckInTokenRequest) ProtoMessage() {}
+
+func (x *GetCheckInTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCheckInTokenRequest.ProtoReflect.Descriptor instead.
+func (*GetCheckInTokenRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetCheckInTokenRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *GetCheckInTokenRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// Подписанный токен записи и он же в виде QR-кода (PNG)
+type GetCheckInTokenResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	QrImage       []byte                 `protobuf:"bytes,2,opt,name=qr_image,json=qrImage,proto3" json:"qr_image,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCheckInTokenResponse) Reset() {
+	*x = GetCheckInTokenResponse{}
+	mi := &file_proto_event_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCheckInTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCheckInTokenResponse) ProtoMessage() {}
+
+func (x *GetCheckInTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCheckInTokenResponse.ProtoReflect.Descriptor instead.
+func (*GetCheckInTokenResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *GetCheckInTokenResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *GetCheckInTokenResponse) GetQrImage() []byte {
+	if x != nil {
+		return x.QrImage
+	}
+	return nil
+}
+
+// Нужно передать либо токен, либо фотографию QR-кода
+type CheckInRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Token string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Photo []byte                 `protobuf:"bytes,2,opt,name=photo,proto3" json:"photo,omitempty"`
+	// стойка, у которой отметился пользователь
+	CounterId string `protobuf:"bytes,3,opt,name=counter_id,json=counterId,proto3" json:"counter_id,omitempty"`
+	// организатор, который отмечает прибытие
+	UserId        string `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckInRequest) Reset() {
+	*x = CheckInRequest{}
+	mi := &file_proto_event_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckInRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckInRequest) ProtoMessage() {}
+
+func (x *CheckInRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckInRequest.ProtoReflect.Descriptor instead.
+func (*CheckInRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *CheckInRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *CheckInRequest) GetPhoto() []byte {
+	if x != nil {
+		return x.Photo
+	}
+	return nil
+}
+
+func (x *CheckInRequest) GetCounterId() string {
+	if x != nil {
+		return x.CounterId
+	}
+	return ""
+}
+
+func (x *CheckInRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type CheckInResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Queue         *EventQueue            `protobuf:"bytes,1,opt,name=queue,proto3" json:"queue,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckInResponse) Reset() {
+	*x = CheckInResponse{}
+	mi := &file_proto_event_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckInResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckInResponse) ProtoMessage() {}
+
+func (x *CheckInResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckInResponse.ProtoReflect.Descriptor instead.
+func (*CheckInResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *CheckInResponse) GetQueue() *EventQueue {
+	if x != nil {
+		return x.Queue
+	}
+	return nil
+}
+
+type Event struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type        string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Source      string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	Payload     []byte                 `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	Status      string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt   string                 `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt   string                 `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Title       string                 `protobuf:"bytes,8,opt,name=title,proto3" json:"title,omitempty"`
+	Description string                 `protobuf:"bytes,9,opt,name=description,proto3" json:"description,omitempty"`
+	Venue       string                 `protobuf:"bytes,10,opt,name=venue,proto3" json:"venue,omitempty"`
+	StartsAt    string                 `protobuf:"bytes,11,opt,name=starts_at,json=startsAt,proto3" json:"starts_at,omitempty"`
+	EndsAt      string                 `protobuf:"bytes,12,opt,name=ends_at,json=endsAt,proto3" json:"ends_at,omitempty"`
+	Timezone    string                 `protobuf:"bytes,13,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	// 0 — без ограничения
+	Capacity    int32  `protobuf:"varint,14,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	OrganizerId string `protobuf:"bytes,15,opt,name=organizer_id,json=organizerId,proto3" json:"organizer_id,omitempty"`
+	// public или private
+	Visibility string `protobuf:"bytes,16,opt,name=visibility,proto3" json:"visibility,omitempty"`
+	// draft, published, ongoing, finished или cancelled
+	State         string         `protobuf:"bytes,17,opt,name=state,proto3" json:"state,omitempty"`
+	QueueSettings *QueueSettings `protobuf:"bytes,19,opt,name=queue_settings,json=queueSettings,proto3" json:"queue_settings,omitempty"`
+	// open, paused, closed, drained или archived
+	QueueState    string `protobuf:"bytes,20,opt,name=queue_state,json=queueState,proto3" json:"queue_state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_proto_event_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *Event) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Event) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Event) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Event) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *Event) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+func (x *Event) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Event) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Event) GetVenue() string {
+	if x != nil {
+		return x.Venue
+	}
+	return ""
+}
+
+func (x *Event) GetStartsAt() string {
+	if x != nil {
+		return x.StartsAt
+	}
+	return ""
+}
+
+func (x *Event) GetEndsAt() string {
+	if x != nil {
+		return x.EndsAt
+	}
+	return ""
+}
+
+func (x *Event) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *Event) GetCapacity() int32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+func (x *Event) GetOrganizerId() string {
+	if x != nil {
+		return x.OrganizerId
+	}
+	return ""
+}
+
+func (x *Event) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+func (x *Event) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Event) GetQueueSettings() *QueueSettings {
+	if x != nil {
+		return x.QueueSettings
+	}
+	return nil
+}
+
+func (x *Event) GetQueueState() string {
+	if x != nil {
+		return x.QueueState
+	}
+	return ""
+}
+
+// Редактируемые поля мероприятия, время — в RFC 3339
+type EventDetails struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Venue         string                 `protobuf:"bytes,3,opt,name=venue,proto3" json:"venue,omitempty"`
+	StartsAt      string                 `protobuf:"bytes,4,opt,name=starts_at,json=startsAt,proto3" json:"starts_at,omitempty"`
+	EndsAt        string                 `protobuf:"bytes,5,opt,name=ends_at,json=endsAt,proto3" json:"ends_at,omitempty"`
+	Timezone      string                 `protobuf:"bytes,6,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	Capacity      int32                  `protobuf:"varint,7,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	Visibility    string                 `protobuf:"bytes,8,opt,name=visibility,proto3" json:"visibility,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventDetails) Reset() {
+	*x = EventDetails{}
+	mi := &file_proto_event_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventDetails) ProtoMessage() {}
+
+func (x *EventDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventDetails.ProtoReflect.Descriptor instead.
+func (*EventDetails) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *EventDetails) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *EventDetails) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *EventDetails) GetVenue() string {
+	if x != nil {
+		return x.Venue
+	}
+	return ""
+}
+
+func (x *EventDetails) GetStartsAt() string {
+	if x != nil {
+		return x.StartsAt
+	}
+	return ""
+}
+
+func (x *EventDetails) GetEndsAt() string {
+	if x != nil {
+		return x.EndsAt
+	}
+	return ""
+}
+
+func (x *EventDetails) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *EventDetails) GetCapacity() int32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+func (x *EventDetails) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+type CreateEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrganizerId   string                 `protobuf:"bytes,1,opt,name=organizer_id,json=organizerId,proto3" json:"organizer_id,omitempty"`
+	Details       *EventDetails          `protobuf:"bytes,2,opt,name=details,proto3" json:"details,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateEventRequest) Reset() {
+	*x = CreateEventRequest{}
+	mi := &file_proto_event_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateEventRequest) ProtoMessage() {}
+
+func (x *CreateEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateEventRequest.ProtoReflect.Descriptor instead.
+func (*CreateEventRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *CreateEventRequest) GetOrganizerId() string {
+	if x != nil {
+		return x.OrganizerId
+	}
+	return ""
+}
+
+func (x *CreateEventRequest) GetDetails() *EventDetails {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+type UpdateEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Details       *EventDetails          `protobuf:"bytes,3,opt,name=details,proto3" json:"details,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateEventRequest) Reset() {
+	*x = UpdateEventRequest{}
+	mi := &file_proto_event_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateEventRequest) ProtoMessage() {}
+
+func (x *UpdateEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateEventRequest.ProtoReflect.Descriptor instead.
+func (*UpdateEventRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *UpdateEventRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateEventRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UpdateEventRequest) GetDetails() *EventDetails {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+type GetEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventRequest) Reset() {
+	*x = GetEventRequest{}
+	mi := &file_proto_event_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventRequest) ProtoMessage() {}
+
+func (x *GetEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventRequest.ProtoReflect.Descriptor instead.
+func (*GetEventRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *GetEventRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetEventRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ListEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEventsRequest) Reset() {
+	*x = ListEventsRequest{}
+	mi := &file_proto_event_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEventsRequest) ProtoMessage() {}
+
+func (x *ListEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEventsRequest.ProtoReflect.Descriptor instead.
+func (*ListEventsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ListEventsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ListEventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEventsResponse) Reset() {
+	*x = ListEventsResponse{}
+	mi := &file_proto_event_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEventsResponse) ProtoMessage() {}
+
+func (x *ListEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEventsResponse.ProtoReflect.Descriptor instead.
+func (*ListEventsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *ListEventsResponse) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type ChangeEventStateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	State         string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeEventStateRequest) Reset() {
+	*x = ChangeEventStateRequest{}
+	mi := &file_proto_event_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeEventStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeEventStateRequest) ProtoMessage() {}
+
+func (x *ChangeEventStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeEventStateRequest.ProtoReflect.Descriptor instead.
+func (*ChangeEventStateRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ChangeEventStateRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ChangeEventStateRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ChangeEventStateRequest) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+type GetAllEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAllEventsRequest) Reset() {
+	*x = GetAllEventsRequest{}
+	mi := &file_proto_event_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAllEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAllEventsRequest) ProtoMessage() {}
+
+func (x *GetAllEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAllEventsRequest.ProtoReflect.Descriptor instead.
+func (*GetAllEventsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{40}
+}
+
+type GetAllEventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAllEventsResponse) Reset() {
+	*x = GetAllEventsResponse{}
+	mi := &file_proto_event_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAllEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAllEventsResponse) ProtoMessage() {}
+
+func (x *GetAllEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAllEventsResponse.ProtoReflect.Descriptor instead.
+func (*GetAllEventsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *GetAllEventsResponse) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type GetCounterStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCounterStatsRequest) Reset() {
+	*x = GetCounterStatsRequest{}
+	mi := &file_proto_event_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCounterStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCounterStatsRequest) ProtoMessage() {}
+
+func (x *GetCounterStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCounterStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetCounterStatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *GetCounterStatsRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+type GetCounterStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Counters      []*CounterStats        `protobuf:"bytes,1,rep,name=counters,proto3" json:"counters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCounterStatsResponse) Reset() {
+	*x = GetCounterStatsResponse{}
+	mi := &file_proto_event_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCounterStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCounterStatsResponse) ProtoMessage() {}
+
+func (x *GetCounterStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCounterStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetCounterStatsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *GetCounterStatsResponse) GetCounters() []*CounterStats {
+	if x != nil {
+		return x.Counters
+	}
+	return nil
+}
+
+// Работа одной стойки
+type CounterStats struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	CounterId string                 `protobuf:"bytes,1,opt,name=counter_id,json=counterId,proto3" json:"counter_id,omitempty"`
+	// пользователь, которого стойка обслуживает сейчас
+	ServingUserId string `protobuf:"bytes,2,opt,name=serving_user_id,json=servingUserId,proto3" json:"serving_user_id,omitempty"`
+	Served        int32  `protobuf:"varint,3,opt,name=served,proto3" json:"served,omitempty"`
+	NoShows       int32  `protobuf:"varint,4,opt,name=no_shows,json=noShows,proto3" json:"no_shows,omitempty"`
+	// среднее время от вызова до завершения обслуживания
+	AverageServiceSeconds int64 `protobuf:"varint,5,opt,name=average_service_seconds,json=averageServiceSeconds,proto3" json:"average_service_seconds,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *CounterStats) Reset() {
+	*x = CounterStats{}
+	mi := &file_proto_event_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CounterStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CounterStats) ProtoMessage() {}
+
+func (x *CounterStats) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CounterStats.ProtoReflect.Descriptor instead.
+func (*CounterStats) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *CounterStats) GetCounterId() string {
+	if x != nil {
+		return x.CounterId
+	}
+	return ""
+}
+
+func (x *CounterStats) GetServingUserId() string {
+	if x != nil {
+		return x.ServingUserId
+	}
+	return ""
+}
+
+func (x *CounterStats) GetServed() int32 {
+	if x != nil {
+		return x.Served
+	}
+	return 0
+}
+
+func (x *CounterStats) GetNoShows() int32 {
+	if x != nil {
+		return x.NoShows
+	}
+	return 0
+}
+
+func (x *CounterStats) GetAverageServiceSeconds() int64 {
+	if x != nil {
+		return x.AverageServiceSeconds
+	}
+	return 0
+}
+
+// Запрос на смену состояния очереди
+type QueueStateRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	EventId string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	// организатор мероприятия
+	UserId        string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueueStateRequest) Reset() {
+	*x = QueueStateRequest{}
+	mi := &file_proto_event_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueueStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueueStateRequest) ProtoMessage() {}
+
+func (x *QueueStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueueStateRequest.ProtoReflect.Descriptor instead.
+func (*QueueStateRequest) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *QueueStateRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *QueueStateRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// Состояние очереди после смены
+type QueueStateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         string                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueueStateResponse) Reset() {
+	*x = QueueStateResponse{}
+	mi := &file_proto_event_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueueStateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueueStateResponse) ProtoMessage() {}
+
+func (x *QueueStateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_event_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueueStateResponse.ProtoReflect.Descriptor instead.
+func (*QueueStateResponse) Descriptor() ([]byte, []int) {
+	return file_proto_event_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *QueueStateResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+var File_proto_event_proto protoreflect.FileDescriptor
+
+const file_proto_event_proto_rawDesc = "" +
+	"\n" +
+	"\x11proto/event.proto\x12\x03gen\"[\n" +
+	"\x13ProcessEventRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\">\n" +
+	"\x14ProcessEventResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"'\n" +
+	"\x15GetEventStatusRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xaa\x01\n" +
+	"\x16GetEventStatusResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\tR\tupdatedAt\"\xe7\x02\n" +
+	"\n" +
+	"EventQueue\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\bevent_id\x18\x02 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x1a\n" +
+	"\bposition\x18\x05 \x01(\x05R\bposition\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\tR\tupdatedAt\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\b \x01(\tR\texpiresAt\x12\x12\n" +
+	"\x04lane\x18\t \x01(\tR\x04lane\x12\x1d\n" +
+	"\n" +
+	"counter_id\x18\n" +
+	" \x01(\tR\tcounterId\x12\x1b\n" +
+	"\tcalled_at\x18\v \x01(\tR\bcalledAt\x124\n" +
+	"\x16estimated_wait_seconds\x18\f \x01(\x03R\x14estimatedWaitSeconds\"R\n" +
+	"\x10JoinQueueRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userIdJ\x04\b\x03\x10\x04R\x04lane\"\x98\x01\n" +
+	"\x11JoinQueueResponse\x12\x19\n" +
+	"\bqueue_id\x18\x01 \x01(\tR\aqueueId\x12\x1a\n" +
+	"\bposition\x18\x02 \x01(\x05R\bposition\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x124\n" +
+	"\x16estimated_wait_seconds\x18\x04 \x01(\x03R\x14estimatedWaitSeconds\"G\n" +
+	"\x11LeaveQueueRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\".\n" +
+	"\x12LeaveQueueResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"2\n" +
+	"\x15GetQueueStatusRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\"A\n" +
+	"\x16GetQueueStatusResponse\x12'\n" +
+	"\x06queues\x18\x01 \x03(\v2\x0f.gen.EventQueueR\x06queues\"L\n" +
+	"\x16GetUserPositionRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"\x83\x01\n" +
+	"\x17GetUserPositionResponse\x12\x1a\n" +
+	"\bposition\x18\x01 \x01(\x05R\bposition\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x124\n" +
+	"\x16estimated_wait_seconds\x18\x03 \x01(\x03R\x14estimatedWaitSeconds\"g\n" +
+	"\x12ProcessNextRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x1d\n" +
+	"\n" +
+	"counter_id\x18\x02 \x01(\tR\tcounterId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\"<\n" +
+	"\x13ProcessNextResponse\x12%\n" +
+	"\x05queue\x18\x01 \x01(\v2\x0f.gen.EventQueueR\x05queue\"G\n" +
+	"\x11CloseQueueRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"D\n" +
+	"\x12CloseQueueResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\"G\n" +
+	"\x11WatchQueueRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"\xf9\x01\n" +
+	"\rQueueSettings\x12\x1d\n" +
+	"\n" +
+	"max_active\x18\x01 \x01(\x05R\tmaxActive\x12\x1b\n" +
+	"\tmax_total\x18\x02 \x01(\x05R\bmaxTotal\x12 \n" +
+	"\fmax_per_user\x18\x03 \x01(\x05R\n" +
+	"maxPerUser\x120\n" +
+	"\x14turn_timeout_seconds\x18\x04 \x01(\x05R\x12turnTimeoutSeconds\x12\x16\n" +
+	"\x06policy\x18\x05 \x01(\tR\x06policy\x12$\n" +
+	"\x05lanes\x18\x06 \x03(\v2\x0e.gen.QueueLaneR\x05lanes\x12\x1a\n" +
+	"\bcounters\x18\a \x03(\tR\bcounters\"7\n" +
+	"\tQueueLane\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06weight\x18\x02 \x01(\x05R\x06weight\"\x80\x01\n" +
+	"\x1aUpdateQueueSettingsRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12.\n" +
+	"\bsettings\x18\x03 \x01(\v2\x12.gen.QueueSettingsR\bsettings\"\xa9\x02\n" +
+	"\vQueueUpdate\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1a\n" +
+	"\bposition\x18\x04 \x01(\x05R\bposition\x124\n" +
+	"\x16estimated_wait_seconds\x18\x05 \x01(\x03R\x14estimatedWaitSeconds\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\tR\tupdatedAt\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\tR\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"counter_id\x18\b \x01(\tR\tcounterId\x12\x1f\n" +
+	"\vqueue_state\x18\t \x01(\tR\n" +
+	"queueState\"|\n" +
+	"\x11AssignLaneRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04lane\x18\x03 \x01(\tR\x04lane\x12\x1f\n" +
+	"\voperator_id\x18\x04 \x01(\tR\n" +
+	"operatorId\";\n" +
+	"\x12AssignLaneResponse\x12%\n" +
+	"\x05queue\x18\x01 \x01(\v2\x0f.gen.EventQueueR\x05queue\"k\n" +
+	"\x14CompleteEntryRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1f\n" +
+	"\voperator_id\x18\x03 \x01(\tR\n" +
+	"operatorId\">\n" +
+	"\x15CompleteEntryResponse\x12%\n" +
+	"\x05queue\x18\x01 \x01(\v2\x0f.gen.EventQueueR\x05queue\"g\n" +
+	"\x10SkipEntryRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1f\n" +
+	"\voperator_id\x18\x03 \x01(\tR\n" +
+	"operatorId\"c\n" +
+	"\x11SkipEntryResponse\x12)\n" +
+	"\askipped\x18\x01 \x01(\v2\x0f.gen.EventQueueR\askipped\x12#\n" +
+	"\x04next\x18\x02 \x01(\v2\x0f.gen.EventQueueR\x04next\"L\n" +
+	"\x16GetCheckInTokenRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"J\n" +
+	"\x17GetCheckInTokenResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x19\n" +
+	"\bqr_image\x18\x02 \x01(\fR\aqrImage\"t\n" +
+	"\x0eCheckInRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x14\n" +
+	"\x05photo\x18\x02 \x01(\fR\x05photo\x12\x1d\n" +
+	"\n" +
+	"counter_id\x18\x03 \x01(\tR\tcounterId\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\"8\n" +
+	"\x0fCheckInResponse\x12%\n" +
+	"\x05queue\x18\x01 \x01(\v2\x0f.gen.EventQueueR\x05queue\"\xb8\x04\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\tR\tupdatedAt\x12\x14\n" +
+	"\x05title\x18\b \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\t \x01(\tR\vdescription\x12\x14\n" +
+	"\x05venue\x18\n" +
+	" \x01(\tR\x05venue\x12\x1b\n" +
+	"\tstarts_at\x18\v \x01(\tR\bstartsAt\x12\x17\n" +
+	"\aends_at\x18\f \x01(\tR\x06endsAt\x12\x1a\n" +
+	"\btimezone\x18\r \x01(\tR\btimezone\x12\x1a\n" +
+	"\bcapacity\x18\x0e \x01(\x05R\bcapacity\x12!\n" +
+	"\forganizer_id\x18\x0f \x01(\tR\vorganizerId\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\x10 \x01(\tR\n" +
+	"visibility\x12\x14\n" +
+	"\x05state\x18\x11 \x01(\tR\x05state\x129\n" +
+	"\x0equeue_settings\x18\x13 \x01(\v2\x12.gen.QueueSettingsR\rqueueSettings\x12\x1f\n" +
+	"\vqueue_state\x18\x14 \x01(\tR\n" +
+	"queueStateJ\x04\b\x12\x10\x13R\fqueue_closed\"\xea\x01\n" +
+	"\fEventDetails\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05venue\x18\x03 \x01(\tR\x05venue\x12\x1b\n" +
+	"\tstarts_at\x18\x04 \x01(\tR\bstartsAt\x12\x17\n" +
+	"\aends_at\x18\x05 \x01(\tR\x06endsAt\x12\x1a\n" +
+	"\btimezone\x18\x06 \x01(\tR\btimezone\x12\x1a\n" +
+	"\bcapacity\x18\a \x01(\x05R\bcapacity\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\b \x01(\tR\n" +
+	"visibility\"d\n" +
+	"\x12CreateEventRequest\x12!\n" +
+	"\forganizer_id\x18\x01 \x01(\tR\vorganizerId\x12+\n" +
+	"\adetails\x18\x02 \x01(\v2\x11.gen.EventDetailsR\adetails\"j\n" +
+	"\x12UpdateEventRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12+\n" +
+	"\adetails\x18\x03 \x01(\v2\x11.gen.EventDetailsR\adetails\":\n" +
+	"\x0fGetEventRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\",\n" +
+	"\x11ListEventsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"8\n" +
+	"\x12ListEventsResponse\x12\"\n" +
+	"\x06events\x18\x01 \x03(\v2\n" +
+	".gen.EventR\x06events\"X\n" +
+	"\x17ChangeEventStateRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\"\x15\n" +
+	"\x13GetAllEventsRequest\":\n" +
+	"\x14GetAllEventsResponse\x12\"\n" +
+	"\x06events\x18\x01 \x03(\v2\n" +
+	".gen.EventR\x06events\"3\n" +
+	"\x16GetCounterStatsRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\"H\n" +
+	"\x17GetCounterStatsResponse\x12-\n" +
+	"\bcounters\x18\x01 \x03(\v2\x11.gen.CounterStatsR\bcounters\"\xc0\x01\n" +
+	"\fCounterStats\x12\x1d\n" +
+	"\n" +
+	"counter_id\x18\x01 \x01(\tR\tcounterId\x12&\n" +
+	"\x0fserving_user_id\x18\x02 \x01(\tR\rservingUserId\x12\x16\n" +
+	"\x06served\x18\x03 \x01(\x05R\x06served\x12\x19\n" +
+	"\bno_shows\x18\x04 \x01(\x05R\anoShows\x126\n" +
+	"\x17average_service_seconds\x18\x05 \x01(\x03R\x15averageServiceSeconds\"G\n" +
+	"\x11QueueStateRequest\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"*\n" +
+	"\x12QueueStateResponse\x12\x14\n" +
+	"\x05state\x18\x01 \x01(\tR\x05state*\x96\x01\n" +
+	"\vQueueStatus\x12\x1c\n" +
+	"\x18QUEUE_STATUS_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14QUEUE_STATUS_WAITING\x10\x01\x12\x17\n" +
+	"\x13QUEUE_STATUS_ACTIVE\x10\x02\x12\x1a\n" +
+	"\x16QUEUE_STATUS_COMPLETED\x10\x03\x12\x1a\n" +
+	"\x16QUEUE_STATUS_CANCELLED\x10\x042\xf6\x03\n" +
+	"\fEventService\x12C\n" +
+	"\fProcessEvent\x12\x18.gen.ProcessEventRequest\x1a\x19.gen.ProcessEventResponse\x12I\n" +
+	"\x0eGetEventStatus\x12\x1a.gen.GetEventStatusRequest\x1a\x1b.gen.GetEventStatusResponse\x12C\n" +
+	"\fGetAllEvents\x12\x18.gen.GetAllEventsRequest\x1a\x19.gen.GetAllEventsResponse\x122\n" +
+	"\vCreateEvent\x12\x17.gen.CreateEventRequest\x1a\n" +
+	".gen.Event\x122\n" +
+	"\vUpdateEvent\x12\x17.gen.UpdateEventRequest\x1a\n" +
+	".gen.Event\x12,\n" +
+	"\bGetEvent\x12\x14.gen.GetEventRequest\x1a\n" +
+	".gen.Event\x12=\n" +
+	"\n" +
+	"ListEvents\x12\x16.gen.ListEventsRequest\x1a\x17.gen.ListEventsResponse\x12<\n" +
+	"\x10ChangeEventState\x12\x1c.gen.ChangeEventStateRequest\x1a\n" +
+	".gen.Event2\xc3\t\n" +
+	"\x11EventQueueService\x12:\n" +
+	"\tJoinQueue\x12\x15.gen.JoinQueueRequest\x1a\x16.gen.JoinQueueResponse\x12=\n" +
+	"\n" +
+	"LeaveQueue\x12\x16.gen.LeaveQueueRequest\x1a\x17.gen.LeaveQueueResponse\x12I\n" +
+	"\x0eGetQueueStatus\x12\x1a.gen.GetQueueStatusRequest\x1a\x1b.gen.GetQueueStatusResponse\x12L\n" +
+	"\x0fGetUserPosition\x12\x1b.gen.GetUserPositionRequest\x1a\x1c.gen.GetUserPositionResponse\x12@\n" +
+	"\vProcessNext\x12\x17.gen.ProcessNextRequest\x1a\x18.gen.ProcessNextResponse\x12=\n" +
+	"\n" +
+	"CloseQueue\x12\x16.gen.CloseQueueRequest\x1a\x17.gen.CloseQueueResponse\x128\n" +
+	"\n" +
+	"WatchQueue\x12\x16.gen.WatchQueueRequest\x1a\x10.gen.QueueUpdate0\x01\x12J\n" +
+	"\x13UpdateQueueSettings\x12\x1f.gen.UpdateQueueSettingsRequest\x1a\x12.gen.QueueSettings\x12=\n" +
+	"\n" +
+	"AssignLane\x12\x16.gen.AssignLaneRequest\x1a\x17.gen.AssignLaneResponse\x12F\n" +
+	"\rCompleteEntry\x12\x19.gen.CompleteEntryRequest\x1a\x1a.gen.CompleteEntryResponse\x12:\n" +
+	"\tSkipEntry\x12\x15.gen.SkipEntryRequest\x1a\x16.gen.SkipEntryResponse\x12L\n" +
+	"\x0fGetCheckInToken\x12\x1b.gen.GetCheckInTokenRequest\x1a\x1c.gen.GetCheckInTokenResponse\x124\n" +
+	"\aCheckIn\x12\x13.gen.CheckInRequest\x1a\x14.gen.CheckInResponse\x12L\n" +
+	"\x0fGetCounterStats\x12\x1b.gen.GetCounterStatsRequest\x1a\x1c.gen.GetCounterStatsResponse\x12=\n" +
+	"\n" +
+	"PauseQueue\x12\x16.gen.QueueStateRequest\x1a\x17.gen.QueueStateResponse\x12>\n" +
+	"\vResumeQueue\x12\x16.gen.QueueStateRequest\x1a\x17.gen.QueueStateResponse\x12>\n" +
+	"\vReopenQueue\x12\x16.gen.QueueStateRequest\x1a\x17.gen.QueueStateResponse\x12?\n" +
+	"\fArchiveQueue\x12\x16.gen.QueueStateRequest\x1a\x17.gen.QueueStateResponseB\aZ\x05./genb\x06proto3"
+
+var (
+	file_proto_event_proto_rawDescOnce sync.Once
+	file_proto_event_proto_rawDescData []byte
+)
+
+func file_proto_event_proto_rawDescGZIP() []byte {
+	file_proto_event_proto_rawDescOnce.Do(func() {
+		file_proto_event_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_event_proto_rawDesc), len(file_proto_event_proto_rawDesc)))
+	})
+	return file_proto_event_proto_rawDescData
+}
+
+var file_proto_event_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_event_proto_msgTypes = make([]protoimpl.MessageInfo, 47)
+var file_proto_event_proto_goTypes = []any{
+	(QueueStatus)(0),                   // 0: gen.QueueStatus
+	(*ProcessEventRequest)(nil),        // 1: gen.ProcessEventRequest
+	(*ProcessEventResponse)(nil),       // 2: gen.ProcessEventResponse
+	(*GetEventStatusRequest)(nil),      // 3: gen.GetEventStatusRequest
+	(*GetEventStatusResponse)(nil),     // 4: gen.GetEventStatusResponse
+	(*EventQueue)(nil),                 // 5: gen.EventQueue
+	(*JoinQueueRequest)(nil),           // 6: gen.JoinQueueRequest
+	(*JoinQueueResponse)(nil),          // 7: gen.JoinQueueResponse
+	(*LeaveQueueRequest)(nil),          // 8: gen.LeaveQueueRequest
+	(*LeaveQueueResponse)(nil),         // 9: gen.LeaveQueueResponse
+	(*GetQueueStatusRequest)(nil),      // 10: gen.GetQueueStatusRequest
+	(*GetQueueStatusResponse)(nil),     // 11: gen.GetQueueStatusResponse
+	(*GetUserPositionRequest)(nil),     // 12: gen.GetUserPositionRequest
+	(*GetUserPositionResponse)(nil),    // 13: gen.GetUserPositionResponse
+	(*ProcessNextRequest)(nil),         // 14: gen.ProcessNextRequest
+	(*ProcessNextResponse)(nil),        // 15: gen.ProcessNextResponse
+	(*CloseQueueRequest)(nil),          // 16: gen.CloseQueueRequest
+	(*CloseQueueResponse)(nil),         // 17: gen.CloseQueueResponse
+	(*WatchQueueRequest)(nil),          // 18: gen.WatchQueueRequest
+	(*QueueSettings)(nil),              // 19: gen.QueueSettings
+	(*QueueLane)(nil),                  // 20: gen.QueueLane
+	(*UpdateQueueSettingsRequest)(nil), // 21: gen.UpdateQueueSettingsRequest
+	(*QueueUpdate)(nil),                // 22: gen.QueueUpdate
+	(*AssignLaneRequest)(nil),          // 23: gen.AssignLaneRequest
+	(*AssignLaneResponse)(nil),         // 24: gen.AssignLaneResponse
+	(*CompleteEntryRequest)(nil),       // 25: gen.CompleteEntryRequest
+	(*CompleteEntryResponse)(nil),      // 26: gen.CompleteEntryResponse
+	(*SkipEntryRequest)(nil),           // 27: gen.SkipEntryRequest
+	(*SkipEntryResponse)(nil),          // 28: gen.SkipEntryResponse
+	(*GetCheckInTokenRequest)(nil),     // 29: gen.GetCheckInTokenRequest
+	(*GetCheckInTokenResponse)(nil),    // 30: gen.GetCheckInTokenResponse
+	(*CheckInRequest)(nil),             // 31: gen.CheckInRequest
+	(*CheckInResponse)(nil),            // 32: gen.CheckInResponse
+	(*Event)(nil),                      // 33: gen.Event
+	(*EventDetails)(nil),               // 34: gen.EventDetails
+	(*CreateEventRequest)(nil),         // 35: gen.CreateEventRequest
+	(*UpdateEventRequest)(nil),         // 36: gen.UpdateEventRequest
+	(*GetEventRequest)(nil),            // 37: gen.GetEventRequest
+	(*ListEventsRequest)(nil),          // 38: gen.ListEventsRequest
+	(*ListEventsResponse)(nil),         // 39: gen.ListEventsResponse
+	(*ChangeEventStateRequest)(nil),    // 40: gen.ChangeEventStateRequest
+	(*GetAllEventsRequest)(nil),        // 41: gen.GetAllEventsRequest
+	(*GetAllEventsResponse)(nil),       // 42: gen.GetAllEventsResponse
+	(*GetCounterStatsRequest)(nil),     // 43: gen.GetCounterStatsRequest
+	(*GetCounterStatsResponse)(nil),    // 44: gen.GetCounterStatsResponse
+	(*CounterStats)(nil),               // 45: gen.CounterStats
+	(*QueueStateRequest)(nil),          // 46: gen.QueueStateRequest
+	(*QueueStateResponse)(nil),         // 47: gen.QueueStateResponse
+}
+var file_proto_event_proto_depIdxs = []int32{
+	5,  // 0: gen.GetQueueStatusResponse.queues:type_name -> gen.EventQueue
+	5,  // 1: gen.ProcessNextResponse.queue:type_name -> gen.EventQueue
+	20, // 2: gen.QueueSettings.lanes:type_name -> gen.QueueLane
+	19, // 3: gen.UpdateQueueSettingsRequest.settings:type_name -> gen.QueueSettings
+	5,  // 4: gen.AssignLaneResponse.queue:type_name -> gen.EventQueue
+	5,  // 5: gen.CompleteEntryResponse.queue:type_name -> gen.EventQueue
+	5,  // 6: gen.SkipEntryResponse.skipped:type_name -> gen.EventQueue
+	5,  // 7: gen.SkipEntryResponse.next:type_name -> gen.EventQueue
+	5,  // 8: gen.CheckInResponse.queue:type_name -> gen.EventQueue
+	19, // 9: gen.Event.queue_settings:type_name -> gen.QueueSettings
+	34, // 10: gen.CreateEventRequest.details:type_name -> gen.EventDetails
+	34, // 11: gen.UpdateEventRequest.details:type_name -> gen.EventDetails
+	33, // 12: gen.ListEventsResponse.events:type_name -> gen.Event
+	33, // 13: gen.GetAllEventsResponse.events:type_name -> gen.Event
+	45, // 14: gen.GetCounterStatsResponse.counters:type_name -> gen.CounterStats
+	1,  // 15: gen.EventService.ProcessEvent:input_type -> gen.ProcessEventRequest
+	3,  // 16: gen.EventService.GetEventStatus:input_type -> gen.GetEventStatusRequest
+	41, // 17: gen.EventService.GetAllEvents:input_type -> gen.GetAllEventsRequest
+	35, // 18: gen.EventService.CreateEvent:input_type -> gen.CreateEventRequest
+	36, // 19: gen.EventService.UpdateEvent:input_type -> gen.UpdateEventRequest
+	37, // 20: gen.EventService.GetEvent:input_type -> gen.GetEventRequest
+	38, // 21: gen.EventService.ListEvents:input_type -> gen.ListEventsRequest
+	40, // 22: gen.EventService.ChangeEventState:input_type -> gen.ChangeEventStateRequest
+	6,  // 23: gen.EventQueueService.JoinQueue:input_type -> gen.JoinQueueRequest
+	8,  // 24: gen.EventQueueService.LeaveQueue:input_type -> gen.LeaveQueueRequest
+	10, // 25: gen.EventQueueService.GetQueueStatus:input_type -> gen.GetQueueStatusRequest
+	12, // 26: gen.EventQueueService.GetUserPosition:input_type -> gen.GetUserPositionRequest
+	14, // 27: gen.EventQueueService.ProcessNext:input_type -> gen.ProcessNextRequest
+	16, // 28: gen.EventQueueService.CloseQueue:input_type -> gen.CloseQueueRequest
+	18, // 29: gen.EventQueueService.WatchQueue:input_type -> gen.WatchQueueRequest
+	21, // 30: gen.EventQueueService.UpdateQueueSettings:input_type -> gen.UpdateQueueSettingsRequest
+	23, // 31: gen.EventQueueService.AssignLane:input_type -> gen.AssignLaneRequest
+	25, // 32: gen.EventQueueService.CompleteEntry:input_type -> gen.CompleteEntryRequest
+	27, // 33: gen.EventQueueService.SkipEntry:input_type -> gen.SkipEntryRequest
+	29, // 34: gen.EventQueueService.GetCheckInToken:input_type -> gen.GetCheckInTokenRequest
+	31, // 35: gen.EventQueueService.CheckIn:input_type -> gen.CheckInRequest
+	43, // 36: gen.EventQueueService.GetCounterStats:input_type -> gen.GetCounterStatsRequest
+	46, // 37: gen.EventQueueService.PauseQueue:input_type -> gen.QueueStateRequest
+	46, // 38: gen.EventQueueService.ResumeQueue:input_type -> gen.QueueStateRequest
+	46, // 39: gen.EventQueueService.ReopenQueue:input_type -> gen.QueueStateRequest
+	46, // 40: gen.EventQueueService.ArchiveQueue:input_type -> gen.QueueStateRequest
+	2,  // 41: gen.EventService.ProcessEvent:output_type -> gen.ProcessEventResponse
+	4,  // 42: gen.EventService.GetEventStatus:output_type -> gen.GetEventStatusResponse
+	42, // 43: gen.EventService.GetAllEvents:output_type -> gen.GetAllEventsResponse
+	33, // 44: gen.EventService.CreateEvent:output_type -> gen.Event
+	33, // 45: gen.EventService.UpdateEvent:output_type -> gen.Event
+	33, // 46: gen.EventService.GetEvent:output_type -> gen.Event
+	39, // 47: gen.EventService.ListEvents:output_type -> gen.ListEventsResponse
+	33, // 48: gen.EventService.ChangeEventState:output_type -> gen.Event
+	7,  // 49: gen.EventQueueService.JoinQueue:output_type -> gen.JoinQueueResponse
+	9,  // 50: gen.EventQueueService.LeaveQueue:output_type -> gen.LeaveQueueResponse
+	11, // 51: gen.EventQueueService.GetQueueStatus:output_type -> gen.GetQueueStatusResponse
+	13, // 52: gen.EventQueueService.GetUserPosition:output_type -> gen.GetUserPositionResponse
+	15, // 53: gen.EventQueueService.ProcessNext:output_type -> gen.ProcessNextResponse
+	17, // 54: gen.EventQueueService.CloseQueue:output_type -> gen.CloseQueueResponse
+	22, // 55: gen.EventQueueService.WatchQueue:output_type -> gen.QueueUpdate
+	19, // 56: gen.EventQueueService.UpdateQueueSettings:output_type -> gen.QueueSettings
+	24, // 57: gen.EventQueueService.AssignLane:output_type -> gen.AssignLaneResponse
+	26, // 58: gen.EventQueueService.CompleteEntry:output_type -> gen.CompleteEntryResponse
+	28, // 59: gen.EventQueueService.SkipEntry:output_type -> gen.SkipEntryResponse
+	30, // 60: gen.EventQueueService.GetCheckInToken:output_type -> gen.GetCheckInTokenResponse
+	32, // 61: gen.EventQueueService.CheckIn:output_type -> gen.CheckInResponse
+	44, // 62: gen.EventQueueService.GetCounterStats:output_type -> gen.GetCounterStatsResponse
+	47, // 63: gen.EventQueueService.PauseQueue:output_type -> gen.QueueStateResponse
+	47, // 64: gen.EventQueueService.ResumeQueue:output_type -> gen.QueueStateResponse
+	47, // 65: gen.EventQueueService.ReopenQueue:output_type -> gen.QueueStateResponse
+	47, // 66: gen.EventQueueService.ArchiveQueue:output_type -> gen.QueueStateResponse
+	41, // [41:67] is the sub-list for method output_type
+	15, // [15:41] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_proto_event_proto_init() }
+func file_proto_event_proto_init() {
+	if File_proto_event_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_event_proto_rawDesc), len(file_proto_event_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   47,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_proto_event_proto_goTypes,
+		DependencyIndexes: file_proto_event_proto_depIdxs,
+		EnumInfos:         file_proto_event_proto_enumTypes,
+		MessageInfos:      file_proto_event_proto_msgTypes,
+	}.Build()
+	File_proto_event_proto = out.File
+	file_proto_event_proto_goTypes = nil
+	file_proto_event_proto_depIdxs = nil
+}
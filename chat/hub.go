@@ -1,40 +1,109 @@
 package chat
 
+import "github.com/XRS0/ToTalkB/wsqueue"
+
 type Hub struct {
-	clients map[*Client]bool
-	broadcast chan []byte
-	register chan *Client
+	rooms      map[string]map[*Client]bool
+	broadcast  chan roomMessage
+	register   chan *Client
 	unregister chan *Client
+	kick       chan kick
+	online     chan onlineQuery
+}
+
+// roomMessage рассылается только клиентам одного чата
+type roomMessage struct {
+	chatId  string
+	message wsqueue.Message
+}
+
+// kick отключает все соединения пользователя в чате
+type kick struct {
+	chatId string
+	userId string
+}
+
+// onlineQuery запрашивает пользователей, подключённых к чату
+type onlineQuery struct {
+	chatId string
+	result chan map[string]bool
 }
 
 func NewHub() *Hub {
 	return &Hub{
-		broadcast:  make(chan []byte),
+		broadcast:  make(chan roomMessage),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
-		clients:    make(map[*Client]bool),
+		kick:       make(chan kick),
+		online:     make(chan onlineQuery),
+		rooms:      make(map[string]map[*Client]bool),
 	}
 }
 
+// Broadcast рассылает сообщение всем подключённым участникам чата
+func (h *Hub) Broadcast(chatId string, message []byte) {
+	h.Publish(chatId, wsqueue.Message{Kind: frameMessage, Data: message})
+}
+
+// Publish рассылает фрейм участникам чата, при переполнении очереди клиента
+// срабатывает политика, заданная для типа фрейма
+func (h *Hub) Publish(chatId string, message wsqueue.Message) {
+	h.broadcast <- roomMessage{chatId: chatId, message: message}
+}
+
+// Kick отключает пользователя от чата, например после бана
+func (h *Hub) Kick(chatId, userId string) {
+	h.kick <- kick{chatId: chatId, userId: userId}
+}
+
+// Online возвращает пользователей, у которых открыт сокет в чате
+func (h *Hub) Online(chatId string) map[string]bool {
+	result := make(chan map[string]bool, 1)
+	h.online <- onlineQuery{chatId: chatId, result: result}
+	return <-result
+}
+
 func (h *Hub) Run() {
 	for {
 		select {
 		case client := <-h.register:
-			h.clients[client] = true
+			room, ok := h.rooms[client.chatId]
+			if !ok {
+				room = make(map[*Client]bool)
+				h.rooms[client.chatId] = room
+			}
+			room[client] = true
 		case client := <-h.unregister:
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
+			if _, ok := h.rooms[client.chatId][client]; ok {
+				h.remove(client)
 			}
-		case message := <-h.broadcast:
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
+		case k := <-h.kick:
+			for client := range h.rooms[k.chatId] {
+				if client.userId == k.userId {
+					h.remove(client)
+				}
+			}
+		case q := <-h.online:
+			users := make(map[string]bool, len(h.rooms[q.chatId]))
+			for client := range h.rooms[q.chatId] {
+				users[client.userId] = true
+			}
+			q.result <- users
+		case m := <-h.broadcast:
+			for client := range h.rooms[m.chatId] {
+				if !client.deliver(m.message) {
+					h.remove(client)
 				}
 			}
 		}
 	}
+}
+
+func (h *Hub) remove(client *Client) {
+	room := h.rooms[client.chatId]
+	delete(room, client)
+	client.queue.Close()
+	if len(room) == 0 {
+		delete(h.rooms, client.chatId)
+	}
 }
\ No newline at end of file
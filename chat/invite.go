@@ -0,0 +1,272 @@
+package chat
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/XRS0/ToTalkB/codes"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	errInviteNotFound  = errors.New("invite not found")
+	errInviteExpired   = errors.New("invite has expired")
+	errInviteRevoked   = errors.New("invite has been revoked")
+	errInviteExhausted = errors.New("invite has reached its usage limit")
+	errBanned          = errors.New("you are banned from this chat")
+)
+
+type inviteInput struct {
+	ExpiresInMinutes *int `json:"expires_in_minutes" binding:"omitempty,min=1"`
+	MaxUses          *int `json:"max_uses" binding:"omitempty,min=1"`
+}
+
+type InviteHandler struct {
+	db      *sqlx.DB
+	baseURL string
+}
+
+// NewInviteHandler создаёт обработчик приглашений, ссылка приглашения — baseURL + token
+func NewInviteHandler(db *sqlx.DB, baseURL string) *InviteHandler {
+	return &InviteHandler{db: db, baseURL: strings.TrimSuffix(baseURL, "/") + "/"}
+}
+
+// Create выпускает новое приглашение в чат с необязательным сроком действия и лимитом использований
+func (h *InviteHandler) Create(c *gin.Context) {
+	chatId, userId, ok := h.adminParams(c)
+	if !ok {
+		return
+	}
+	var input inviteInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := newInviteToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create invite"})
+		return
+	}
+	invite := pkg.Invite{ChatId: chatId, Token: token, CreatedBy: userId, MaxUses: input.MaxUses}
+	if input.ExpiresInMinutes != nil {
+		expiresAt := time.Now().Add(time.Duration(*input.ExpiresInMinutes) * time.Minute)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	err = h.db.QueryRowx(
+		`INSERT INTO chat_invites (chat_id, token, created_by, expires_at, max_uses)
+         VALUES ($1, $2, $3, $4, $5)
+         RETURNING id, created_at`,
+		invite.ChatId, invite.Token, invite.CreatedBy, invite.ExpiresAt, invite.MaxUses,
+	).Scan(&invite.Id, &invite.CreatedAt)
+	if err != nil {
+		log.Printf("Failed to create invite: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create invite"})
+		return
+	}
+
+	invite.URL = h.baseURL + invite.Token
+	c.JSON(http.StatusCreated, invite)
+}
+
+// List возвращает приглашения чата
+func (h *InviteHandler) List(c *gin.Context) {
+	chatId, _, ok := h.adminParams(c)
+	if !ok {
+		return
+	}
+
+	invites := []pkg.Invite{}
+	err := h.db.Select(&invites, "SELECT * FROM chat_invites WHERE chat_id = $1 ORDER BY created_at DESC", chatId)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+	for i := range invites {
+		invites[i].URL = h.baseURL + invites[i].Token
+	}
+	c.JSON(http.StatusOK, invites)
+}
+
+// Revoke отзывает приглашение, после чего по нему нельзя вступить
+func (h *InviteHandler) Revoke(c *gin.Context) {
+	chatId, userId, ok := h.adminParams(c)
+	if !ok {
+		return
+	}
+	inviteId, err := strconv.Atoi(c.Param("inviteId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invite id"})
+		return
+	}
+
+	err = withTx(h.db, func(tx *sqlx.Tx) error {
+		res, err := tx.Exec(
+			"UPDATE chat_invites SET revoked_at = now() WHERE id = $1 AND chat_id = $2 AND revoked_at IS NULL",
+			inviteId, chatId,
+		)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errInviteNotFound
+		}
+		return recordAction(tx, pkg.ModerationAction{
+			ChatId:  chatId,
+			ActorId: userId,
+			Action:  pkg.ActionRevokeInvite,
+			Reason:  strconv.Itoa(inviteId),
+		})
+	})
+	if err != nil {
+		inviteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": inviteId})
+}
+
+// Join добавляет текущего пользователя в чат по токену приглашения
+func (h *InviteHandler) Join(c *gin.Context) {
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	token := c.Param("token")
+
+	var chatId int
+	joined := false
+	err = withTx(h.db, func(tx *sqlx.Tx) error {
+		var invite pkg.Invite
+		err := tx.Get(&invite, "SELECT * FROM chat_invites WHERE token = $1 FOR UPDATE", token)
+		if err == sql.ErrNoRows {
+			return errInviteNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkInvite(&invite, time.Now()); err != nil {
+			return err
+		}
+		chatId = invite.ChatId
+
+		var banned bool
+		if err := tx.Get(&banned, "SELECT EXISTS(SELECT 1 FROM chat_bans WHERE chat_id = $1 AND user_id = $2)", chatId, userId); err != nil {
+			return err
+		}
+		if banned {
+			return errBanned
+		}
+
+		res, err := tx.Exec(
+			`INSERT INTO chat_members (user_id, chat_id, role) VALUES ($1, $2, $3)
+             ON CONFLICT (user_id, chat_id) DO NOTHING`,
+			userId, chatId, pkg.RoleMember,
+		)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil
+		}
+		joined = true
+		_, err = tx.Exec("UPDATE chat_invites SET uses = uses + 1 WHERE id = $1", invite.Id)
+		return err
+	})
+	if err != nil {
+		inviteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"chat_id": chatId, "joined": joined})
+}
+
+// QR отдаёт ссылку приглашения в виде QR-кода, чтобы вступить в чат можно было сканированием на площадке
+func (h *InviteHandler) QR(c *gin.Context) {
+	token := c.Param("token")
+
+	var invite pkg.Invite
+	err := h.db.Get(&invite, "SELECT * FROM chat_invites WHERE token = $1", token)
+	if err == sql.ErrNoRows {
+		inviteError(c, errInviteNotFound)
+		return
+	}
+	if err != nil {
+		inviteError(c, err)
+		return
+	}
+	if err := checkInvite(&invite, time.Now()); err != nil {
+		inviteError(c, err)
+		return
+	}
+
+	image, err := codes.GeneratePNG(h.baseURL + invite.Token)
+	if err != nil {
+		log.Printf("Failed to render invite qr code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render qr code"})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", image)
+}
+
+func (h *InviteHandler) adminParams(c *gin.Context) (chatId, userId int, ok bool) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return 0, 0, false
+	}
+	userId, err = strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+	if err := requireAdmin(h.db, chatId, userId); err != nil {
+		moderationError(c, err)
+		return 0, 0, false
+	}
+	return chatId, userId, true
+}
+
+func checkInvite(invite *pkg.Invite, now time.Time) error {
+	switch {
+	case invite.RevokedAt != nil:
+		return errInviteRevoked
+	case invite.ExpiresAt != nil && !invite.ExpiresAt.After(now):
+		return errInviteExpired
+	case invite.MaxUses != nil && invite.Uses >= *invite.MaxUses:
+		return errInviteExhausted
+	}
+	return nil
+}
+
+func newInviteToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func inviteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errInviteNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, errInviteExpired), errors.Is(err, errInviteRevoked), errors.Is(err, errInviteExhausted):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, errBanned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		moderationError(c, err)
+	}
+}
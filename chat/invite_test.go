@@ -0,0 +1,229 @@
+package chat
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+var inviteColumns = []string{"id", "chat_id", "token", "created_by", "expires_at", "max_uses", "uses", "revoked_at", "created_at"}
+
+// inviteStore — приглашения поверх участников и банов moderationStore
+type inviteStore struct {
+	*moderationStore
+	invites []*pkg.Invite
+}
+
+func (s *inviteStore) query(query string, args []driver.Value) (*fakeRows, error) {
+	switch {
+	case strings.HasPrefix(query, "INSERT INTO chat_invites"):
+		invite := &pkg.Invite{Id: len(s.invites) + 1, ChatId: int(args[0].(int64)), Token: args[1].(string), CreatedBy: int(args[2].(int64)), CreatedAt: time.Now()}
+		if expiresAt, ok := args[3].(time.Time); ok {
+			invite.ExpiresAt = &expiresAt
+		}
+		if maxUses, ok := args[4].(int64); ok {
+			n := int(maxUses)
+			invite.MaxUses = &n
+		}
+		s.invites = append(s.invites, invite)
+		return fakeRow([]string{"id", "created_at"}, int64(invite.Id), invite.CreatedAt), nil
+	case strings.HasPrefix(query, "SELECT * FROM chat_invites WHERE token"):
+		for _, invite := range s.invites {
+			if invite.Token == args[0].(string) {
+				return fakeRow(inviteColumns, inviteValues(invite)...), nil
+			}
+		}
+		return noRows(inviteColumns...), nil
+	case strings.HasPrefix(query, "UPDATE chat_invites SET revoked_at"):
+		invite := s.invite(args[0])
+		if invite == nil || invite.ChatId != int(args[1].(int64)) || invite.RevokedAt != nil {
+			return affectedRows(0), nil
+		}
+		now := time.Now()
+		invite.RevokedAt = &now
+	case strings.HasPrefix(query, "UPDATE chat_invites SET uses"):
+		s.invite(args[0]).Uses++
+	case strings.HasPrefix(query, "INSERT INTO chat_members"):
+		user := int(args[0].(int64))
+		if _, ok := s.roles[user]; ok {
+			return affectedRows(0), nil
+		}
+		s.roles[user] = args[2].(string)
+	default:
+		return s.moderationStore.query(query, args)
+	}
+	return nil, nil
+}
+
+func (s *inviteStore) invite(id driver.Value) *pkg.Invite {
+	for _, invite := range s.invites {
+		if int64(invite.Id) == id.(int64) {
+			return invite
+		}
+	}
+	return nil
+}
+
+func inviteValues(invite *pkg.Invite) []driver.Value {
+	var expiresAt, maxUses, revokedAt driver.Value
+	if invite.ExpiresAt != nil {
+		expiresAt = *invite.ExpiresAt
+	}
+	if invite.MaxUses != nil {
+		maxUses = int64(*invite.MaxUses)
+	}
+	if invite.RevokedAt != nil {
+		revokedAt = *invite.RevokedAt
+	}
+	return []driver.Value{int64(invite.Id), int64(invite.ChatId), invite.Token, int64(invite.CreatedBy), expiresAt, maxUses, int64(invite.Uses), revokedAt, invite.CreatedAt}
+}
+
+func TestCheckInvite(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	limit := func(n int) *int { return &n }
+	tests := []struct {
+		name   string
+		invite pkg.Invite
+		err    error
+	}{
+		{"no limits", pkg.Invite{Uses: 100}, nil},
+		{"before expiry", pkg.Invite{ExpiresAt: at(time.Minute)}, nil},
+		{"at expiry", pkg.Invite{ExpiresAt: at(0)}, errInviteExpired},
+		{"after expiry", pkg.Invite{ExpiresAt: at(-time.Minute)}, errInviteExpired},
+		{"uses left", pkg.Invite{MaxUses: limit(2), Uses: 1}, nil},
+		{"uses exhausted", pkg.Invite{MaxUses: limit(2), Uses: 2}, errInviteExhausted},
+		{"revoked", pkg.Invite{RevokedAt: at(-time.Hour)}, errInviteRevoked},
+		{"revoked before expiry", pkg.Invite{RevokedAt: at(-time.Hour), ExpiresAt: at(-time.Minute)}, errInviteRevoked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkInvite(&tt.invite, now); !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestInviteHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &inviteStore{moderationStore: newModerationStore()}
+	handler := NewInviteHandler(newFakeDB(t, store.query), "https://chat.example.com/invite")
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userId", c.GetHeader("X-User"))
+	})
+	router.POST("/chat/:chatId/invites", handler.Create)
+	router.DELETE("/chat/:chatId/invites/:inviteId", handler.Revoke)
+	router.POST("/invites/:token/join", handler.Join)
+
+	do := func(method, path, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	create := func(body string) *pkg.Invite {
+		t.Helper()
+		w := do(http.MethodPost, "/chat/1/invites", "2", body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected invite to be created, got %d %s", w.Code, w.Body)
+		}
+		var invite pkg.Invite
+		if err := json.Unmarshal(w.Body.Bytes(), &invite); err != nil {
+			t.Fatalf("decode invite: %v", err)
+		}
+		if invite.Token == "" || invite.URL != "https://chat.example.com/invite/"+invite.Token {
+			t.Fatalf("unexpected invite %+v", invite)
+		}
+		return &invite
+	}
+	join := func(invite *pkg.Invite, user string) (int, bool) {
+		t.Helper()
+		w := do(http.MethodPost, "/invites/"+invite.Token+"/join", user, "")
+		var body struct {
+			ChatId int  `json:"chat_id"`
+			Joined bool `json:"joined"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code == http.StatusOK && body.ChatId != 1 {
+			t.Fatalf("expected to join chat 1, got %s", w.Body)
+		}
+		return w.Code, body.Joined
+	}
+
+	if code := do(http.MethodPost, "/chat/1/invites", "3", "").Code; code != http.StatusForbidden {
+		t.Fatalf("expected member to be denied creating an invite, got %d", code)
+	}
+	if code := do(http.MethodPost, "/chat/1/invites", "2", `{"max_uses":0}`).Code; code != http.StatusBadRequest {
+		t.Fatalf("expected zero usage limit to be rejected, got %d", code)
+	}
+
+	single := create(`{"max_uses":1}`)
+	if code, joined := join(single, "7"); code != http.StatusOK || !joined {
+		t.Fatalf("expected user to join by invite, got %d, %v", code, joined)
+	}
+	if store.roles[7] != pkg.RoleMember || store.invites[0].Uses != 1 {
+		t.Fatalf("expected user 7 to be a member and the invite used once, got %v, %d", store.roles, store.invites[0].Uses)
+	}
+	if code, _ := join(single, "8"); code != http.StatusGone {
+		t.Fatalf("expected exhausted invite to be gone, got %d", code)
+	}
+
+	expiring := create(`{"expires_in_minutes":1}`)
+	if expiresAt := store.invites[1].ExpiresAt; expiresAt == nil || time.Until(*expiresAt) > time.Minute {
+		t.Fatalf("expected invite to expire in a minute, got %v", expiresAt)
+	}
+	*store.invites[1].ExpiresAt = time.Now().Add(-time.Second)
+	if code, _ := join(expiring, "8"); code != http.StatusGone {
+		t.Fatalf("expected expired invite to be gone, got %d", code)
+	}
+
+	open := create("")
+	if code, _ := join(open, "6"); code != http.StatusForbidden {
+		t.Fatalf("expected banned user to be denied, got %d", code)
+	}
+	// участник уже в чате: приглашение не расходуется
+	if code, joined := join(open, "3"); code != http.StatusOK || joined || store.invites[2].Uses != 0 {
+		t.Fatalf("expected member to stay without using the invite, got %d, %v, %d uses", code, joined, store.invites[2].Uses)
+	}
+
+	revoke := func(user string) int {
+		return do(http.MethodDelete, "/chat/1/invites/3", user, "").Code
+	}
+	if code := revoke("3"); code != http.StatusForbidden {
+		t.Fatalf("expected member to be denied revoking an invite, got %d", code)
+	}
+	if code := revoke("2"); code != http.StatusOK {
+		t.Fatalf("expected admin to revoke the invite, got %d", code)
+	}
+	if code := revoke("2"); code != http.StatusNotFound {
+		t.Fatalf("expected revoked invite to be gone, got %d", code)
+	}
+	if code, _ := join(open, "8"); code != http.StatusGone {
+		t.Fatalf("expected revoked invite to be gone, got %d", code)
+	}
+	if code, _ := join(&pkg.Invite{Token: "unknown"}, "8"); code != http.StatusNotFound {
+		t.Fatalf("expected unknown invite to be not found, got %d", code)
+	}
+	if _, ok := store.roles[8]; ok {
+		t.Fatal("expected user 8 to stay outside the chat")
+	}
+	if len(store.actions) != 1 || store.actions[0] != pkg.ActionRevokeInvite {
+		t.Fatalf("expected revoke to be logged, got %v", store.actions)
+	}
+}
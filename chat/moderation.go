@@ -0,0 +1,594 @@
+package chat
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const maxMuteDuration = 30 * 24 * time.Hour
+
+var (
+	errNotMember = errors.New("not a chat member")
+	errForbidden = errors.New("not enough rights")
+)
+
+// mutedError возвращается, когда замьюченный участник пытается писать в чат
+type mutedError struct {
+	until time.Time
+}
+
+func (e *mutedError) Error() string {
+	return fmt.Sprintf("you are muted until %s", e.until.Format(time.RFC3339))
+}
+
+type ErrorMessage struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+type ModerationHandler struct {
+	db  *sqlx.DB
+	hub *Hub
+}
+
+func NewModerationHandler(db *sqlx.DB, hub *Hub) *ModerationHandler {
+	return &ModerationHandler{db: db, hub: hub}
+}
+
+type muteInput struct {
+	DurationMinutes int    `json:"duration_minutes" binding:"required,min=1"`
+	Reason          string `json:"reason"`
+}
+
+type banInput struct {
+	Reason string `json:"reason"`
+}
+
+type reportInput struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type roleInput struct {
+	Role string `json:"role" binding:"required,oneof=admin member"`
+}
+
+type resolveInput struct {
+	Status string `json:"status" binding:"required,oneof=resolved dismissed"`
+}
+
+// Mute запрещает участнику писать в чат на duration_minutes минут
+func (h *ModerationHandler) Mute(c *gin.Context) {
+	chatId, actorId, targetId, ok := moderationParams(c)
+	if !ok {
+		return
+	}
+	var input muteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	duration := time.Duration(input.DurationMinutes) * time.Minute
+	if duration > maxMuteDuration {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mute duration is too long"})
+		return
+	}
+	until := time.Now().Add(duration)
+
+	err := h.withTargetTx(chatId, actorId, targetId, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(
+			"UPDATE chat_members SET muted_until = $1 WHERE chat_id = $2 AND user_id = $3",
+			until, chatId, targetId,
+		); err != nil {
+			return err
+		}
+		return recordAction(tx, pkg.ModerationAction{
+			ChatId:       chatId,
+			ActorId:      actorId,
+			TargetUserId: &targetId,
+			Action:       pkg.ActionMute,
+			Reason:       input.Reason,
+			ExpiresAt:    &until,
+		})
+	})
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": targetId, "muted_until": until})
+}
+
+// Unmute досрочно снимает мьют
+func (h *ModerationHandler) Unmute(c *gin.Context) {
+	chatId, actorId, targetId, ok := moderationParams(c)
+	if !ok {
+		return
+	}
+
+	err := h.withTargetTx(chatId, actorId, targetId, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(
+			"UPDATE chat_members SET muted_until = NULL WHERE chat_id = $1 AND user_id = $2",
+			chatId, targetId,
+		); err != nil {
+			return err
+		}
+		return recordAction(tx, pkg.ModerationAction{
+			ChatId:       chatId,
+			ActorId:      actorId,
+			TargetUserId: &targetId,
+			Action:       pkg.ActionUnmute,
+		})
+	})
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": targetId})
+}
+
+// Ban исключает участника из чата и запрещает ему возвращаться
+func (h *ModerationHandler) Ban(c *gin.Context) {
+	chatId, actorId, targetId, ok := moderationParams(c)
+	if !ok {
+		return
+	}
+	var input banInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.withTargetTx(chatId, actorId, targetId, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(
+			"DELETE FROM chat_members WHERE chat_id = $1 AND user_id = $2",
+			chatId, targetId,
+		); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO chat_bans (chat_id, user_id, banned_by, reason)
+         VALUES ($1, $2, $3, $4)
+         ON CONFLICT (chat_id, user_id) DO UPDATE SET banned_by = $3, reason = $4, created_at = now()`,
+			chatId, targetId, actorId, input.Reason,
+		); err != nil {
+			return err
+		}
+		return recordAction(tx, pkg.ModerationAction{
+			ChatId:       chatId,
+			ActorId:      actorId,
+			TargetUserId: &targetId,
+			Action:       pkg.ActionBan,
+			Reason:       input.Reason,
+		})
+	})
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	h.hub.Kick(strconv.Itoa(chatId), strconv.Itoa(targetId))
+	c.JSON(http.StatusOK, gin.H{"user_id": targetId})
+}
+
+// Unban снимает бан. Вернуться в чат пользователь может только по новому приглашению.
+func (h *ModerationHandler) Unban(c *gin.Context) {
+	chatId, actorId, targetId, ok := moderationParams(c)
+	if !ok {
+		return
+	}
+
+	err := withTx(h.db, func(tx *sqlx.Tx) error {
+		if err := requireAdmin(tx, chatId, actorId); err != nil {
+			return err
+		}
+		res, err := tx.Exec("DELETE FROM chat_bans WHERE chat_id = $1 AND user_id = $2", chatId, targetId)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return sql.ErrNoRows
+		}
+		return recordAction(tx, pkg.ModerationAction{
+			ChatId:       chatId,
+			ActorId:      actorId,
+			TargetUserId: &targetId,
+			Action:       pkg.ActionUnban,
+		})
+	})
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": targetId})
+}
+
+// SetRole назначает участника администратором или снимает с него права. Менять роли может только владелец чата
+func (h *ModerationHandler) SetRole(c *gin.Context) {
+	chatId, actorId, targetId, ok := moderationParams(c)
+	if !ok {
+		return
+	}
+	var input roleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	action := pkg.ActionGrantAdmin
+	if input.Role == pkg.RoleMember {
+		action = pkg.ActionRevokeAdmin
+	}
+
+	err := withTx(h.db, func(tx *sqlx.Tx) error {
+		actor, err := getMember(tx, chatId, actorId)
+		if err != nil {
+			return err
+		}
+		target, err := getMember(tx, chatId, targetId)
+		if errors.Is(err, errNotMember) {
+			return sql.ErrNoRows
+		}
+		if err != nil {
+			return err
+		}
+		// роль владельца не передаётся и не снимается
+		if actor.Role != pkg.RoleOwner || target.Role == pkg.RoleOwner {
+			return errForbidden
+		}
+		if _, err := tx.Exec(
+			"UPDATE chat_members SET role = $1 WHERE chat_id = $2 AND user_id = $3",
+			input.Role, chatId, targetId,
+		); err != nil {
+			return err
+		}
+		return recordAction(tx, pkg.ModerationAction{
+			ChatId:       chatId,
+			ActorId:      actorId,
+			TargetUserId: &targetId,
+			Action:       action,
+		})
+	})
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": targetId, "role": input.Role})
+}
+
+// Report отправляет жалобу на сообщение в очередь модерации чата
+func (h *ModerationHandler) Report(c *gin.Context) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return
+	}
+	messageId, err := strconv.Atoi(c.Param("messageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message id"})
+		return
+	}
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	var input reportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := isChatMember(h.db, chatId, userId)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+	if !member {
+		moderationError(c, errNotMember)
+		return
+	}
+
+	var report pkg.Report
+	err = h.db.QueryRowx(
+		`INSERT INTO message_reports (chat_id, message_id, reporter_id, reason)
+         SELECT m.chat_id, m.id, $3, $4 FROM messages m WHERE m.id = $2 AND m.chat_id = $1
+         ON CONFLICT (message_id, reporter_id) DO NOTHING
+         RETURNING id, source, status, created_at`,
+		chatId, messageId, userId, input.Reason,
+	).Scan(&report.Id, &report.Source, &report.Status, &report.CreatedAt)
+	if err == sql.ErrNoRows {
+		// Либо сообщения нет в чате, либо жалоба уже подана
+		var exists bool
+		if err := h.db.Get(&exists, "SELECT EXISTS(SELECT 1 FROM messages WHERE id = $1 AND chat_id = $2)", messageId, chatId); err != nil {
+			moderationError(c, err)
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "message already reported"})
+		return
+	}
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	report.ChatId, report.MessageId, report.ReporterId, report.Reason = chatId, messageId, &userId, input.Reason
+	c.JSON(http.StatusCreated, report)
+}
+
+// Reports возвращает очередь модерации чата, по умолчанию только открытые жалобы
+func (h *ModerationHandler) Reports(c *gin.Context) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return
+	}
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	if err := requireAdmin(h.db, chatId, userId); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	status := c.DefaultQuery("status", pkg.ReportStatusOpen)
+	reports := []pkg.Report{}
+	err = h.db.Select(&reports,
+		`SELECT r.id, r.chat_id, r.message_id, r.reporter_id, r.source, r.reason, r.status,
+                r.resolved_by, r.resolved_at, r.created_at, m.content, u.name AS sender
+         FROM message_reports r
+         JOIN messages m ON m.id = r.message_id
+         JOIN users u ON u.id = m.sender_id
+         WHERE r.chat_id = $1 AND r.status = $2
+         ORDER BY r.created_at ASC`,
+		chatId, status,
+	)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+// ResolveReport закрывает жалобу как обработанную или отклонённую
+func (h *ModerationHandler) ResolveReport(c *gin.Context) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return
+	}
+	reportId, err := strconv.Atoi(c.Param("reportId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid report id"})
+		return
+	}
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	var input resolveInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	action := pkg.ActionResolveReport
+	if input.Status == pkg.ReportStatusDismissed {
+		action = pkg.ActionDismissReport
+	}
+
+	err = withTx(h.db, func(tx *sqlx.Tx) error {
+		if err := requireAdmin(tx, chatId, userId); err != nil {
+			return err
+		}
+		var targetId int
+		err := tx.QueryRowx(
+			`UPDATE message_reports r SET status = $1, resolved_by = $2, resolved_at = now()
+             FROM messages m
+             WHERE r.id = $3 AND r.chat_id = $4 AND r.status = $5 AND m.id = r.message_id
+             RETURNING m.sender_id`,
+			input.Status, userId, reportId, chatId, pkg.ReportStatusOpen,
+		).Scan(&targetId)
+		if err != nil {
+			return err
+		}
+		return recordAction(tx, pkg.ModerationAction{
+			ChatId:       chatId,
+			ActorId:      userId,
+			TargetUserId: &targetId,
+			ReportId:     &reportId,
+			Action:       action,
+		})
+	})
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": reportId, "status": input.Status})
+}
+
+// Log возвращает журнал действий модераторов чата
+func (h *ModerationHandler) Log(c *gin.Context) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return
+	}
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	if err := requireAdmin(h.db, chatId, userId); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	actions := []pkg.ModerationAction{}
+	err = h.db.Select(&actions,
+		"SELECT * FROM moderation_actions WHERE chat_id = $1 ORDER BY created_at DESC LIMIT 200",
+		chatId,
+	)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, actions)
+}
+
+// withTargetTx выполняет fn в транзакции после проверки, что actor может модерировать target
+func (h *ModerationHandler) withTargetTx(chatId, actorId, targetId int, fn func(tx *sqlx.Tx) error) error {
+	return withTx(h.db, func(tx *sqlx.Tx) error {
+		actor, err := getMember(tx, chatId, actorId)
+		if err != nil {
+			return err
+		}
+		target, err := getMember(tx, chatId, targetId)
+		if errors.Is(err, errNotMember) {
+			return sql.ErrNoRows
+		}
+		if err != nil {
+			return err
+		}
+		if !canModerate(actor.Role, target.Role) {
+			return errForbidden
+		}
+		return fn(tx)
+	})
+}
+
+// checkCanPost проверяет, что пользователь состоит в чате и не замьючен
+func checkCanPost(db sqlx.Queryer, chatId, userId int) error {
+	member, err := getMember(db, chatId, userId)
+	if err != nil {
+		return err
+	}
+	if member.MutedUntil != nil && member.MutedUntil.After(time.Now()) {
+		return &mutedError{until: *member.MutedUntil}
+	}
+	return nil
+}
+
+func getMember(db sqlx.Queryer, chatId, userId int) (*pkg.Member, error) {
+	var member pkg.Member
+	err := sqlx.Get(db, &member,
+		"SELECT user_id, chat_id, role, muted_until FROM chat_members WHERE chat_id = $1 AND user_id = $2",
+		chatId, userId,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errNotMember
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func requireAdmin(db sqlx.Queryer, chatId, userId int) error {
+	member, err := getMember(db, chatId, userId)
+	if err != nil {
+		return err
+	}
+	if roleRank(member.Role) < roleRank(pkg.RoleAdmin) {
+		return errForbidden
+	}
+	return nil
+}
+
+// canModerate — администратор и владелец могут модерировать только участников ниже себя по роли
+func canModerate(actor, target string) bool {
+	return roleRank(actor) >= roleRank(pkg.RoleAdmin) && roleRank(actor) > roleRank(target)
+}
+
+func roleRank(role string) int {
+	switch role {
+	case pkg.RoleOwner:
+		return 2
+	case pkg.RoleAdmin:
+		return 1
+	}
+	return 0
+}
+
+func recordAction(tx *sqlx.Tx, action pkg.ModerationAction) error {
+	_, err := tx.Exec(
+		`INSERT INTO moderation_actions (chat_id, actor_id, target_user_id, report_id, action, reason, expires_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		action.ChatId,
+		action.ActorId,
+		action.TargetUserId,
+		action.ReportId,
+		action.Action,
+		action.Reason,
+		action.ExpiresAt,
+	)
+	return err
+}
+
+func withTx(db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func moderationParams(c *gin.Context) (chatId, actorId, targetId int, ok bool) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return 0, 0, 0, false
+	}
+	targetId, err = strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, 0, false
+	}
+	actorId, err = strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return 0, 0, 0, false
+	}
+	return chatId, actorId, targetId, true
+}
+
+func moderationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errNotMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, errForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	default:
+		log.Printf("Moderation error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
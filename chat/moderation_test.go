@@ -0,0 +1,243 @@
+package chat
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+var memberColumns = []string{"user_id", "chat_id", "role", "muted_until"}
+
+// moderationStore — участники, баны и жалобы одного чата для тестовой базы
+type moderationStore struct {
+	roles   map[int]string
+	muted   map[int]time.Time
+	banned  map[int]bool
+	reports map[int]string
+	actions []string
+	err     error
+}
+
+func newModerationStore() *moderationStore {
+	return &moderationStore{
+		roles:   map[int]string{1: pkg.RoleOwner, 2: pkg.RoleAdmin, 3: pkg.RoleMember, 4: pkg.RoleMember, 5: pkg.RoleMember},
+		muted:   map[int]time.Time{4: time.Now().Add(time.Hour), 5: time.Now().Add(-time.Hour)},
+		banned:  map[int]bool{6: true},
+		reports: map[int]string{1: pkg.ReportStatusOpen, 2: pkg.ReportStatusOpen},
+	}
+}
+
+func (s *moderationStore) query(query string, args []driver.Value) (*fakeRows, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	switch {
+	case query == "BEGIN", query == "COMMIT", query == "ROLLBACK":
+	case strings.Contains(query, "FROM chat_bans"):
+		return fakeRow([]string{"exists"}, s.banned[int(args[1].(int64))]), nil
+	case strings.HasPrefix(query, "SELECT user_id, chat_id, role, muted_until FROM chat_members"):
+		user := int(args[1].(int64))
+		role, ok := s.roles[user]
+		if !ok {
+			return noRows(memberColumns...), nil
+		}
+		var muted driver.Value
+		if until, ok := s.muted[user]; ok {
+			muted = until
+		}
+		return fakeRow(memberColumns, int64(user), args[0], role, muted), nil
+	case strings.HasPrefix(query, "UPDATE chat_members SET role"):
+		s.roles[int(args[2].(int64))] = args[0].(string)
+	case strings.Contains(query, "UPDATE message_reports"):
+		id := int(args[2].(int64))
+		if s.reports[id] != pkg.ReportStatusOpen {
+			return noRows("sender_id"), nil
+		}
+		s.reports[id] = args[0].(string)
+		return fakeRow([]string{"sender_id"}, int64(3)), nil
+	case strings.Contains(query, "INSERT INTO moderation_actions"):
+		s.actions = append(s.actions, args[4].(string))
+	default:
+		return nil, fmt.Errorf("unexpected query %q", query)
+	}
+	return nil, nil
+}
+
+func TestCanModerate(t *testing.T) {
+	tests := []struct {
+		actor  string
+		target string
+		want   bool
+	}{
+		{pkg.RoleOwner, pkg.RoleAdmin, true},
+		{pkg.RoleOwner, pkg.RoleMember, true},
+		{pkg.RoleAdmin, pkg.RoleMember, true},
+		{pkg.RoleAdmin, "", true},
+		{pkg.RoleOwner, pkg.RoleOwner, false},
+		{pkg.RoleAdmin, pkg.RoleAdmin, false},
+		{pkg.RoleAdmin, pkg.RoleOwner, false},
+		{pkg.RoleMember, pkg.RoleMember, false},
+		{pkg.RoleMember, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.actor+" "+tt.target, func(t *testing.T) {
+			if got := canModerate(tt.actor, tt.target); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCheckCanConnect(t *testing.T) {
+	db := newFakeDB(t, newModerationStore().query)
+	tests := []struct {
+		name   string
+		chatId string
+		userId string
+		status int
+		err    error
+	}{
+		{"member", "1", "3", 0, nil},
+		{"muted member", "1", "4", 0, nil},
+		{"banned", "1", "6", http.StatusForbidden, errBanned},
+		{"not a member", "1", "7", http.StatusForbidden, errNotMember},
+		{"invalid chat id", "abc", "3", http.StatusBadRequest, nil},
+		{"invalid user id", "1", "", http.StatusUnauthorized, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := checkCanConnect(db, tt.chatId, tt.userId)
+			if status != tt.status || (tt.status == 0) != (err == nil) || (tt.err != nil && !errors.Is(err, tt.err)) {
+				t.Fatalf("expected (%d, %v), got (%d, %v)", tt.status, tt.err, status, err)
+			}
+		})
+	}
+
+	broken := newModerationStore()
+	broken.err = errors.New("connection refused")
+	if status, _ := checkCanConnect(newFakeDB(t, broken.query), "1", "3"); status != http.StatusInternalServerError {
+		t.Fatalf("expected %d on a database error, got %d", http.StatusInternalServerError, status)
+	}
+}
+
+func TestCheckCanPost(t *testing.T) {
+	db := newFakeDB(t, newModerationStore().query)
+	if err := checkCanPost(db, 1, 3); err != nil {
+		t.Fatalf("expected member to post, got %v", err)
+	}
+	var muted *mutedError
+	if err := checkCanPost(db, 1, 4); !errors.As(err, &muted) || !muted.until.After(time.Now()) {
+		t.Fatalf("expected muted error, got %v", err)
+	}
+	if err := checkCanPost(db, 1, 5); err != nil {
+		t.Fatalf("expected expired mute to be ignored, got %v", err)
+	}
+	if err := checkCanPost(db, 1, 6); !errors.Is(err, errNotMember) {
+		t.Fatalf("expected banned user to be rejected as not a member, got %v", err)
+	}
+}
+
+// moderationRouter поднимает обработчики модерации над тестовой базой, пользователя берёт из X-User
+func moderationRouter(t *testing.T, store *moderationStore) func(method, path, user, body string) int {
+	gin.SetMode(gin.TestMode)
+	handler := NewModerationHandler(newFakeDB(t, store.query), nil)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userId", c.GetHeader("X-User"))
+	})
+	router.PUT("/chat/:chatId/members/:userId/role", handler.SetRole)
+	router.POST("/chat/:chatId/reports/:reportId/resolve", handler.ResolveReport)
+
+	return func(method, path, user, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+}
+
+func TestSetRole(t *testing.T) {
+	store := newModerationStore()
+	do := moderationRouter(t, store)
+
+	tests := []struct {
+		name   string
+		target string
+		user   string
+		body   string
+		want   int
+	}{
+		{"admin cannot grant admin", "3", "2", `{"role":"admin"}`, http.StatusForbidden},
+		{"member cannot grant admin", "3", "3", `{"role":"admin"}`, http.StatusForbidden},
+		{"ownership is not transferred", "3", "1", `{"role":"owner"}`, http.StatusBadRequest},
+		{"owner keeps the role", "1", "1", `{"role":"member"}`, http.StatusForbidden},
+		{"not a member", "7", "1", `{"role":"admin"}`, http.StatusNotFound},
+		{"invalid user id", "abc", "1", `{"role":"admin"}`, http.StatusBadRequest},
+		{"owner grants admin", "3", "1", `{"role":"admin"}`, http.StatusOK},
+		{"owner revokes admin", "2", "1", `{"role":"member"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := do(http.MethodPut, "/chat/1/members/"+tt.target+"/role", tt.user, tt.body); code != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, code)
+			}
+		})
+	}
+
+	if store.roles[3] != pkg.RoleAdmin || store.roles[2] != pkg.RoleMember || store.roles[1] != pkg.RoleOwner {
+		t.Fatalf("unexpected roles %v", store.roles)
+	}
+	if want := []string{pkg.ActionGrantAdmin, pkg.ActionRevokeAdmin}; !slices.Equal(store.actions, want) {
+		t.Fatalf("expected actions %v, got %v", want, store.actions)
+	}
+	// новый администратор сразу может модерировать
+	if code := do(http.MethodPost, "/chat/1/reports/1/resolve", "3", `{"status":"resolved"}`); code != http.StatusOK {
+		t.Fatalf("expected new admin to resolve a report, got %d", code)
+	}
+}
+
+func TestResolveReport(t *testing.T) {
+	store := newModerationStore()
+	do := moderationRouter(t, store)
+
+	tests := []struct {
+		name   string
+		report string
+		user   string
+		body   string
+		want   int
+	}{
+		{"member cannot resolve", "1", "3", `{"status":"resolved"}`, http.StatusForbidden},
+		{"not a member", "1", "7", `{"status":"resolved"}`, http.StatusForbidden},
+		{"unknown status", "1", "2", `{"status":"open"}`, http.StatusBadRequest},
+		{"admin resolves", "1", "2", `{"status":"resolved"}`, http.StatusOK},
+		{"already resolved", "1", "2", `{"status":"dismissed"}`, http.StatusNotFound},
+		{"owner dismisses", "2", "1", `{"status":"dismissed"}`, http.StatusOK},
+		{"unknown report", "9", "1", `{"status":"resolved"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := do(http.MethodPost, "/chat/1/reports/"+tt.report+"/resolve", tt.user, tt.body); code != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, code)
+			}
+		})
+	}
+
+	if store.reports[1] != pkg.ReportStatusResolved || store.reports[2] != pkg.ReportStatusDismissed {
+		t.Fatalf("unexpected report statuses %v", store.reports)
+	}
+	if want := []string{pkg.ActionResolveReport, pkg.ActionDismissReport}; !slices.Equal(store.actions, want) {
+		t.Fatalf("expected actions %v, got %v", want, store.actions)
+	}
+}
@@ -0,0 +1,290 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/XRS0/ToTalkB/chat/gen"
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	notifyBatchWindow   = 30 * time.Second
+	notifyFlushInterval = time.Second
+	notifyTimeout       = 5 * time.Second
+	notifyPreviewLength = 100
+	notifyQueueSize     = 1024
+
+	// chatNotificationType — тип уведомления в notify, который доставляется пользователю по его сокету
+	chatNotificationType = "websocket"
+)
+
+type notifyKey struct {
+	chatId int
+	userId int
+}
+
+// notifyPost — новое сообщение, о котором нужно уведомить участников чата
+type notifyPost struct {
+	chatId   int
+	senderId int
+	content  string
+}
+
+type notifyBatch struct {
+	count    int
+	senderId int
+	preview  string
+	due      time.Time
+}
+
+// OfflineNotifier отправляет через сервис notify уведомления участникам чата,
+// у которых нет открытого сокета. Сообщения, пришедшие одному пользователю в одном чате
+// в течение окна, объединяются в одно уведомление "N new messages".
+// Все уведомления разбирает один обработчик Run
+type OfflineNotifier struct {
+	db      *sqlx.DB
+	hub     *Hub
+	client  gen.NotificationServiceClient
+	window  time.Duration
+	now     func() time.Time
+	posts   chan notifyPost
+	pending map[notifyKey]*notifyBatch
+}
+
+func NewOfflineNotifier(db *sqlx.DB, hub *Hub, client gen.NotificationServiceClient) *OfflineNotifier {
+	return &OfflineNotifier{
+		db:      db,
+		hub:     hub,
+		client:  client,
+		window:  notifyBatchWindow,
+		now:     time.Now,
+		posts:   make(chan notifyPost, notifyQueueSize),
+		pending: make(map[notifyKey]*notifyBatch),
+	}
+}
+
+// MessagePosted ставит уведомления офлайн-участникам, не задерживая отправителя.
+// Имя отправителя в уведомлении берётся из базы по senderId
+func (n *OfflineNotifier) MessagePosted(chatId, senderId int, content string) {
+	if n == nil {
+		return
+	}
+	select {
+	case n.posts <- notifyPost{chatId: chatId, senderId: senderId, content: content}:
+	default:
+		log.Printf("Notification queue is full, dropping notification for chat %d", chatId)
+	}
+}
+
+// Run собирает сообщения в пачки и отправляет те, у которых истекло окно, пока не отменён ctx
+func (n *OfflineNotifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(notifyFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case post := <-n.posts:
+			n.enqueue(post)
+		case <-ticker.C:
+			n.flushDue()
+		}
+	}
+}
+
+func (n *OfflineNotifier) enqueue(post notifyPost) {
+	var members []int
+	now := n.now()
+	err := n.db.Select(&members,
+		`SELECT user_id FROM chat_members
+         WHERE chat_id = $1 AND user_id <> $2
+           AND NOT (notifications_muted AND (notifications_muted_until IS NULL OR notifications_muted_until > $3))`,
+		post.chatId, post.senderId, now,
+	)
+	if err != nil {
+		log.Printf("Failed to load chat members for notification: %v", err)
+		return
+	}
+	if len(members) == 0 {
+		return
+	}
+	online := n.hub.Online(strconv.Itoa(post.chatId))
+	preview := truncate(post.content, notifyPreviewLength)
+
+	for _, userId := range members {
+		if online[strconv.Itoa(userId)] {
+			continue
+		}
+		key := notifyKey{chatId: post.chatId, userId: userId}
+		if batch, ok := n.pending[key]; ok {
+			batch.count++
+			batch.senderId = post.senderId
+			batch.preview = preview
+			continue
+		}
+		n.pending[key] = &notifyBatch{count: 1, senderId: post.senderId, preview: preview, due: now.Add(n.window)}
+	}
+}
+
+// flushDue отправляет пачки, окно которых уже закрылось
+func (n *OfflineNotifier) flushDue() {
+	now := n.now()
+	for key, batch := range n.pending {
+		if batch.due.After(now) {
+			continue
+		}
+		delete(n.pending, key)
+		n.flush(key, batch)
+	}
+}
+
+func (n *OfflineNotifier) flush(key notifyKey, batch *notifyBatch) {
+	// за время окна пользователь мог подключиться и уже увидеть сообщения
+	if n.hub.Online(strconv.Itoa(key.chatId))[strconv.Itoa(key.userId)] {
+		return
+	}
+
+	var chatName string
+	if err := n.db.Get(&chatName, "SELECT name FROM chats WHERE id = $1", key.chatId); err != nil {
+		log.Printf("Failed to load chat for notification: %v", err)
+		return
+	}
+
+	notification := pkg.ChatNotification{
+		ChatId:   key.chatId,
+		ChatName: chatName,
+		Count:    batch.count,
+		Title:    chatName,
+		Body:     fmt.Sprintf("%d new messages", batch.count),
+	}
+	if batch.count == 1 {
+		var sender string
+		if err := n.db.Get(&sender, "SELECT name FROM users WHERE id = $1", batch.senderId); err != nil {
+			log.Printf("Failed to load sender for notification: %v", err)
+			return
+		}
+		notification.Body = sender + ": " + batch.preview
+	}
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		log.Printf("Failed to marshal notification: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	_, err = n.client.SendNotification(ctx, &gen.SendNotificationRequest{
+		UserId:  int32(key.userId),
+		Type:    chatNotificationType,
+		Payload: payload,
+	})
+	if err != nil {
+		log.Printf("Failed to send notification: %v", err)
+	}
+}
+
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max]) + "…"
+}
+
+type notificationSettingsInput struct {
+	Muted           bool `json:"muted"`
+	MutedForMinutes *int `json:"muted_for_minutes" binding:"omitempty,min=1"`
+}
+
+type NotificationSettingsHandler struct {
+	db *sqlx.DB
+}
+
+func NewNotificationSettingsHandler(db *sqlx.DB) *NotificationSettingsHandler {
+	return &NotificationSettingsHandler{db: db}
+}
+
+// Get возвращает настройки уведомлений текущего пользователя в чате
+func (h *NotificationSettingsHandler) Get(c *gin.Context) {
+	chatId, userId, ok := memberParams(c)
+	if !ok {
+		return
+	}
+
+	var settings pkg.NotificationSettings
+	err := h.db.Get(&settings,
+		"SELECT notifications_muted, notifications_muted_until FROM chat_members WHERE chat_id = $1 AND user_id = $2",
+		chatId, userId,
+	)
+	if err != nil {
+		notificationSettingsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// Update отключает или включает уведомления о сообщениях чата, muted_for_minutes — отключить на время
+func (h *NotificationSettingsHandler) Update(c *gin.Context) {
+	chatId, userId, ok := memberParams(c)
+	if !ok {
+		return
+	}
+	var input notificationSettingsInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings := pkg.NotificationSettings{Muted: input.Muted}
+	if input.Muted && input.MutedForMinutes != nil {
+		until := time.Now().Add(time.Duration(*input.MutedForMinutes) * time.Minute)
+		settings.MutedUntil = &until
+	}
+
+	res, err := h.db.Exec(
+		"UPDATE chat_members SET notifications_muted = $1, notifications_muted_until = $2 WHERE chat_id = $3 AND user_id = $4",
+		settings.Muted, settings.MutedUntil, chatId, userId,
+	)
+	if err != nil {
+		notificationSettingsError(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		notificationSettingsError(c, errNotMember)
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+func memberParams(c *gin.Context) (chatId, userId int, ok bool) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return 0, 0, false
+	}
+	userId, err = strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+	return chatId, userId, true
+}
+
+func notificationSettingsError(c *gin.Context, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		err = errNotMember
+	}
+	moderationError(c, err)
+}
@@ -0,0 +1,134 @@
+package chat
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/gen"
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"google.golang.org/grpc"
+)
+
+// recordingNotifications запоминает отправленные уведомления по получателям
+type recordingNotifications struct {
+	gen.NotificationServiceClient
+	sent map[int32][]pkg.ChatNotification
+}
+
+func (r *recordingNotifications) SendNotification(ctx context.Context, in *gen.SendNotificationRequest, opts ...grpc.CallOption) (*gen.SendNotificationResponse, error) {
+	var notification pkg.ChatNotification
+	if err := json.Unmarshal(in.Payload, &notification); err != nil {
+		return nil, err
+	}
+	r.sent[in.UserId] = append(r.sent[in.UserId], notification)
+	return &gen.SendNotificationResponse{}, nil
+}
+
+// notifierQuery — чат 1 с участниками 1–4 для тестовой базы
+func notifierQuery(query string, args []driver.Value) (*fakeRows, error) {
+	switch {
+	case strings.HasPrefix(query, "SELECT user_id FROM chat_members"):
+		rows := noRows("user_id")
+		for user := int64(1); user <= 4; user++ {
+			if user != args[1].(int64) {
+				rows.values = append(rows.values, []driver.Value{user})
+			}
+		}
+		return rows, nil
+	case strings.HasPrefix(query, "SELECT name FROM chats"):
+		return fakeRow([]string{"name"}, "Команда"), nil
+	case strings.HasPrefix(query, "SELECT name FROM users"):
+		names := map[int64]string{1: "ann", 2: "bob", 3: "eve", 4: "max"}
+		return fakeRow([]string{"name"}, names[args[0].(int64)]), nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", query)
+}
+
+func TestOfflineNotifierBatching(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	// у пользователя 4 открыт сокет, уведомления ему не нужны
+	hub.register <- &Client{hub: hub, chatId: "1", userId: "4"}
+
+	start := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	client := &recordingNotifications{sent: make(map[int32][]pkg.ChatNotification)}
+	n := NewOfflineNotifier(newFakeDB(t, notifierQuery), hub, client)
+	n.now = func() time.Time { return now }
+
+	expect := func(want map[int32][]string) {
+		t.Helper()
+		got := make(map[int32][]string)
+		for user, notifications := range client.sent {
+			for _, notification := range notifications {
+				if notification.ChatId != 1 || notification.Title != "Команда" {
+					t.Fatalf("unexpected notification %+v", notification)
+				}
+				got[user] = append(got[user], fmt.Sprintf("%d %s", notification.Count, notification.Body))
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("after %v expected %v, got %v", now.Sub(start), want, got)
+		}
+	}
+
+	n.enqueue(notifyPost{chatId: 1, senderId: 2, content: "привет"})
+	now = start.Add(10 * time.Second)
+	n.enqueue(notifyPost{chatId: 1, senderId: 3, content: "как дела?"})
+
+	now = start.Add(notifyBatchWindow - time.Second)
+	n.flushDue()
+	expect(map[int32][]string{})
+
+	// окно считается от первого сообщения пачки
+	now = start.Add(notifyBatchWindow)
+	n.flushDue()
+	expect(map[int32][]string{
+		1: {"2 2 new messages"},
+		3: {"1 bob: привет"},
+	})
+
+	now = start.Add(10*time.Second + notifyBatchWindow)
+	n.flushDue()
+	expect(map[int32][]string{
+		1: {"2 2 new messages"},
+		2: {"1 eve: как дела?"},
+		3: {"1 bob: привет"},
+	})
+
+	// после отправки следующее сообщение открывает новое окно
+	now = start.Add(time.Minute)
+	n.enqueue(notifyPost{chatId: 1, senderId: 1, content: strings.Repeat("я", notifyPreviewLength+1)})
+	now = now.Add(notifyBatchWindow - time.Second)
+	n.flushDue()
+	if len(client.sent[2]) != 1 {
+		t.Fatalf("expected new batch to wait for its window, got %v", client.sent[2])
+	}
+	now = now.Add(time.Second)
+	n.flushDue()
+	if got := client.sent[2]; len(got) != 2 || got[1].Body != "ann: "+strings.Repeat("я", notifyPreviewLength)+"…" {
+		t.Fatalf("expected truncated preview from ann, got %v", got)
+	}
+	if len(n.pending) != 0 {
+		t.Fatalf("expected no pending batches, got %v", n.pending)
+	}
+}
+
+func TestOfflineNotifierQueue(t *testing.T) {
+	n := NewOfflineNotifier(nil, nil, nil)
+	for i := 0; i < notifyQueueSize+1; i++ {
+		n.MessagePosted(1, 1, "привет")
+	}
+	// отправитель не блокируется, лишние уведомления отбрасываются
+	if len(n.posts) != notifyQueueSize {
+		t.Fatalf("expected %d queued posts, got %d", notifyQueueSize, len(n.posts))
+	}
+
+	var none *OfflineNotifier
+	none.MessagePosted(1, 1, "привет")
+}
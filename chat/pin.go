@@ -0,0 +1,286 @@
+package chat
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/XRS0/ToTalkB/chat/repository"
+	"github.com/XRS0/ToTalkB/wsqueue"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	maxPinnedMessages     = 5
+	maxAnnouncementLength = 2000
+)
+
+var errTooManyPins = errors.New("too many pinned messages")
+
+// PinsMessage — служебный фрейм со списком закреплённых сообщений чата
+type PinsMessage struct {
+	Type string              `json:"type"`
+	Pins []pkg.PinnedMessage `json:"pins"`
+}
+
+type announcementInput struct {
+	Content string `json:"content" binding:"required"`
+}
+
+type PinHandler struct {
+	db       *sqlx.DB
+	hub      *Hub
+	notifier *OfflineNotifier
+}
+
+func NewPinHandler(db *sqlx.DB, hub *Hub, notifier *OfflineNotifier) *PinHandler {
+	return &PinHandler{db: db, hub: hub, notifier: notifier}
+}
+
+// List возвращает закреплённые сообщения чата
+func (h *PinHandler) List(c *gin.Context) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return
+	}
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	if _, err := getMember(h.db, chatId, userId); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	pins, err := loadPins(h.db, chatId)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pins)
+}
+
+// Pin закрепляет сообщение, не более maxPinnedMessages на чат
+func (h *PinHandler) Pin(c *gin.Context) {
+	chatId, messageId, userId, ok := pinParams(c)
+	if !ok {
+		return
+	}
+
+	err := withTx(h.db, func(tx *sqlx.Tx) error {
+		if err := requireAdmin(tx, chatId, userId); err != nil {
+			return err
+		}
+		var exists bool
+		if err := tx.Get(&exists, "SELECT EXISTS(SELECT 1 FROM messages WHERE id = $1 AND chat_id = $2)", messageId, chatId); err != nil {
+			return err
+		}
+		if !exists {
+			return sql.ErrNoRows
+		}
+		return pinMessage(tx, chatId, messageId, userId)
+	})
+	if err != nil {
+		pinError(c, err)
+		return
+	}
+
+	h.broadcastPins(chatId)
+	c.JSON(http.StatusOK, gin.H{"message_id": messageId})
+}
+
+// Unpin открепляет сообщение
+func (h *PinHandler) Unpin(c *gin.Context) {
+	chatId, messageId, userId, ok := pinParams(c)
+	if !ok {
+		return
+	}
+
+	err := withTx(h.db, func(tx *sqlx.Tx) error {
+		if err := requireAdmin(tx, chatId, userId); err != nil {
+			return err
+		}
+		res, err := tx.Exec("DELETE FROM pinned_messages WHERE chat_id = $1 AND message_id = $2", chatId, messageId)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return sql.ErrNoRows
+		}
+		return recordAction(tx, pkg.ModerationAction{
+			ChatId:  chatId,
+			ActorId: userId,
+			Action:  pkg.ActionUnpin,
+			Reason:  strconv.Itoa(messageId),
+		})
+	})
+	if err != nil {
+		pinError(c, err)
+		return
+	}
+
+	h.broadcastPins(chatId)
+	c.JSON(http.StatusOK, gin.H{"message_id": messageId})
+}
+
+// Announce публикует объявление организатора и сразу закрепляет его
+func (h *PinHandler) Announce(c *gin.Context) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return
+	}
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+	var input announcementInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	content := strings.TrimSpace(input.Content)
+	if content == "" || utf8.RuneCountInString(content) > maxAnnouncementLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid announcement length"})
+		return
+	}
+
+	var message *pkg.Message
+	err = withTx(h.db, func(tx *sqlx.Tx) error {
+		if err := requireAdmin(tx, chatId, userId); err != nil {
+			return err
+		}
+		var err error
+		message, err = repository.InsertMessage(c.Request.Context(), tx, repository.NewMessage{
+			ChatId:    chatId,
+			SenderId:  userId,
+			Content:   content,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		return pinMessage(tx, chatId, message.Id, userId)
+	})
+	if err != nil {
+		pinError(c, err)
+		return
+	}
+
+	jsonMsg, err := json.Marshal(OutgoingMessage{
+		Id:      message.Id,
+		Seq:     message.Seq,
+		Content: message.Content,
+		Sender:  message.Sender,
+		Time:    message.CreatedAt.Format("15:04"),
+	})
+	if err == nil {
+		h.hub.Broadcast(strconv.Itoa(chatId), jsonMsg)
+	}
+	h.broadcastPins(chatId)
+	h.notifier.MessagePosted(chatId, userId, content)
+
+	c.JSON(http.StatusCreated, gin.H{"message_id": message.Id, "seq": message.Seq})
+}
+
+func (h *PinHandler) broadcastPins(chatId int) {
+	jsonMsg, err := pinsFrame(h.db, chatId)
+	if err != nil {
+		log.Printf("Failed to load pinned messages: %v", err)
+		return
+	}
+	h.hub.Publish(strconv.Itoa(chatId), wsqueue.Message{Kind: framePins, Key: framePins, Data: jsonMsg})
+}
+
+// pinMessage закрепляет сообщение, блокируя строку чата, чтобы параллельные
+// закрепления не превысили лимит
+func pinMessage(tx *sqlx.Tx, chatId, messageId, userId int) error {
+	if _, err := tx.Exec("SELECT id FROM chats WHERE id = $1 FOR UPDATE", chatId); err != nil {
+		return err
+	}
+	var pinned []int
+	if err := tx.Select(&pinned, "SELECT message_id FROM pinned_messages WHERE chat_id = $1", chatId); err != nil {
+		return err
+	}
+	for _, id := range pinned {
+		if id == messageId {
+			return nil
+		}
+	}
+	if len(pinned) >= maxPinnedMessages {
+		return errTooManyPins
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO pinned_messages (chat_id, message_id, pinned_by) VALUES ($1, $2, $3)",
+		chatId, messageId, userId,
+	); err != nil {
+		return err
+	}
+	return recordAction(tx, pkg.ModerationAction{
+		ChatId:  chatId,
+		ActorId: userId,
+		Action:  pkg.ActionPin,
+		Reason:  strconv.Itoa(messageId),
+	})
+}
+
+func loadPins(db sqlx.Queryer, chatId int) ([]pkg.PinnedMessage, error) {
+	pins := []pkg.PinnedMessage{}
+	err := sqlx.Select(db, &pins,
+		`SELECT p.message_id, m.content, u.name AS sender, m.created_at, p.pinned_by, p.pinned_at
+         FROM pinned_messages p
+         JOIN messages m ON m.id = p.message_id
+         JOIN users u ON u.id = m.sender_id
+         WHERE p.chat_id = $1
+         ORDER BY p.pinned_at DESC`,
+		chatId,
+	)
+	return pins, err
+}
+
+func pinsFrame(db sqlx.Queryer, chatId int) ([]byte, error) {
+	pins, err := loadPins(db, chatId)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(PinsMessage{Type: framePins, Pins: pins})
+}
+
+func pinParams(c *gin.Context) (chatId, messageId, userId int, ok bool) {
+	chatId, err := strconv.Atoi(c.Param("chatId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+		return 0, 0, 0, false
+	}
+	messageId, err = strconv.Atoi(c.Param("messageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message id"})
+		return 0, 0, 0, false
+	}
+	userId, err = strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return 0, 0, 0, false
+	}
+	return chatId, messageId, userId, true
+}
+
+func pinError(c *gin.Context, err error) {
+	if errors.Is(err, errTooManyPins) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	moderationError(c, err)
+}
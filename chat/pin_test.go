@@ -0,0 +1,200 @@
+package chat
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/XRS0/ToTalkB/wsqueue"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	messageColumns = []string{"id", "chat_id", "seq", "sender_id", "sender", "content", "created_at", "expires_at"}
+	pinColumns     = []string{"message_id", "content", "sender", "created_at", "pinned_by", "pinned_at"}
+)
+
+// pinState — то, что откатывается вместе с транзакцией
+type pinState struct {
+	messages []int
+	pinned   []int
+	lastSeq  int64
+	actions  []string
+}
+
+// pinStore — сообщения и закрепления чата 1 поверх moderationStore
+type pinStore struct {
+	*moderationStore
+	pinState
+	saved pinState
+}
+
+func (s *pinStore) snapshot() pinState {
+	return pinState{
+		messages: slices.Clone(s.messages),
+		pinned:   slices.Clone(s.pinned),
+		lastSeq:  s.lastSeq,
+		actions:  slices.Clone(s.moderationStore.actions),
+	}
+}
+
+func (s *pinStore) query(query string, args []driver.Value) (*fakeRows, error) {
+	switch {
+	case query == "BEGIN":
+		s.saved = s.snapshot()
+	case query == "ROLLBACK":
+		s.pinState = s.saved
+		s.moderationStore.actions = s.saved.actions
+	case strings.HasPrefix(query, "SELECT EXISTS(SELECT 1 FROM messages"):
+		return fakeRow([]string{"exists"}, slices.Contains(s.messages, int(args[0].(int64)))), nil
+	case strings.HasPrefix(query, "SELECT id FROM chats"):
+	case strings.HasPrefix(query, "SELECT message_id FROM pinned_messages"):
+		rows := noRows("message_id")
+		for _, id := range s.pinned {
+			rows.values = append(rows.values, []driver.Value{int64(id)})
+		}
+		return rows, nil
+	case strings.HasPrefix(query, "INSERT INTO pinned_messages"):
+		s.pinned = append(s.pinned, int(args[1].(int64)))
+	case strings.HasPrefix(query, "DELETE FROM pinned_messages"):
+		i := slices.Index(s.pinned, int(args[1].(int64)))
+		if i < 0 {
+			return affectedRows(0), nil
+		}
+		s.pinned = slices.Delete(s.pinned, i, i+1)
+	case strings.HasPrefix(query, "UPDATE chats SET last_seq"):
+		s.lastSeq++
+		return fakeRow([]string{"last_seq"}, s.lastSeq), nil
+	case strings.HasPrefix(query, "WITH m AS"):
+		id := int64(100 + len(s.messages))
+		s.messages = append(s.messages, int(id))
+		return fakeRow(messageColumns, id, args[0], args[1], args[2], "bob", args[4], args[3], nil), nil
+	case strings.Contains(query, "FROM pinned_messages p"):
+		rows := noRows(pinColumns...)
+		for _, id := range s.pinned {
+			rows.values = append(rows.values, []driver.Value{int64(id), "текст", "bob", time.Now(), int64(2), time.Now()})
+		}
+		return rows, nil
+	default:
+		return s.moderationStore.query(query, args)
+	}
+	return nil, nil
+}
+
+func TestPinHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &pinStore{moderationStore: newModerationStore(), pinState: pinState{messages: []int{1, 2, 3, 4, 5, 6}}}
+	hub := NewHub()
+	go hub.Run()
+	member := &Client{hub: hub, chatId: "1", userId: "3", queue: wsqueue.New(sendQueueSize, sendPolicies, nil)}
+	hub.register <- member
+	handler := NewPinHandler(newFakeDB(t, store.query), hub, nil)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userId", c.GetHeader("X-User"))
+	})
+	router.POST("/chat/:chatId/messages/:messageId/pin", handler.Pin)
+	router.DELETE("/chat/:chatId/messages/:messageId/pin", handler.Unpin)
+	router.POST("/chat/:chatId/announcements", handler.Announce)
+
+	do := func(method, path, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	pin := func(method string, messageId int) int {
+		return do(method, "/chat/1/messages/"+strconv.Itoa(messageId)+"/pin", "2", "").Code
+	}
+
+	if code := do(http.MethodPost, "/chat/1/messages/1/pin", "3", "").Code; code != http.StatusForbidden {
+		t.Fatalf("expected member to be denied pinning, got %d", code)
+	}
+	for id := 1; id <= maxPinnedMessages; id++ {
+		if code := pin(http.MethodPost, id); code != http.StatusOK {
+			t.Fatalf("pin message %d: expected %d, got %d", id, http.StatusOK, code)
+		}
+	}
+	// повторное закрепление не занимает место
+	if code := pin(http.MethodPost, 1); code != http.StatusOK || len(store.pinned) != maxPinnedMessages {
+		t.Fatalf("expected repeated pin to be a no-op, got %d, %v", code, store.pinned)
+	}
+	if code := pin(http.MethodPost, 6); code != http.StatusConflict {
+		t.Fatalf("expected pin over the limit to conflict, got %d", code)
+	}
+	if code := pin(http.MethodPost, 99); code != http.StatusNotFound {
+		t.Fatalf("expected unknown message to be not found, got %d", code)
+	}
+
+	// объявление не закрепилось — значит, и в чате его быть не должно
+	before := store.snapshot()
+	if code := do(http.MethodPost, "/chat/1/announcements", "2", `{"content":"Начинаем в 10"}`).Code; code != http.StatusConflict {
+		t.Fatalf("expected announcement over the pin limit to conflict, got %d", code)
+	}
+	if after := store.snapshot(); !slices.Equal(after.messages, before.messages) || after.lastSeq != before.lastSeq ||
+		!slices.Equal(after.actions, before.actions) {
+		t.Fatalf("expected announcement to be rolled back, got %+v", after)
+	}
+
+	if code := pin(http.MethodDelete, 1); code != http.StatusOK {
+		t.Fatalf("expected unpin, got %d", code)
+	}
+	if code := pin(http.MethodDelete, 1); code != http.StatusNotFound {
+		t.Fatalf("expected second unpin to be not found, got %d", code)
+	}
+
+	tests := []struct {
+		name string
+		user string
+		body string
+		want int
+	}{
+		{"member cannot announce", "3", `{"content":"Начинаем в 10"}`, http.StatusForbidden},
+		{"blank announcement", "2", `{"content":"   "}`, http.StatusBadRequest},
+		{"announcement too long", "2", `{"content":"` + strings.Repeat("я", maxAnnouncementLength+1) + `"}`, http.StatusBadRequest},
+		{"announcement", "2", `{"content":" Начинаем в 10 "}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := do(http.MethodPost, "/chat/1/announcements", tt.user, tt.body).Code; code != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, code)
+			}
+		})
+	}
+	if want := []int{2, 3, 4, 5, 106}; !slices.Equal(store.pinned, want) || store.lastSeq != 1 {
+		t.Fatalf("expected announcement 106 to be pinned, got %v, seq %d", store.pinned, store.lastSeq)
+	}
+
+	// участники получают объявление и актуальный список закреплённых
+	hub.Online("1")
+	var announced OutgoingMessage
+	var pins PinsMessage
+	for _, frame := range member.queue.Drain() {
+		switch frame.Kind {
+		case frameMessage:
+			json.Unmarshal(frame.Data, &announced)
+		case framePins:
+			json.Unmarshal(frame.Data, &pins)
+		}
+	}
+	if announced.Id != 106 || announced.Content != "Начинаем в 10" || announced.Sender != "bob" {
+		t.Fatalf("unexpected announcement frame %+v", announced)
+	}
+	if len(pins.Pins) != maxPinnedMessages || pins.Pins[len(pins.Pins)-1].MessageId != 106 {
+		t.Fatalf("unexpected pins frame %+v", pins)
+	}
+	want := []string{pkg.ActionPin, pkg.ActionPin, pkg.ActionPin, pkg.ActionPin, pkg.ActionPin, pkg.ActionUnpin, pkg.ActionPin}
+	if !slices.Equal(store.moderationStore.actions, want) {
+		t.Fatalf("expected actions %v, got %v", want, store.moderationStore.actions)
+	}
+}
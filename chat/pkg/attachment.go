@@ -0,0 +1,29 @@
+package pkg
+
+import (
+	"fmt"
+	"time"
+)
+
+type Attachment struct {
+	Id           int       `json:"id" db:"id"`
+	ChatId       int       `json:"chat_id" db:"chat_id"`
+	MessageId    *int      `json:"message_id,omitempty" db:"message_id"`
+	UploaderId   int       `json:"uploader_id" db:"uploader_id"`
+	FileName     string    `json:"file_name" db:"file_name"`
+	MimeType     string    `json:"mime_type" db:"mime_type"`
+	Size         int64     `json:"size" db:"size"`
+	StorageKey   string    `json:"-" db:"storage_key"`
+	ThumbnailKey *string   `json:"-" db:"thumbnail_key"`
+	URL          string    `json:"url" db:"-"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty" db:"-"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// SetURLs заполняет ссылки на скачивание вложения и его превью
+func (a *Attachment) SetURLs() {
+	a.URL = fmt.Sprintf("/api/attachments/%d", a.Id)
+	if a.ThumbnailKey != nil {
+		a.ThumbnailURL = fmt.Sprintf("/api/attachments/%d/thumbnail", a.Id)
+	}
+}
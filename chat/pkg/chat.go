@@ -1,7 +1,8 @@
 package pkg
 
 type Chat struct {
-    Id      int    `json:"id" db:"id"`
-    Name    string `json:"name" db:"name" binding:"required"`
-    OwnerId int    `json:"owner_id" db:"created_by"`
+    Id      int     `json:"id" db:"id"`
+    Name    string  `json:"name" db:"name" binding:"required"`
+    OwnerId int     `json:"owner_id" db:"created_by"`
+    EventId *string `json:"event_id,omitempty" db:"event_id"`
 }
\ No newline at end of file
@@ -0,0 +1,21 @@
+package pkg
+
+import "time"
+
+// ExportedMessage — сообщение в выгрузке истории чата
+type ExportedMessage struct {
+	Id          int                  `json:"id" db:"id"`
+	SenderId    int                  `json:"sender_id" db:"sender_id"`
+	Sender      string               `json:"sender" db:"sender"`
+	Content     string               `json:"content" db:"content"`
+	CreatedAt   time.Time            `json:"created_at" db:"created_at"`
+	Attachments []ExportedAttachment `json:"attachments" db:"-"`
+}
+
+type ExportedAttachment struct {
+	Id       int    `json:"id"`
+	FileName string `json:"file_name"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	URL      string `json:"url"`
+}
@@ -0,0 +1,16 @@
+package pkg
+
+import "time"
+
+type Invite struct {
+	Id        int        `json:"id" db:"id"`
+	ChatId    int        `json:"chat_id" db:"chat_id"`
+	Token     string     `json:"token" db:"token"`
+	CreatedBy int        `json:"created_by" db:"created_by"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	MaxUses   *int       `json:"max_uses,omitempty" db:"max_uses"`
+	Uses      int        `json:"uses" db:"uses"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	URL       string     `json:"url" db:"-"`
+}
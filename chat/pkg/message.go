@@ -3,9 +3,41 @@ package pkg
 import "time"
 
 type Message struct {
-	Id        int       `json:"id" db:"id"`
-	ChatId    int       `json:"chat_id" db:"chat_id"`
-	Sender    string    `json:"sender" db:"sender"`
-	Content   string    `json:"content" db:"content"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	Id          int          `json:"id" db:"id"`
+	ChatId      int          `json:"chat_id" db:"chat_id"`
+	Seq         int64        `json:"seq" db:"seq"`
+	SenderId    int          `json:"sender_id" db:"sender_id"`
+	Sender      string       `json:"sender" db:"sender"`
+	Content     string       `json:"content" db:"content"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty" db:"expires_at"`
+	Attachments []Attachment `json:"attachments,omitempty" db:"-"`
+}
+
+// ScheduledMessage — сообщение, которое появится в чате в SendAt
+type ScheduledMessage struct {
+	Id        int        `json:"id" db:"id"`
+	ChatId    int        `json:"chat_id" db:"chat_id"`
+	SenderId  int        `json:"sender_id" db:"sender_id"`
+	Content   string     `json:"content" db:"content"`
+	SendAt    time.Time  `json:"send_at" db:"send_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Receipt — докуда участник получил и прочитал сообщения чата
+type Receipt struct {
+	ChatId       int       `json:"chat_id" db:"chat_id"`
+	UserId       int       `json:"user_id" db:"user_id"`
+	DeliveredSeq int64     `json:"delivered_seq" db:"delivered_seq"`
+	ReadSeq      int64     `json:"read_seq" db:"read_seq"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MessageStatus — кому сообщение доставлено и кем прочитано
+type MessageStatus struct {
+	MessageId   int   `json:"message_id"`
+	Seq         int64 `json:"seq"`
+	DeliveredTo []int `json:"delivered_to"`
+	ReadBy      []int `json:"read_by"`
 }
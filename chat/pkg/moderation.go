@@ -0,0 +1,63 @@
+package pkg
+
+import "time"
+
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+const (
+	ActionMute          = "mute"
+	ActionUnmute        = "unmute"
+	ActionBan           = "ban"
+	ActionUnban         = "unban"
+	ActionResolveReport = "resolve_report"
+	ActionDismissReport = "dismiss_report"
+	ActionPin           = "pin"
+	ActionUnpin         = "unpin"
+	ActionRevokeInvite  = "revoke_invite"
+	ActionGrantAdmin    = "grant_admin"
+	ActionRevokeAdmin   = "revoke_admin"
+)
+
+type Member struct {
+	UserId     int        `json:"user_id" db:"user_id"`
+	ChatId     int        `json:"chat_id" db:"chat_id"`
+	Role       string     `json:"role" db:"role"`
+	MutedUntil *time.Time `json:"muted_until,omitempty" db:"muted_until"`
+}
+
+type Report struct {
+	Id         int        `json:"id" db:"id"`
+	ChatId     int        `json:"chat_id" db:"chat_id"`
+	MessageId  int        `json:"message_id" db:"message_id"`
+	ReporterId *int       `json:"reporter_id,omitempty" db:"reporter_id"`
+	Source     string     `json:"source" db:"source"`
+	Reason     string     `json:"reason" db:"reason"`
+	Status     string     `json:"status" db:"status"`
+	ResolvedBy *int       `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	Content    string     `json:"content" db:"content"`
+	Sender     string     `json:"sender" db:"sender"`
+}
+
+type ModerationAction struct {
+	Id           int        `json:"id" db:"id"`
+	ChatId       int        `json:"chat_id" db:"chat_id"`
+	ActorId      int        `json:"actor_id" db:"actor_id"`
+	TargetUserId *int       `json:"target_user_id,omitempty" db:"target_user_id"`
+	ReportId     *int       `json:"report_id,omitempty" db:"report_id"`
+	Action       string     `json:"action" db:"action"`
+	Reason       string     `json:"reason" db:"reason"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,19 @@
+package pkg
+
+import "time"
+
+// NotificationSettings — настройки уведомлений участника в конкретном чате.
+// Muted без MutedUntil означает бессрочное отключение
+type NotificationSettings struct {
+	Muted      bool       `json:"muted" db:"notifications_muted"`
+	MutedUntil *time.Time `json:"muted_until,omitempty" db:"notifications_muted_until"`
+}
+
+// ChatNotification — полезная нагрузка уведомления о новых сообщениях для сервиса notify
+type ChatNotification struct {
+	ChatId   int    `json:"chat_id"`
+	ChatName string `json:"chat_name"`
+	Count    int    `json:"count"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+}
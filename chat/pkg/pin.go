@@ -0,0 +1,12 @@
+package pkg
+
+import "time"
+
+type PinnedMessage struct {
+	MessageId int       `json:"message_id" db:"message_id"`
+	Content   string    `json:"content" db:"content"`
+	Sender    string    `json:"sender" db:"sender"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	PinnedBy  int       `json:"pinned_by" db:"pinned_by"`
+	PinnedAt  time.Time `json:"pinned_at" db:"pinned_at"`
+}
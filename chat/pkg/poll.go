@@ -0,0 +1,31 @@
+package pkg
+
+import "time"
+
+type Poll struct {
+	Id          int          `json:"id" db:"id"`
+	ChatId      int          `json:"chat_id" db:"chat_id"`
+	CreatedBy   int          `json:"created_by" db:"created_by"`
+	Question    string       `json:"question" db:"question"`
+	Multiple    bool         `json:"multiple" db:"multiple"`
+	Anonymous   bool         `json:"anonymous" db:"anonymous"`
+	ClosesAt    *time.Time   `json:"closes_at,omitempty" db:"closes_at"`
+	ClosedAt    *time.Time   `json:"closed_at,omitempty" db:"closed_at"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	Closed      bool         `json:"closed" db:"-"`
+	TotalVoters int          `json:"total_voters" db:"-"`
+	Options     []PollOption `json:"options" db:"-"`
+}
+
+type PollOption struct {
+	Id     int         `json:"id" db:"id"`
+	Text   string      `json:"text" db:"text"`
+	Votes  int         `json:"votes" db:"votes"`
+	Voters []PollVoter `json:"voters,omitempty" db:"-"`
+}
+
+// PollVoter — проголосовавший участник, в анонимных опросах не раскрывается
+type PollVoter struct {
+	UserId int    `json:"user_id" db:"user_id"`
+	Name   string `json:"name" db:"name"`
+}
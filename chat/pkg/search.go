@@ -0,0 +1,21 @@
+package pkg
+
+import "time"
+
+type SearchResult struct {
+	Id        int       `json:"id" db:"id"`
+	ChatId    int       `json:"chat_id" db:"chat_id"`
+	ChatName  string    `json:"chat_name" db:"chat_name"`
+	Sender    string    `json:"sender" db:"sender"`
+	Content   string    `json:"content" db:"content"`
+	Highlight string    `json:"highlight" db:"highlight"`
+	Rank      float64   `json:"rank" db:"rank"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type SearchPage struct {
+	Results []SearchResult `json:"results"`
+	Total   int            `json:"total"`
+	Limit   int            `json:"limit"`
+	Offset  int            `json:"offset"`
+}
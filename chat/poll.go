@@ -0,0 +1,476 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/XRS0/ToTalkB/chat/command"
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/XRS0/ToTalkB/wsqueue"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	minPollOptions        = 2
+	maxPollOptions        = 10
+	maxPollQuestionLength = 300
+	maxPollOptionLength   = 200
+
+	// uniqueViolation — код ошибки Postgres при нарушении уникальности
+	uniqueViolation = "23505"
+)
+
+var (
+	errPollClosed   = errors.New("poll is closed")
+	errAlreadyVoted = errors.New("you have already voted in this poll")
+	errInvalidPoll  = errors.New("poll needs a question and 2 to 10 distinct options")
+	errInvalidVote  = errors.New("invalid poll options")
+)
+
+// PollMessage — служебный фрейм с текущими результатами опроса
+type PollMessage struct {
+	Type string    `json:"type"`
+	Poll *pkg.Poll `json:"poll"`
+}
+
+// VoteInput — голос в опросе, приходит по REST или в поле vote сообщения WebSocket
+type VoteInput struct {
+	PollId    int   `json:"poll_id"`
+	OptionIds []int `json:"option_ids" binding:"required"`
+}
+
+type pollInput struct {
+	Question        string   `json:"question" binding:"required"`
+	Options         []string `json:"options" binding:"required"`
+	Multiple        bool     `json:"multiple"`
+	Anonymous       bool     `json:"anonymous"`
+	ClosesInMinutes *int     `json:"closes_in_minutes" binding:"omitempty,min=1"`
+}
+
+type PollHandler struct {
+	db  *sqlx.DB
+	hub *Hub
+}
+
+func NewPollHandler(db *sqlx.DB, hub *Hub) *PollHandler {
+	return &PollHandler{db: db, hub: hub}
+}
+
+// List возвращает опросы чата с результатами, новые первыми
+func (h *PollHandler) List(c *gin.Context) {
+	chatId, userId, ok := memberParams(c)
+	if !ok {
+		return
+	}
+	if _, err := getMember(h.db, chatId, userId); err != nil {
+		pollError(c, err)
+		return
+	}
+
+	var ids []int
+	if err := h.db.Select(&ids, "SELECT id FROM polls WHERE chat_id = $1 ORDER BY created_at DESC", chatId); err != nil {
+		pollError(c, err)
+		return
+	}
+	polls := make([]*pkg.Poll, 0, len(ids))
+	for _, id := range ids {
+		poll, err := loadPoll(h.db, id)
+		if err != nil {
+			pollError(c, err)
+			return
+		}
+		polls = append(polls, poll)
+	}
+	c.JSON(http.StatusOK, polls)
+}
+
+// Get возвращает опрос с результатами
+func (h *PollHandler) Get(c *gin.Context) {
+	chatId, pollId, userId, ok := pollParams(c)
+	if !ok {
+		return
+	}
+	if _, err := getMember(h.db, chatId, userId); err != nil {
+		pollError(c, err)
+		return
+	}
+
+	poll, err := loadPoll(h.db, pollId)
+	if err == nil && poll.ChatId != chatId {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		pollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, poll)
+}
+
+// Create создаёт опрос, closes_in_minutes — через сколько минут опрос закроется сам
+func (h *PollHandler) Create(c *gin.Context) {
+	chatId, userId, ok := memberParams(c)
+	if !ok {
+		return
+	}
+	var input pollInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	poll := pkg.Poll{
+		ChatId:    chatId,
+		CreatedBy: userId,
+		Question:  input.Question,
+		Multiple:  input.Multiple,
+		Anonymous: input.Anonymous,
+	}
+	if input.ClosesInMinutes != nil {
+		closesAt := time.Now().Add(time.Duration(*input.ClosesInMinutes) * time.Minute)
+		poll.ClosesAt = &closesAt
+	}
+
+	created, err := createPoll(h.db, h.hub, poll, input.Options)
+	if err != nil {
+		pollError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// Vote принимает голос участника, результаты рассылаются всему чату
+func (h *PollHandler) Vote(c *gin.Context) {
+	chatId, pollId, userId, ok := pollParams(c)
+	if !ok {
+		return
+	}
+	var input VoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	poll, err := vote(h.db, h.hub, chatId, pollId, userId, input.OptionIds)
+	if err != nil {
+		pollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, poll)
+}
+
+// Close досрочно закрывает опрос
+func (h *PollHandler) Close(c *gin.Context) {
+	chatId, pollId, userId, ok := pollParams(c)
+	if !ok {
+		return
+	}
+
+	err := withTx(h.db, func(tx *sqlx.Tx) error {
+		if err := requireAdmin(tx, chatId, userId); err != nil {
+			return err
+		}
+		res, err := tx.Exec(
+			"UPDATE polls SET closed_at = $1 WHERE id = $2 AND chat_id = $3 AND closed_at IS NULL",
+			time.Now(), pollId, chatId,
+		)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+	if err != nil {
+		pollError(c, err)
+		return
+	}
+
+	poll, err := broadcastPoll(h.db, h.hub, pollId)
+	if err != nil {
+		pollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, poll)
+}
+
+// PollCommand — команда /poll для создания опроса из чата:
+// /poll [-multi] [-anon] Вопрос | вариант 1 | вариант 2
+func PollCommand(db *sqlx.DB, hub *Hub) command.Command {
+	const usage = "/poll [-multi] [-anon] question | option | option"
+	return command.Command{
+		Name:        "poll",
+		Usage:       usage,
+		Description: "start a poll in this chat",
+		Handler: func(ctx context.Context, call *command.Call) (*command.Reply, error) {
+			poll := pkg.Poll{ChatId: call.ChatId, CreatedBy: call.UserId}
+			args := call.Args
+			for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+				switch args[0] {
+				case "-multi":
+					poll.Multiple = true
+				case "-anon":
+					poll.Anonymous = true
+				default:
+					return nil, &command.UsageError{Usage: usage}
+				}
+				args = args[1:]
+			}
+			parts := strings.Split(strings.Join(args, " "), "|")
+			if len(parts) < 1+minPollOptions {
+				return nil, &command.UsageError{Usage: usage}
+			}
+			poll.Question = parts[0]
+
+			if _, err := createPoll(db, hub, poll, parts[1:]); err != nil {
+				if errors.Is(err, errForbidden) || errors.Is(err, errInvalidPoll) {
+					return command.Private("%s", err.Error()), nil
+				}
+				return nil, err
+			}
+			return nil, nil
+		},
+	}
+}
+
+// createPoll сохраняет опрос организатора и рассылает его в чат
+func createPoll(db *sqlx.DB, hub *Hub, poll pkg.Poll, options []string) (*pkg.Poll, error) {
+	poll.Question = strings.TrimSpace(poll.Question)
+	texts, err := pollOptions(poll.Question, options)
+	if err != nil {
+		return nil, err
+	}
+
+	err = withTx(db, func(tx *sqlx.Tx) error {
+		if err := requireAdmin(tx, poll.ChatId, poll.CreatedBy); err != nil {
+			return err
+		}
+		err := tx.QueryRowx(
+			`INSERT INTO polls (chat_id, created_by, question, multiple, anonymous, closes_at)
+             VALUES ($1, $2, $3, $4, $5, $6)
+             RETURNING id`,
+			poll.ChatId, poll.CreatedBy, poll.Question, poll.Multiple, poll.Anonymous, poll.ClosesAt,
+		).Scan(&poll.Id)
+		if err != nil {
+			return err
+		}
+		for i, text := range texts {
+			_, err := tx.Exec("INSERT INTO poll_options (poll_id, position, text) VALUES ($1, $2, $3)", poll.Id, i, text)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if poll.ClosesAt != nil {
+		// итоговые результаты уходят в чат, когда опрос закрывается по времени
+		time.AfterFunc(time.Until(*poll.ClosesAt), func() {
+			if _, err := broadcastPoll(db, hub, poll.Id); err != nil {
+				log.Printf("Failed to broadcast closed poll: %v", err)
+			}
+		})
+	}
+	return broadcastPoll(db, hub, poll.Id)
+}
+
+// vote записывает бюллетень участника; повторный голос отклоняет первичный ключ poll_ballots
+func vote(db *sqlx.DB, hub *Hub, chatId, pollId, userId int, optionIds []int) (*pkg.Poll, error) {
+	err := withTx(db, func(tx *sqlx.Tx) error {
+		if err := checkCanPost(tx, chatId, userId); err != nil {
+			return err
+		}
+		var poll pkg.Poll
+		err := tx.Get(&poll,
+			"SELECT * FROM polls WHERE id = $1 AND chat_id = $2",
+			pollId, chatId,
+		)
+		if err != nil {
+			return err
+		}
+		if pollClosed(&poll, time.Now()) {
+			return errPollClosed
+		}
+
+		ids, err := ballotOptions(&poll, optionIds)
+		if err != nil {
+			return err
+		}
+		var valid int
+		if err := tx.Get(&valid, "SELECT COUNT(*) FROM poll_options WHERE poll_id = $1 AND id = ANY($2)", pollId, pq.Array(ids)); err != nil {
+			return err
+		}
+		if valid != len(ids) {
+			return errInvalidVote
+		}
+
+		_, err = tx.Exec("INSERT INTO poll_ballots (poll_id, user_id) VALUES ($1, $2)", pollId, userId)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return errAlreadyVoted
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			"INSERT INTO poll_votes (poll_id, user_id, option_id) SELECT $1, $2, unnest($3::integer[])",
+			pollId, userId, pq.Array(ids),
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return broadcastPoll(db, hub, pollId)
+}
+
+func loadPoll(db sqlx.Queryer, pollId int) (*pkg.Poll, error) {
+	var poll pkg.Poll
+	if err := sqlx.Get(db, &poll, "SELECT * FROM polls WHERE id = $1", pollId); err != nil {
+		return nil, err
+	}
+	poll.Closed = pollClosed(&poll, time.Now())
+
+	err := sqlx.Select(db, &poll.Options,
+		`SELECT o.id, o.text, COUNT(v.user_id) AS votes
+         FROM poll_options o
+         LEFT JOIN poll_votes v ON v.option_id = o.id
+         WHERE o.poll_id = $1
+         GROUP BY o.id
+         ORDER BY o.position ASC`,
+		pollId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Get(db, &poll.TotalVoters, "SELECT COUNT(*) FROM poll_ballots WHERE poll_id = $1", pollId); err != nil {
+		return nil, err
+	}
+	if poll.Anonymous {
+		return &poll, nil
+	}
+
+	var voters []struct {
+		OptionId int `db:"option_id"`
+		pkg.PollVoter
+	}
+	err = sqlx.Select(db, &voters,
+		`SELECT v.option_id, u.id AS user_id, u.name
+         FROM poll_votes v
+         JOIN users u ON u.id = v.user_id
+         WHERE v.poll_id = $1
+         ORDER BY u.name ASC`,
+		pollId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range voters {
+		for i := range poll.Options {
+			if poll.Options[i].Id == v.OptionId {
+				poll.Options[i].Voters = append(poll.Options[i].Voters, v.PollVoter)
+			}
+		}
+	}
+	return &poll, nil
+}
+
+// broadcastPoll рассылает актуальные результаты опроса всем участникам чата
+func broadcastPoll(db sqlx.Queryer, hub *Hub, pollId int) (*pkg.Poll, error) {
+	poll, err := loadPoll(db, pollId)
+	if err != nil {
+		return nil, err
+	}
+	jsonMsg, err := json.Marshal(PollMessage{Type: framePoll, Poll: poll})
+	if err != nil {
+		return nil, err
+	}
+	hub.Publish(strconv.Itoa(poll.ChatId), wsqueue.Message{
+		Kind: framePoll,
+		Key:  framePoll + ":" + strconv.Itoa(poll.Id),
+		Data: jsonMsg,
+	})
+	return poll, nil
+}
+
+// pollOptions проверяет вопрос и возвращает варианты ответа без лишних пробелов
+func pollOptions(question string, options []string) ([]string, error) {
+	if question == "" || utf8.RuneCountInString(question) > maxPollQuestionLength {
+		return nil, errInvalidPoll
+	}
+	seen := make(map[string]bool, len(options))
+	texts := make([]string, 0, len(options))
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" || utf8.RuneCountInString(option) > maxPollOptionLength || seen[option] {
+			return nil, errInvalidPoll
+		}
+		seen[option] = true
+		texts = append(texts, option)
+	}
+	if len(texts) < minPollOptions || len(texts) > maxPollOptions {
+		return nil, errInvalidPoll
+	}
+	return texts, nil
+}
+
+// ballotOptions убирает повторы из выбранных вариантов и проверяет их число;
+// принадлежность вариантов опросу проверяется в базе
+func ballotOptions(poll *pkg.Poll, optionIds []int) ([]int, error) {
+	ids := make([]int, 0, len(optionIds))
+	seen := make(map[int]bool, len(optionIds))
+	for _, id := range optionIds {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 || (!poll.Multiple && len(ids) > 1) {
+		return nil, errInvalidVote
+	}
+	return ids, nil
+}
+
+func pollClosed(poll *pkg.Poll, now time.Time) bool {
+	return poll.ClosedAt != nil || (poll.ClosesAt != nil && !poll.ClosesAt.After(now))
+}
+
+func pollParams(c *gin.Context) (chatId, pollId, userId int, ok bool) {
+	chatId, userId, ok = memberParams(c)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	pollId, err := strconv.Atoi(c.Param("pollId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid poll id"})
+		return 0, 0, 0, false
+	}
+	return chatId, pollId, userId, true
+}
+
+func pollError(c *gin.Context, err error) {
+	var muted *mutedError
+	switch {
+	case errors.Is(err, errInvalidPoll), errors.Is(err, errInvalidVote):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, errPollClosed), errors.Is(err, errAlreadyVoted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &muted):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		moderationError(c, err)
+	}
+}
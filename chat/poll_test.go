@@ -0,0 +1,97 @@
+package chat
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+)
+
+func TestPollClosed(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	tests := []struct {
+		name string
+		poll pkg.Poll
+		want bool
+	}{
+		{"open without deadline", pkg.Poll{}, false},
+		{"deadline ahead", pkg.Poll{ClosesAt: at(time.Minute)}, false},
+		{"deadline reached", pkg.Poll{ClosesAt: at(0)}, true},
+		{"deadline passed", pkg.Poll{ClosesAt: at(-time.Minute)}, true},
+		{"closed by hand", pkg.Poll{ClosedAt: at(-time.Hour)}, true},
+		{"closed by hand before deadline", pkg.Poll{ClosesAt: at(time.Hour), ClosedAt: at(-time.Minute)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pollClosed(&tt.poll, now); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBallotOptions(t *testing.T) {
+	single := &pkg.Poll{}
+	multiple := &pkg.Poll{Multiple: true}
+	tests := []struct {
+		name    string
+		poll    *pkg.Poll
+		options []int
+		want    []int
+		err     error
+	}{
+		{"single choice", single, []int{3}, []int{3}, nil},
+		{"repeated single choice", single, []int{3, 3}, []int{3}, nil},
+		{"several options in a single choice poll", single, []int{3, 4}, nil, errInvalidVote},
+		{"nothing chosen", single, nil, nil, errInvalidVote},
+		{"multiple choice", multiple, []int{4, 3, 4}, []int{4, 3}, nil},
+		{"nothing chosen in a multiple choice poll", multiple, []int{}, nil, errInvalidVote},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ballotOptions(tt.poll, tt.options)
+			if !errors.Is(err, tt.err) || !slices.Equal(got, tt.want) {
+				t.Fatalf("expected (%v, %v), got (%v, %v)", tt.want, tt.err, got, err)
+			}
+		})
+	}
+}
+
+func TestPollOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		question string
+		options  []string
+		want     []string
+	}{
+		{"options are trimmed", "Когда?", []string{" утром ", "вечером"}, []string{"утром", "вечером"}},
+		{"no question", "", []string{"a", "b"}, nil},
+		{"question too long", strings.Repeat("?", maxPollQuestionLength+1), []string{"a", "b"}, nil},
+		{"one option", "Когда?", []string{"утром"}, nil},
+		{"too many options", "Когда?", strings.Split("a b c d e f g h i j k", " "), nil},
+		{"blank option", "Когда?", []string{"утром", " "}, nil},
+		{"duplicate options", "Когда?", []string{"утром", "утром "}, nil},
+		{"option too long", "Когда?", []string{"утром", strings.Repeat("я", maxPollOptionLength+1)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pollOptions(tt.question, tt.options)
+			if tt.want == nil {
+				if !errors.Is(err, errInvalidPoll) {
+					t.Fatalf("expected errInvalidPoll, got %v, %v", got, err)
+				}
+				return
+			}
+			if err != nil || !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %v, got %v, %v", tt.want, got, err)
+			}
+		})
+	}
+}
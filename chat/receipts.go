@@ -0,0 +1,155 @@
+package chat
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/XRS0/ToTalkB/wsqueue"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// ReceiptMessage — служебный фрейм с отметками доставки и прочтения участника
+type ReceiptMessage struct {
+	Type    string      `json:"type"`
+	Receipt pkg.Receipt `json:"receipt"`
+}
+
+type receiptInput struct {
+	DeliveredSeq int64 `json:"delivered_seq" binding:"min=0"`
+	ReadSeq      int64 `json:"read_seq" binding:"min=0"`
+}
+
+type ReceiptHandler struct {
+	db  *sqlx.DB
+	hub *Hub
+}
+
+func NewReceiptHandler(db *sqlx.DB, hub *Hub) *ReceiptHandler {
+	return &ReceiptHandler{db: db, hub: hub}
+}
+
+// List возвращает отметки доставки и прочтения всех участников чата
+func (h *ReceiptHandler) List(c *gin.Context) {
+	chatId, userId, ok := memberParams(c)
+	if !ok {
+		return
+	}
+	if _, err := getMember(h.db, chatId, userId); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	receipts := []pkg.Receipt{}
+	if err := h.db.Select(&receipts, "SELECT * FROM message_receipts WHERE chat_id = $1 ORDER BY user_id", chatId); err != nil {
+		moderationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, receipts)
+}
+
+// Update отмечает сообщения до delivered_seq доставленными, а до read_seq — прочитанными
+func (h *ReceiptHandler) Update(c *gin.Context) {
+	chatId, userId, ok := memberParams(c)
+	if !ok {
+		return
+	}
+	var input receiptInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	receipt, err := acknowledge(h.db, h.hub, chatId, userId, input.DeliveredSeq, input.ReadSeq)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, receipt)
+}
+
+// Status возвращает, кому из участников сообщение доставлено и кем прочитано
+func (h *ReceiptHandler) Status(c *gin.Context) {
+	chatId, messageId, userId, ok := pinParams(c)
+	if !ok {
+		return
+	}
+	if _, err := getMember(h.db, chatId, userId); err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	var message struct {
+		Seq      int64 `db:"seq"`
+		SenderId int   `db:"sender_id"`
+	}
+	err := h.db.Get(&message, "SELECT seq, sender_id FROM messages WHERE id = $1 AND chat_id = $2", messageId, chatId)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+
+	status := pkg.MessageStatus{MessageId: messageId, Seq: message.Seq, DeliveredTo: []int{}, ReadBy: []int{}}
+	var receipts []pkg.Receipt
+	err = h.db.Select(&receipts,
+		"SELECT * FROM message_receipts WHERE chat_id = $1 AND user_id <> $2 AND delivered_seq >= $3 ORDER BY user_id",
+		chatId, message.SenderId, message.Seq,
+	)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+	for _, r := range receipts {
+		status.DeliveredTo = append(status.DeliveredTo, r.UserId)
+		if r.ReadSeq >= message.Seq {
+			status.ReadBy = append(status.ReadBy, r.UserId)
+		}
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// acknowledge сдвигает отметки участника вперёд и рассылает их в комнату чата.
+// Прочитанное считается доставленным, отметки не уходят дальше последнего сообщения чата
+// и никогда не откатываются назад
+func acknowledge(db *sqlx.DB, hub *Hub, chatId, userId int, deliveredSeq, readSeq int64) (*pkg.Receipt, error) {
+	if _, err := getMember(db, chatId, userId); err != nil {
+		return nil, err
+	}
+	var lastSeq int64
+	if err := db.Get(&lastSeq, "SELECT last_seq FROM chats WHERE id = $1", chatId); err != nil {
+		return nil, err
+	}
+	readSeq = min(max(readSeq, 0), lastSeq)
+	deliveredSeq = min(max(deliveredSeq, readSeq), lastSeq)
+
+	var receipt pkg.Receipt
+	err := db.Get(&receipt,
+		`INSERT INTO message_receipts (chat_id, user_id, delivered_seq, read_seq, updated_at)
+         VALUES ($1, $2, $3, $4, now())
+         ON CONFLICT (chat_id, user_id) DO UPDATE SET
+             delivered_seq = GREATEST(message_receipts.delivered_seq, EXCLUDED.delivered_seq),
+             read_seq = GREATEST(message_receipts.read_seq, EXCLUDED.read_seq),
+             updated_at = EXCLUDED.updated_at
+         RETURNING *`,
+		chatId, userId, deliveredSeq, readSeq,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonMsg, err := json.Marshal(ReceiptMessage{Type: frameReceipt, Receipt: receipt})
+	if err != nil {
+		log.Printf("Failed to marshal receipt: %v", err)
+		return &receipt, nil
+	}
+	// отметки одного участника схлопываются: клиенту важна только последняя
+	hub.Publish(strconv.Itoa(chatId), wsqueue.Message{
+		Kind: frameReceipt,
+		Key:  frameReceipt + ":" + strconv.Itoa(userId),
+		Data: jsonMsg,
+	})
+	return &receipt, nil
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+)
+
+// ChatMemory — реализация ChatRepository в памяти для тестов
+type ChatMemory struct {
+	mu      sync.RWMutex
+	nextId  int
+	chats   map[int]pkg.Chat
+	members map[int]map[int]string
+}
+
+func NewChatMemory() *ChatMemory {
+	return &ChatMemory{
+		chats:   make(map[int]pkg.Chat),
+		members: make(map[int]map[int]string),
+	}
+}
+
+func (r *ChatMemory) Create(ctx context.Context, chat *pkg.Chat) error {
+	chat.Name = strings.TrimSpace(chat.Name)
+	if chat.Name == "" {
+		return ErrInvalidChat
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextId++
+	chat.Id = r.nextId
+	r.chats[chat.Id] = *chat
+	r.members[chat.Id] = map[int]string{chat.OwnerId: pkg.RoleOwner}
+	return nil
+}
+
+func (r *ChatMemory) GetById(ctx context.Context, id int) (*pkg.Chat, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	chat, ok := r.chats[id]
+	if !ok {
+		return nil, ErrChatNotFound
+	}
+	return &chat, nil
+}
+
+func (r *ChatMemory) ListByMember(ctx context.Context, userId int) ([]pkg.Chat, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	chats := []pkg.Chat{}
+	for id, members := range r.members {
+		if _, ok := members[userId]; ok {
+			chats = append(chats, r.chats[id])
+		}
+	}
+	sort.Slice(chats, func(i, j int) bool { return chats[i].Id < chats[j].Id })
+	return chats, nil
+}
+
+func (r *ChatMemory) AddMember(ctx context.Context, chatId, userId int, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	members, ok := r.members[chatId]
+	if !ok {
+		return ErrChatNotFound
+	}
+	if _, ok := members[userId]; ok {
+		return ErrAlreadyMember
+	}
+	members[userId] = role
+	return nil
+}
+
+func (r *ChatMemory) IsMember(ctx context.Context, chatId, userId int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.members[chatId][userId]
+	return ok, nil
+}
+
+// MessageMemory — реализация MessageRepository в памяти для тестов.
+// Вложения не хранит, имя отправителя задаётся через SetUserName
+type MessageMemory struct {
+	mu          sync.RWMutex
+	chats       *ChatMemory
+	nextId      int
+	messages    map[int][]pkg.Message
+	lastSeq     map[int]int64
+	names       map[int]string
+	flags       map[int][]string
+	nextSchedId int
+	scheduled   []scheduledMemory
+}
+
+type scheduledMemory struct {
+	pkg.ScheduledMessage
+	flags []string
+}
+
+func NewMessageMemory(chats *ChatMemory) *MessageMemory {
+	return &MessageMemory{
+		chats:    chats,
+		messages: make(map[int][]pkg.Message),
+		lastSeq:  make(map[int]int64),
+		names:    make(map[int]string),
+		flags:    make(map[int][]string),
+	}
+}
+
+func (r *MessageMemory) SetUserName(userId int, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names[userId] = name
+}
+
+// Flags возвращает флаги фильтров, записанные для сообщения
+func (r *MessageMemory) Flags(messageId int) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.flags[messageId]
+}
+
+func (r *MessageMemory) Create(ctx context.Context, msg NewMessage) (*pkg.Message, error) {
+	if msg.Content == "" && len(msg.AttachmentIds) == 0 {
+		return nil, ErrEmptyMessage
+	}
+	if err := checkExpiry(msg); err != nil {
+		return nil, err
+	}
+	if _, err := r.chats.GetById(ctx, msg.ChatId); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.insert(msg), nil
+}
+
+func (r *MessageMemory) insert(msg NewMessage) *pkg.Message {
+	r.nextId++
+	r.lastSeq[msg.ChatId]++
+	sender, ok := r.names[msg.SenderId]
+	if !ok {
+		sender = strconv.Itoa(msg.SenderId)
+	}
+	message := pkg.Message{
+		Id:        r.nextId,
+		ChatId:    msg.ChatId,
+		Seq:       r.lastSeq[msg.ChatId],
+		SenderId:  msg.SenderId,
+		Sender:    sender,
+		Content:   msg.Content,
+		CreatedAt: msg.CreatedAt,
+		ExpiresAt: msg.ExpiresAt,
+	}
+	r.messages[msg.ChatId] = append(r.messages[msg.ChatId], message)
+	if len(msg.Flags) > 0 {
+		r.flags[message.Id] = append([]string(nil), msg.Flags...)
+	}
+	return &message
+}
+
+func (r *MessageMemory) History(ctx context.Context, chatId int, afterSeq int64) ([]pkg.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := time.Now()
+	messages := []pkg.Message{}
+	for _, msg := range r.messages[chatId] {
+		if msg.Seq > afterSeq && (msg.ExpiresAt == nil || msg.ExpiresAt.After(now)) {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+func (r *MessageMemory) Schedule(ctx context.Context, msg NewMessage, sendAt time.Time) (*pkg.ScheduledMessage, error) {
+	if msg.Content == "" {
+		return nil, ErrEmptyMessage
+	}
+	msg.CreatedAt = sendAt
+	if err := checkExpiry(msg); err != nil {
+		return nil, err
+	}
+	if _, err := r.chats.GetById(ctx, msg.ChatId); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextSchedId++
+	scheduled := pkg.ScheduledMessage{
+		Id:        r.nextSchedId,
+		ChatId:    msg.ChatId,
+		SenderId:  msg.SenderId,
+		Content:   msg.Content,
+		SendAt:    sendAt,
+		ExpiresAt: msg.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	r.scheduled = append(r.scheduled, scheduledMemory{ScheduledMessage: scheduled, flags: msg.Flags})
+	return &scheduled, nil
+}
+
+func (r *MessageMemory) Scheduled(ctx context.Context, chatId int) ([]pkg.ScheduledMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	scheduled := []pkg.ScheduledMessage{}
+	for _, s := range r.scheduled {
+		if s.ChatId == chatId {
+			scheduled = append(scheduled, s.ScheduledMessage)
+		}
+	}
+	sort.SliceStable(scheduled, func(i, j int) bool { return scheduled[i].SendAt.Before(scheduled[j].SendAt) })
+	return scheduled, nil
+}
+
+func (r *MessageMemory) CancelScheduled(ctx context.Context, chatId, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, s := range r.scheduled {
+		if s.Id == id && s.ChatId == chatId {
+			r.scheduled = append(r.scheduled[:i], r.scheduled[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotScheduled
+}
+
+func (r *MessageMemory) PublishDue(ctx context.Context, now time.Time, limit int) ([]pkg.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sort.SliceStable(r.scheduled, func(i, j int) bool { return r.scheduled[i].SendAt.Before(r.scheduled[j].SendAt) })
+
+	published := []pkg.Message{}
+	n := 0
+	for n < len(r.scheduled) && n < limit && !r.scheduled[n].SendAt.After(now) {
+		s := r.scheduled[n]
+		n++
+		if s.ExpiresAt != nil && !s.ExpiresAt.After(now) {
+			continue
+		}
+		published = append(published, *r.insert(NewMessage{
+			ChatId:    s.ChatId,
+			SenderId:  s.SenderId,
+			Content:   s.Content,
+			CreatedAt: now,
+			ExpiresAt: s.ExpiresAt,
+			Flags:     s.flags,
+		}))
+	}
+	r.scheduled = r.scheduled[n:]
+	return published, nil
+}
+
+func (r *MessageMemory) PurgeExpired(ctx context.Context, now time.Time, limit int) ([]pkg.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expired := []pkg.Message{}
+	for chatId, messages := range r.messages {
+		kept := messages[:0]
+		for _, msg := range messages {
+			if len(expired) < limit && msg.ExpiresAt != nil && !msg.ExpiresAt.After(now) {
+				expired = append(expired, msg)
+				delete(r.flags, msg.Id)
+				continue
+			}
+			kept = append(kept, msg)
+		}
+		r.messages[chatId] = kept
+	}
+	return expired, nil
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+)
+
+var testNow = time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func after(d time.Duration) *time.Time {
+	t := testNow.Add(d)
+	return &t
+}
+
+// contents возвращает тексты сообщений в порядке отправки
+func contents(messages []pkg.Message) []string {
+	var texts []string
+	for _, msg := range messages {
+		texts = append(texts, msg.Content)
+	}
+	return texts
+}
+
+func TestCreateChat(t *testing.T) {
+	ctx := context.Background()
+	chats := NewChatMemory()
+
+	chat := &pkg.Chat{Name: "  Команда  ", OwnerId: 7}
+	if err := chats.Create(ctx, chat); err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	if chat.Id == 0 || chat.Name != "Команда" {
+		t.Fatalf("unexpected chat %+v", chat)
+	}
+	// создатель становится участником вместе с созданием чата
+	if member, _ := chats.IsMember(ctx, chat.Id, 7); !member {
+		t.Fatal("expected owner to be a member")
+	}
+	if err := chats.AddMember(ctx, chat.Id, 7, pkg.RoleMember); !errors.Is(err, ErrAlreadyMember) {
+		t.Fatalf("expected ErrAlreadyMember for the owner, got %v", err)
+	}
+
+	// без имени не создаётся ни чат, ни участник
+	if err := chats.Create(ctx, &pkg.Chat{Name: " ", OwnerId: 8}); !errors.Is(err, ErrInvalidChat) {
+		t.Fatalf("expected ErrInvalidChat, got %v", err)
+	}
+	list, err := chats.ListByMember(ctx, 8)
+	if err != nil || len(list) != 0 {
+		t.Fatalf("expected no chats for the failed owner, got %v, %v", list, err)
+	}
+
+	if err := chats.AddMember(ctx, 100, 8, pkg.RoleMember); !errors.Is(err, ErrChatNotFound) {
+		t.Fatalf("expected ErrChatNotFound, got %v", err)
+	}
+	if _, err := chats.GetById(ctx, 100); !errors.Is(err, ErrChatNotFound) {
+		t.Fatalf("expected ErrChatNotFound, got %v", err)
+	}
+}
+
+func TestCreateMessageErrors(t *testing.T) {
+	ctx := context.Background()
+	chats := NewChatMemory()
+	chat := &pkg.Chat{Name: "chat", OwnerId: 1}
+	if err := chats.Create(ctx, chat); err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	messages := NewMessageMemory(chats)
+
+	tests := []struct {
+		name string
+		msg  NewMessage
+		want error
+	}{
+		{"empty", NewMessage{ChatId: chat.Id, SenderId: 1, CreatedAt: testNow}, ErrEmptyMessage},
+		{"unknown chat", NewMessage{ChatId: 100, SenderId: 1, Content: "hi", CreatedAt: testNow}, ErrChatNotFound},
+		{"expires when sent", NewMessage{ChatId: chat.Id, SenderId: 1, Content: "hi", CreatedAt: testNow, ExpiresAt: after(0)}, ErrInvalidExpiry},
+		{"expired before sent", NewMessage{ChatId: chat.Id, SenderId: 1, Content: "hi", CreatedAt: testNow, ExpiresAt: after(-time.Minute)}, ErrInvalidExpiry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := messages.Create(ctx, tt.msg); !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := messages.Schedule(ctx, NewMessage{ChatId: chat.Id, SenderId: 1, Content: "hi", ExpiresAt: after(time.Minute)}, testNow.Add(time.Hour)); !errors.Is(err, ErrInvalidExpiry) {
+		t.Fatalf("expected ErrInvalidExpiry for a message expiring before it is sent, got %v", err)
+	}
+	if err := messages.CancelScheduled(ctx, chat.Id, 100); !errors.Is(err, ErrNotScheduled) {
+		t.Fatalf("expected ErrNotScheduled, got %v", err)
+	}
+}
+
+func TestPublishDue(t *testing.T) {
+	ctx := context.Background()
+	chats := NewChatMemory()
+	chat := &pkg.Chat{Name: "chat", OwnerId: 1}
+	if err := chats.Create(ctx, chat); err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	messages := NewMessageMemory(chats)
+
+	schedule := func(content string, sendAt time.Duration, flags ...string) {
+		msg := NewMessage{ChatId: chat.Id, SenderId: 1, Content: content, Flags: flags}
+		if _, err := messages.Schedule(ctx, msg, testNow.Add(sendAt)); err != nil {
+			t.Fatalf("schedule %s: %v", content, err)
+		}
+	}
+	schedule("second", 2*time.Minute)
+	schedule("later", time.Hour)
+	schedule("first", time.Minute, "links")
+	schedule("third", 3*time.Minute)
+
+	// наступившие публикуются по времени отправки, не больше limit за раз
+	published, err := messages.PublishDue(ctx, testNow.Add(5*time.Minute), 2)
+	if err != nil {
+		t.Fatalf("publish due: %v", err)
+	}
+	if got := contents(published); !slices.Equal(got, []string{"first", "second"}) {
+		t.Fatalf("expected first and second, got %v", got)
+	}
+	if flags := messages.Flags(published[0].Id); !slices.Equal(flags, []string{"links"}) {
+		t.Fatalf("expected flags to be carried over, got %v", flags)
+	}
+	published, err = messages.PublishDue(ctx, testNow.Add(5*time.Minute), 10)
+	if err != nil {
+		t.Fatalf("publish due: %v", err)
+	}
+	if got := contents(published); !slices.Equal(got, []string{"third"}) {
+		t.Fatalf("expected third, got %v", got)
+	}
+
+	history, err := messages.History(ctx, chat.Id, 0)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if got := contents(history); !slices.Equal(got, []string{"first", "second", "third"}) {
+		t.Fatalf("unexpected history %v", got)
+	}
+	for i, msg := range history {
+		if msg.Seq != int64(i+1) {
+			t.Fatalf("expected seq %d for %s, got %d", i+1, msg.Content, msg.Seq)
+		}
+	}
+	scheduled, err := messages.Scheduled(ctx, chat.Id)
+	if err != nil || len(scheduled) != 1 || scheduled[0].Content != "later" {
+		t.Fatalf("expected only the later message to stay scheduled, got %v, %v", scheduled, err)
+	}
+
+	// сообщение, истёкшее до публикации, снимается без публикации
+	stale := NewMessage{ChatId: chat.Id, SenderId: 1, Content: "stale", ExpiresAt: after(2 * time.Hour)}
+	if _, err := messages.Schedule(ctx, stale, testNow.Add(90*time.Minute)); err != nil {
+		t.Fatalf("schedule stale: %v", err)
+	}
+	published, err = messages.PublishDue(ctx, testNow.Add(3*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("publish due: %v", err)
+	}
+	if got := contents(published); !slices.Equal(got, []string{"later"}) {
+		t.Fatalf("expected only later to be published, got %v", got)
+	}
+	if scheduled, _ := messages.Scheduled(ctx, chat.Id); len(scheduled) != 0 {
+		t.Fatalf("expected nothing scheduled, got %v", scheduled)
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	chats := NewChatMemory()
+	chat := &pkg.Chat{Name: "chat", OwnerId: 1}
+	if err := chats.Create(ctx, chat); err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	messages := NewMessageMemory(chats)
+
+	for _, msg := range []NewMessage{
+		{Content: "kept"},
+		{Content: "short", ExpiresAt: after(time.Minute), Flags: []string{"blocklist"}},
+		{Content: "long", ExpiresAt: after(time.Hour)},
+		{Content: "medium", ExpiresAt: after(2 * time.Minute)},
+	} {
+		msg.ChatId, msg.SenderId, msg.CreatedAt = chat.Id, 1, testNow
+		if _, err := messages.Create(ctx, msg); err != nil {
+			t.Fatalf("create %s: %v", msg.Content, err)
+		}
+	}
+
+	purged, err := messages.PurgeExpired(ctx, testNow.Add(5*time.Minute), 1)
+	if err != nil {
+		t.Fatalf("purge expired: %v", err)
+	}
+	if got := contents(purged); !slices.Equal(got, []string{"short"}) {
+		t.Fatalf("expected limit to purge only short, got %v", got)
+	}
+	if flags := messages.Flags(purged[0].Id); flags != nil {
+		t.Fatalf("expected flags of a purged message to be removed, got %v", flags)
+	}
+	purged, err = messages.PurgeExpired(ctx, testNow.Add(5*time.Minute), 10)
+	if err != nil {
+		t.Fatalf("purge expired: %v", err)
+	}
+	if got := contents(purged); !slices.Equal(got, []string{"medium"}) {
+		t.Fatalf("expected medium, got %v", got)
+	}
+
+	messages.mu.RLock()
+	remaining := contents(messages.messages[chat.Id])
+	messages.mu.RUnlock()
+	if !slices.Equal(remaining, []string{"kept", "long"}) {
+		t.Fatalf("unexpected remaining messages %v", remaining)
+	}
+}
@@ -0,0 +1,410 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// foreignKeyViolation — код ошибки Postgres при нарушении внешнего ключа
+const foreignKeyViolation = "23503"
+
+type ChatPostgres struct {
+	db *sqlx.DB
+}
+
+func NewChatPostgres(db *sqlx.DB) *ChatPostgres {
+	return &ChatPostgres{db: db}
+}
+
+func (r *ChatPostgres) Create(ctx context.Context, chat *pkg.Chat) error {
+	chat.Name = strings.TrimSpace(chat.Name)
+	if chat.Name == "" {
+		return ErrInvalidChat
+	}
+
+	return withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx,
+			"INSERT INTO chats (name, created_by, event_id) VALUES ($1, $2, $3) RETURNING id",
+			chat.Name, chat.OwnerId, chat.EventId,
+		).Scan(&chat.Id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO chat_members (user_id, chat_id, role) VALUES ($1, $2, $3)",
+			chat.OwnerId, chat.Id, pkg.RoleOwner,
+		)
+		return err
+	})
+}
+
+func (r *ChatPostgres) GetById(ctx context.Context, id int) (*pkg.Chat, error) {
+	var chat pkg.Chat
+	err := r.db.GetContext(ctx, &chat, "SELECT id, name, created_by, event_id FROM chats WHERE id = $1", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrChatNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+func (r *ChatPostgres) ListByMember(ctx context.Context, userId int) ([]pkg.Chat, error) {
+	chats := []pkg.Chat{}
+	err := r.db.SelectContext(ctx, &chats,
+		`SELECT c.id, c.name, c.created_by, c.event_id
+         FROM chats c
+         JOIN chat_members cm ON cm.chat_id = c.id
+         WHERE cm.user_id = $1
+         ORDER BY c.id ASC`,
+		userId,
+	)
+	return chats, err
+}
+
+func (r *ChatPostgres) AddMember(ctx context.Context, chatId, userId int, role string) error {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO chat_members (user_id, chat_id, role) VALUES ($1, $2, $3)
+         ON CONFLICT (user_id, chat_id) DO NOTHING`,
+		userId, chatId, role,
+	)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return ErrChatNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAlreadyMember
+	}
+	return nil
+}
+
+func (r *ChatPostgres) IsMember(ctx context.Context, chatId, userId int) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists,
+		"SELECT EXISTS(SELECT 1 FROM chat_members WHERE chat_id = $1 AND user_id = $2)",
+		chatId, userId,
+	)
+	return exists, err
+}
+
+type MessagePostgres struct {
+	db *sqlx.DB
+}
+
+func NewMessagePostgres(db *sqlx.DB) *MessagePostgres {
+	return &MessagePostgres{db: db}
+}
+
+func (r *MessagePostgres) Create(ctx context.Context, msg NewMessage) (*pkg.Message, error) {
+	if msg.Content == "" && len(msg.AttachmentIds) == 0 {
+		return nil, ErrEmptyMessage
+	}
+	if err := checkExpiry(msg); err != nil {
+		return nil, err
+	}
+
+	var message *pkg.Message
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var err error
+		if message, err = InsertMessage(ctx, tx, msg); err != nil {
+			return err
+		}
+		if message.Attachments, err = linkAttachments(ctx, tx, message.Id, msg.ChatId, msg.SenderId, msg.AttachmentIds); err != nil {
+			return err
+		}
+		return flagMessage(ctx, tx, msg.ChatId, message.Id, msg.Flags)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (r *MessagePostgres) History(ctx context.Context, chatId int, afterSeq int64) ([]pkg.Message, error) {
+	messages := []pkg.Message{}
+	err := r.db.SelectContext(ctx, &messages,
+		`SELECT m.id, m.chat_id, m.seq, m.sender_id, m.content, u.name AS sender, m.created_at, m.expires_at
+         FROM messages m
+         JOIN users u ON m.sender_id = u.id
+         WHERE m.chat_id = $1 AND m.seq > $2 AND (m.expires_at IS NULL OR m.expires_at > $3)
+         ORDER BY m.seq ASC`,
+		chatId, afterSeq, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := withAttachments(ctx, r.db, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *MessagePostgres) Schedule(ctx context.Context, msg NewMessage, sendAt time.Time) (*pkg.ScheduledMessage, error) {
+	if msg.Content == "" {
+		return nil, ErrEmptyMessage
+	}
+	msg.CreatedAt = sendAt
+	if err := checkExpiry(msg); err != nil {
+		return nil, err
+	}
+
+	var scheduled pkg.ScheduledMessage
+	err := r.db.GetContext(ctx, &scheduled,
+		`INSERT INTO scheduled_messages (chat_id, sender_id, content, flags, send_at, expires_at)
+         VALUES ($1, $2, $3, $4, $5, $6)
+         RETURNING id, chat_id, sender_id, content, send_at, expires_at, created_at`,
+		msg.ChatId, msg.SenderId, msg.Content, pq.StringArray(msg.Flags), sendAt, msg.ExpiresAt,
+	)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return nil, ErrChatNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &scheduled, nil
+}
+
+func (r *MessagePostgres) Scheduled(ctx context.Context, chatId int) ([]pkg.ScheduledMessage, error) {
+	scheduled := []pkg.ScheduledMessage{}
+	err := r.db.SelectContext(ctx, &scheduled,
+		`SELECT id, chat_id, sender_id, content, send_at, expires_at, created_at
+         FROM scheduled_messages WHERE chat_id = $1
+         ORDER BY send_at ASC, id ASC`,
+		chatId,
+	)
+	return scheduled, err
+}
+
+func (r *MessagePostgres) CancelScheduled(ctx context.Context, chatId, id int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM scheduled_messages WHERE id = $1 AND chat_id = $2", id, chatId)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotScheduled
+	}
+	return nil
+}
+
+// PublishDue забирает наступившие сообщения с SKIP LOCKED, поэтому несколько экземпляров
+// сервиса не опубликуют одно сообщение дважды
+func (r *MessagePostgres) PublishDue(ctx context.Context, now time.Time, limit int) ([]pkg.Message, error) {
+	published := []pkg.Message{}
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var due []struct {
+			pkg.ScheduledMessage
+			Flags pq.StringArray `db:"flags"`
+		}
+		err := tx.SelectContext(ctx, &due,
+			`SELECT id, chat_id, sender_id, content, flags, send_at, expires_at, created_at
+             FROM scheduled_messages
+             WHERE send_at <= $1
+             ORDER BY send_at ASC, id ASC
+             LIMIT $2
+             FOR UPDATE SKIP LOCKED`,
+			now, limit,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range due {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM scheduled_messages WHERE id = $1", s.Id); err != nil {
+				return err
+			}
+			// сообщение успело истечь, пока ждало публикации
+			if s.ExpiresAt != nil && !s.ExpiresAt.After(now) {
+				continue
+			}
+			message, err := InsertMessage(ctx, tx, NewMessage{
+				ChatId:    s.ChatId,
+				SenderId:  s.SenderId,
+				Content:   s.Content,
+				CreatedAt: now,
+				ExpiresAt: s.ExpiresAt,
+			})
+			if err != nil {
+				return err
+			}
+			if err := flagMessage(ctx, tx, s.ChatId, message.Id, s.Flags); err != nil {
+				return err
+			}
+			published = append(published, *message)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return published, nil
+}
+
+func (r *MessagePostgres) PurgeExpired(ctx context.Context, now time.Time, limit int) ([]pkg.Message, error) {
+	expired := []pkg.Message{}
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.SelectContext(ctx, &expired,
+			`SELECT id, chat_id, seq, sender_id, content, created_at, expires_at
+             FROM messages
+             WHERE expires_at <= $1
+             ORDER BY expires_at ASC
+             LIMIT $2
+             FOR UPDATE SKIP LOCKED`,
+			now, limit,
+		)
+		if err != nil || len(expired) == 0 {
+			return err
+		}
+		// вложения удаляются каскадно вместе с сообщением, ключи файлов нужно забрать до этого
+		if err := withAttachments(ctx, tx, expired); err != nil {
+			return err
+		}
+
+		ids := make([]int, len(expired))
+		for i, msg := range expired {
+			ids[i] = msg.Id
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM messages WHERE id = ANY($1)", pq.Array(ids))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
+
+// InsertMessage сохраняет сообщение в переданной транзакции и выдаёт ему следующий
+// порядковый номер в чате. Строка чата блокируется до конца транзакции, поэтому
+// номера идут без пропусков в порядке фиксации.
+func InsertMessage(ctx context.Context, tx *sqlx.Tx, msg NewMessage) (*pkg.Message, error) {
+	var seq int64
+	err := tx.GetContext(ctx, &seq, "UPDATE chats SET last_seq = last_seq + 1 WHERE id = $1 RETURNING last_seq", msg.ChatId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrChatNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var message pkg.Message
+	err = tx.GetContext(ctx, &message,
+		`WITH m AS (
+             INSERT INTO messages (chat_id, seq, sender_id, created_at, content, expires_at) VALUES ($1, $2, $3, $4, $5, $6)
+             RETURNING id, chat_id, seq, sender_id, content, created_at, expires_at
+         )
+         SELECT m.id, m.chat_id, m.seq, m.sender_id, u.name AS sender, m.content, m.created_at, m.expires_at
+         FROM m JOIN users u ON u.id = m.sender_id`,
+		msg.ChatId, seq, msg.SenderId, msg.CreatedAt, msg.Content, msg.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// linkAttachments привязывает загруженные пользователем вложения к сообщению.
+// Если хотя бы одно вложение из чужого чата, чужого пользователя или уже отправлено,
+// возвращается ErrInvalidAttachments и сообщение не сохраняется.
+func linkAttachments(ctx context.Context, tx *sqlx.Tx, messageId, chatId, userId int, ids []int) ([]pkg.Attachment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+
+	var attachments []pkg.Attachment
+	err := tx.SelectContext(ctx, &attachments,
+		`UPDATE attachments SET message_id = $1
+         WHERE id = ANY($2) AND chat_id = $3 AND uploader_id = $4 AND message_id IS NULL
+         RETURNING *`,
+		messageId, pq.Array(ids), chatId, userId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) != len(ids) {
+		return nil, ErrInvalidAttachments
+	}
+	for i := range attachments {
+		attachments[i].SetURLs()
+	}
+	return attachments, nil
+}
+
+// loadAttachments загружает вложения сообщений, сгруппированные по id сообщения
+func loadAttachments(ctx context.Context, db sqlx.QueryerContext, messageIds []int) (map[int][]pkg.Attachment, error) {
+	result := make(map[int][]pkg.Attachment)
+	if len(messageIds) == 0 {
+		return result, nil
+	}
+
+	var attachments []pkg.Attachment
+	err := sqlx.SelectContext(ctx, db, &attachments,
+		`SELECT * FROM attachments WHERE message_id = ANY($1) ORDER BY id ASC`,
+		pq.Array(messageIds),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range attachments {
+		a.SetURLs()
+		result[*a.MessageId] = append(result[*a.MessageId], a)
+	}
+	return result, nil
+}
+
+// withAttachments заполняет вложения у списка сообщений
+func withAttachments(ctx context.Context, db sqlx.QueryerContext, messages []pkg.Message) error {
+	ids := make([]int, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.Id
+	}
+	attachments, err := loadAttachments(ctx, db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range messages {
+		messages[i].Attachments = attachments[messages[i].Id]
+	}
+	return nil
+}
+
+// flagMessage отправляет сработавшие флаги фильтров в очередь модерации
+func flagMessage(ctx context.Context, tx *sqlx.Tx, chatId, messageId int, flags []string) error {
+	for _, reason := range flags {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO message_reports (chat_id, message_id, reason, source)
+             VALUES ($1, $2, $3, 'filter')`,
+			chatId, messageId, reason,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func withTx(ctx context.Context, db *sqlx.DB, fn func(*sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
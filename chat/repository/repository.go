@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+)
+
+var (
+	ErrChatNotFound       = errors.New("chat not found")
+	ErrNotMember          = errors.New("you are not a member of this chat")
+	ErrAlreadyMember      = errors.New("user is already a member of this chat")
+	ErrInvalidChat        = errors.New("chat name is required")
+	ErrEmptyMessage       = errors.New("message is empty")
+	ErrInvalidExpiry      = errors.New("expires_at must be after the time the message is sent")
+	ErrNotScheduled       = errors.New("scheduled message not found")
+	ErrInvalidAttachments = errors.New("attachments not found or already sent")
+)
+
+type ChatRepository interface {
+	// Create создаёт чат и добавляет создателя владельцем в одной транзакции
+	Create(ctx context.Context, chat *pkg.Chat) error
+	GetById(ctx context.Context, id int) (*pkg.Chat, error)
+	ListByMember(ctx context.Context, userId int) ([]pkg.Chat, error)
+	AddMember(ctx context.Context, chatId, userId int, role string) error
+	IsMember(ctx context.Context, chatId, userId int) (bool, error)
+}
+
+// NewMessage — сообщение, которое сохраняется вместе с вложениями и флагами фильтров
+type NewMessage struct {
+	ChatId        int
+	SenderId      int
+	Content       string
+	CreatedAt     time.Time
+	AttachmentIds []int
+	// Flags — причины, по которым сообщение попадает в очередь модерации
+	Flags []string
+	// ExpiresAt — когда сообщение удаляется из чата, nil — хранится всегда
+	ExpiresAt *time.Time
+}
+
+type MessageRepository interface {
+	// Create сохраняет сообщение, привязывает вложения и записывает флаги в одной транзакции
+	Create(ctx context.Context, msg NewMessage) (*pkg.Message, error)
+	// History возвращает сообщения чата с seq больше afterSeq в порядке отправки вместе с вложениями
+	History(ctx context.Context, chatId int, afterSeq int64) ([]pkg.Message, error)
+
+	// Schedule откладывает публикацию сообщения до sendAt
+	Schedule(ctx context.Context, msg NewMessage, sendAt time.Time) (*pkg.ScheduledMessage, error)
+	Scheduled(ctx context.Context, chatId int) ([]pkg.ScheduledMessage, error)
+	CancelScheduled(ctx context.Context, chatId, id int) error
+	// PublishDue переносит в чат отложенные сообщения, время которых наступило
+	PublishDue(ctx context.Context, now time.Time, limit int) ([]pkg.Message, error)
+	// PurgeExpired удаляет истёкшие сообщения и возвращает их вместе с вложениями,
+	// чтобы вызывающий удалил файлы и оповестил чат
+	PurgeExpired(ctx context.Context, now time.Time, limit int) ([]pkg.Message, error)
+}
+
+func checkExpiry(msg NewMessage) error {
+	if msg.ExpiresAt != nil && !msg.ExpiresAt.After(msg.CreatedAt) {
+		return ErrInvalidExpiry
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/XRS0/ToTalkB/chat/filter"
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/XRS0/ToTalkB/chat/repository"
+	"github.com/XRS0/ToTalkB/chat/storage"
+	"github.com/XRS0/ToTalkB/wsqueue"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	scheduleInterval  = 5 * time.Second
+	scheduleBatchSize = 100
+)
+
+var errScheduledAttachments = errors.New("scheduled messages cannot have attachments")
+
+// ScheduledFrame подтверждает автору, что сообщение отложено
+type ScheduledFrame struct {
+	Type    string               `json:"type"`
+	Message pkg.ScheduledMessage `json:"message"`
+}
+
+// DeletedMessage — фрейм об удалении сообщения из чата, например по истечении срока
+type DeletedMessage struct {
+	Type      string `json:"type"`
+	MessageId int    `json:"message_id"`
+	Seq       int64  `json:"seq"`
+}
+
+// MessageScheduler публикует отложенные сообщения и удаляет истёкшие,
+// оповещая об этом комнаты чатов
+type MessageScheduler struct {
+	messages repository.MessageRepository
+	hub      *Hub
+	store    storage.BlobStore
+	notifier *OfflineNotifier
+	interval time.Duration
+	now      func() time.Time
+}
+
+func NewMessageScheduler(messages repository.MessageRepository, hub *Hub, store storage.BlobStore, notifier *OfflineNotifier) *MessageScheduler {
+	return &MessageScheduler{
+		messages: messages,
+		hub:      hub,
+		store:    store,
+		notifier: notifier,
+		interval: scheduleInterval,
+		now:      time.Now,
+	}
+}
+
+// Run обрабатывает очередь раз в interval до отмены ctx
+func (s *MessageScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.publishDue(ctx)
+			s.purgeExpired(ctx)
+		}
+	}
+}
+
+func (s *MessageScheduler) publishDue(ctx context.Context) {
+	published, err := s.messages.PublishDue(ctx, s.now(), scheduleBatchSize)
+	if err != nil {
+		log.Printf("Failed to publish scheduled messages: %v", err)
+		return
+	}
+	for _, msg := range published {
+		jsonMsg, err := json.Marshal(OutgoingMessage{
+			Id:        msg.Id,
+			Seq:       msg.Seq,
+			ExpiresAt: msg.ExpiresAt,
+			Content:   msg.Content,
+			Sender:    msg.Sender,
+			Time:      msg.CreatedAt.Format("15:04"),
+		})
+		if err != nil {
+			log.Printf("Failed to marshal json: %v", err)
+			continue
+		}
+		s.hub.Broadcast(strconv.Itoa(msg.ChatId), jsonMsg)
+		s.notifier.MessagePosted(msg.ChatId, msg.SenderId, msg.Content)
+	}
+}
+
+func (s *MessageScheduler) purgeExpired(ctx context.Context) {
+	expired, err := s.messages.PurgeExpired(ctx, s.now(), scheduleBatchSize)
+	if err != nil {
+		log.Printf("Failed to purge expired messages: %v", err)
+		return
+	}
+	for _, msg := range expired {
+		for _, a := range msg.Attachments {
+			s.deleteBlob(ctx, a.StorageKey)
+			if a.ThumbnailKey != nil {
+				s.deleteBlob(ctx, *a.ThumbnailKey)
+			}
+		}
+
+		jsonMsg, err := json.Marshal(DeletedMessage{Type: frameDeleted, MessageId: msg.Id, Seq: msg.Seq})
+		if err != nil {
+			log.Printf("Failed to marshal json: %v", err)
+			continue
+		}
+		s.hub.Publish(strconv.Itoa(msg.ChatId), wsqueue.Message{Kind: frameDeleted, Data: jsonMsg})
+	}
+}
+
+func (s *MessageScheduler) deleteBlob(ctx context.Context, key string) {
+	if err := s.store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Failed to delete attachment %s: %v", key, err)
+	}
+}
+
+type scheduledInput struct {
+	Content   string     `json:"content" binding:"required"`
+	SendAt    time.Time  `json:"send_at" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type ScheduledHandler struct {
+	db       *sqlx.DB
+	messages repository.MessageRepository
+	filters  *filter.Chain
+}
+
+func NewScheduledHandler(db *sqlx.DB, messages repository.MessageRepository, filters *filter.Chain) *ScheduledHandler {
+	return &ScheduledHandler{db: db, messages: messages, filters: filters}
+}
+
+// List возвращает сообщения чата, ожидающие публикации
+func (h *ScheduledHandler) List(c *gin.Context) {
+	chatId, _, ok := h.adminParams(c)
+	if !ok {
+		return
+	}
+
+	scheduled, err := h.messages.Scheduled(c.Request.Context(), chatId)
+	if err != nil {
+		repositoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, scheduled)
+}
+
+// Create откладывает объявление до send_at, expires_at — когда оно удалится из чата.
+// Текст проходит те же фильтры, что и сообщения из сокета
+func (h *ScheduledHandler) Create(c *gin.Context) {
+	chatId, userId, ok := h.adminParams(c)
+	if !ok {
+		return
+	}
+	var input scheduledInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	content := strings.TrimSpace(input.Content)
+	if content == "" || utf8.RuneCountInString(content) > maxAnnouncementLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message length"})
+		return
+	}
+
+	result, err := h.filters.Run(c.Request.Context(), filter.Message{ChatId: chatId, UserId: userId, Content: content})
+	var rejected *filter.RejectedError
+	if errors.As(err, &rejected) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to filter scheduled message: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	scheduled, err := h.messages.Schedule(c.Request.Context(), repository.NewMessage{
+		ChatId:    chatId,
+		SenderId:  userId,
+		Content:   result.Content,
+		ExpiresAt: input.ExpiresAt,
+		Flags:     flagReasons(result.Flags),
+	}, input.SendAt)
+	if err != nil {
+		repositoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, scheduled)
+}
+
+// Cancel отменяет отложенное сообщение, пока оно не опубликовано
+func (h *ScheduledHandler) Cancel(c *gin.Context) {
+	chatId, _, ok := h.adminParams(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("scheduledId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scheduled message id"})
+		return
+	}
+
+	if err := h.messages.CancelScheduled(c.Request.Context(), chatId, id); err != nil {
+		repositoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+func (h *ScheduledHandler) adminParams(c *gin.Context) (chatId, userId int, ok bool) {
+	chatId, userId, ok = memberParams(c)
+	if !ok {
+		return 0, 0, false
+	}
+	if err := requireAdmin(h.db, chatId, userId); err != nil {
+		moderationError(c, err)
+		return 0, 0, false
+	}
+	return chatId, userId, true
+}
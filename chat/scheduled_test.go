@@ -0,0 +1,181 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/XRS0/ToTalkB/chat/filter"
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/XRS0/ToTalkB/chat/repository"
+	"github.com/XRS0/ToTalkB/wsqueue"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultFilterSettings — во всех чатах действуют действия фильтров по умолчанию
+type defaultFilterSettings struct{}
+
+func (defaultFilterSettings) Actions(ctx context.Context, chatId int) (map[string]filter.Action, error) {
+	return nil, nil
+}
+
+// scheduledChat создаёт чат 1 владельца ann в памяти
+func scheduledChat(t *testing.T) *repository.MessageMemory {
+	t.Helper()
+	chats := repository.NewChatMemory()
+	if err := chats.Create(context.Background(), &pkg.Chat{Name: "Команда", OwnerId: 1}); err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+	messages := repository.NewMessageMemory(chats)
+	messages.SetUserName(1, "ann")
+	return messages
+}
+
+func TestMessageScheduler(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := start.Add(d)
+		return &t
+	}
+	messages := scheduledChat(t)
+	schedule := func(content string, sendAt time.Duration, expiresAt *time.Time) {
+		t.Helper()
+		_, err := messages.Schedule(ctx, repository.NewMessage{ChatId: 1, SenderId: 1, Content: content, ExpiresAt: expiresAt}, start.Add(sendAt))
+		if err != nil {
+			t.Fatalf("schedule %q: %v", content, err)
+		}
+	}
+	schedule("доброе утро", time.Minute, nil)
+	schedule("перерыв до 13:00", 2*time.Minute, at(3*time.Minute))
+	// истекло раньше, чем дошла очередь публикации
+	schedule("начинаем через минуту", time.Minute, at(90*time.Second))
+
+	hub := NewHub()
+	go hub.Run()
+	member := &Client{hub: hub, chatId: "1", userId: "2", queue: wsqueue.New(sendQueueSize, sendPolicies, nil)}
+	hub.register <- member
+	notifier := NewOfflineNotifier(nil, nil, nil)
+	now := start
+	s := NewMessageScheduler(messages, hub, nil, notifier)
+	s.now = func() time.Time { return now }
+
+	frames := func() []wsqueue.Message {
+		hub.Online("1")
+		return member.queue.Drain()
+	}
+
+	now = start.Add(30 * time.Second)
+	s.publishDue(ctx)
+	if got := frames(); len(got) != 0 {
+		t.Fatalf("expected nothing to be published yet, got %d frames", len(got))
+	}
+
+	now = start.Add(2 * time.Minute)
+	s.publishDue(ctx)
+	var published []OutgoingMessage
+	for _, frame := range frames() {
+		var msg OutgoingMessage
+		if err := json.Unmarshal(frame.Data, &msg); err != nil || frame.Kind != frameMessage {
+			t.Fatalf("unexpected frame %s %s", frame.Kind, frame.Data)
+		}
+		published = append(published, msg)
+	}
+	if len(published) != 2 || published[0].Content != "доброе утро" || published[1].Content != "перерыв до 13:00" ||
+		published[0].Sender != "ann" || published[1].Seq != 2 || published[1].ExpiresAt == nil {
+		t.Fatalf("unexpected published messages %+v", published)
+	}
+	if len(notifier.posts) != 2 {
+		t.Fatalf("expected offline members to be notified twice, got %d", len(notifier.posts))
+	}
+	if scheduled, _ := messages.Scheduled(ctx, 1); len(scheduled) != 0 {
+		t.Fatalf("expected scheduled queue to be empty, got %+v", scheduled)
+	}
+
+	now = start.Add(3*time.Minute - time.Second)
+	s.purgeExpired(ctx)
+	if got := frames(); len(got) != 0 {
+		t.Fatalf("expected nothing to expire yet, got %d frames", len(got))
+	}
+
+	now = start.Add(3 * time.Minute)
+	s.purgeExpired(ctx)
+	got := frames()
+	var deleted DeletedMessage
+	if len(got) != 1 || got[0].Kind != frameDeleted || json.Unmarshal(got[0].Data, &deleted) != nil {
+		t.Fatalf("expected one deleted frame, got %v", got)
+	}
+	if deleted.MessageId != published[1].Id || deleted.Seq != published[1].Seq {
+		t.Fatalf("expected %d to be deleted, got %+v", published[1].Id, deleted)
+	}
+	if history, _ := messages.History(ctx, 1, 0); len(history) != 1 || history[0].Id != published[0].Id {
+		t.Fatalf("expected only the message without expiry to stay, got %+v", history)
+	}
+}
+
+func TestScheduledHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	messages := scheduledChat(t)
+	filters := filter.NewChain(defaultFilterSettings{},
+		filter.NewLengthFilter(30),
+		filter.NewBlocklistFilter([]string{"спам"}),
+		filter.NewLinkFilter(),
+	)
+	handler := NewScheduledHandler(newFakeDB(t, newModerationStore().query), messages, filters)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userId", c.GetHeader("X-User"))
+	})
+	router.POST("/chat/:chatId/scheduled", handler.Create)
+
+	sendAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	tests := []struct {
+		name    string
+		user    string
+		content string
+		want    int
+		stored  string
+	}{
+		{"member cannot schedule", "3", "доброе утро", http.StatusForbidden, ""},
+		{"rejected by a filter", "2", strings.Repeat("я", 31), http.StatusUnprocessableEntity, ""},
+		{"masked by a filter", "2", "спам на evil.io", http.StatusCreated, "**** на evil.io"},
+		{"clean message", "2", " доброе утро ", http.StatusCreated, "доброе утро"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(gin.H{"content": tt.content, "send_at": sendAt})
+			req := httptest.NewRequest(http.MethodPost, "/chat/1/scheduled", strings.NewReader(string(body)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-User", tt.user)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d %s", tt.want, w.Code, w.Body)
+			}
+			if tt.stored == "" {
+				return
+			}
+			var scheduled pkg.ScheduledMessage
+			if err := json.Unmarshal(w.Body.Bytes(), &scheduled); err != nil || scheduled.Content != tt.stored {
+				t.Fatalf("expected %q to be scheduled, got %s", tt.stored, w.Body)
+			}
+		})
+	}
+
+	// флаги фильтров сохраняются и попадают к сообщению при публикации
+	published, err := messages.PublishDue(context.Background(), time.Now().Add(2*time.Hour), 10)
+	if err != nil || len(published) != 2 {
+		t.Fatalf("expected two messages to be published, got %v, %v", published, err)
+	}
+	if flags := messages.Flags(published[0].Id); len(flags) != 1 || !strings.HasPrefix(flags[0], "links: ") {
+		t.Fatalf("expected links flag, got %v", flags)
+	}
+	if flags := messages.Flags(published[1].Id); len(flags) != 0 {
+		t.Fatalf("expected clean message without flags, got %v", flags)
+	}
+}
@@ -0,0 +1,143 @@
+package chat
+
+import (
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/XRS0/ToTalkB/chat/pkg"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchQueryLen  = 256
+
+	// Служебные маркеры ts_headline. Из текста сообщения они вырезаются до подсветки,
+	// поэтому после экранирования их можно безопасно заменить на <mark>
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+type SearchHandler struct {
+	db *sqlx.DB
+}
+
+func NewSearchHandler(db *sqlx.DB) *SearchHandler {
+	return &SearchHandler{db: db}
+}
+
+// Search ищет сообщения по тексту во всех чатах, где состоит пользователь.
+// Параметры: q — поисковый запрос (синтаксис websearch), chat_id — ограничить одним чатом,
+// limit и offset — пагинация.
+func (h *SearchHandler) Search(c *gin.Context) {
+	userId, err := strconv.Atoi(c.GetString("userId"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		return
+	}
+	if len(q) > maxSearchQueryLen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is too long"})
+		return
+	}
+
+	limit, err := queryInt(c, "limit", defaultSearchLimit)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+	limit = min(limit, maxSearchLimit)
+	offset, err := queryInt(c, "offset", 0)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+	var chatId *int
+	if raw := c.Query("chat_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat id"})
+			return
+		}
+		chatId = &id
+	}
+
+	page, err := searchMessages(h.db, userId, q, chatId, limit, offset)
+	if err != nil {
+		log.Printf("Failed to search messages: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// searchScope — сообщения по запросу $1 в чатах пользователя $2, $3 — необязательный фильтр по чату
+const searchScope = `
+        FROM messages m
+        CROSS JOIN (SELECT websearch_to_tsquery('russian', $1) AS query) q
+        JOIN chat_members cm ON cm.chat_id = m.chat_id AND cm.user_id = $2
+        JOIN chats ch ON ch.id = m.chat_id
+        JOIN users u ON u.id = m.sender_id
+        WHERE m.search_vector @@ q.query
+          AND ($3::integer IS NULL OR m.chat_id = $3)
+          AND (m.expires_at IS NULL OR m.expires_at > now())`
+
+func searchMessages(db *sqlx.DB, userId int, q string, chatId *int, limit, offset int) (*pkg.SearchPage, error) {
+	page := &pkg.SearchPage{
+		Results: []pkg.SearchResult{},
+		Limit:   limit,
+		Offset:  offset,
+	}
+	err := db.Select(&page.Results, `
+        SELECT m.id, m.chat_id, ch.name AS chat_name, u.name AS sender, m.content, m.created_at,
+               ts_rank(m.search_vector, q.query) AS rank,
+               ts_headline('russian', translate(m.content, $4 || $5, ''), q.query,
+                   'StartSel=' || $4 || ', StopSel=' || $5 || ', MaxFragments=2, MaxWords=20, MinWords=5') AS highlight`+
+		searchScope+`
+        ORDER BY rank DESC, m.created_at DESC
+        LIMIT $6 OFFSET $7`,
+		q, userId, chatId, highlightStart, highlightStop, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i := range page.Results {
+		page.Results[i].Highlight = renderHighlight(page.Results[i].Highlight)
+	}
+
+	// неполная непустая страница — последняя, и считать совпадения отдельно не нужно
+	if n := len(page.Results); n > 0 && n < limit {
+		page.Total = offset + n
+		return page, nil
+	}
+	if err := db.Get(&page.Total, "SELECT COUNT(*)"+searchScope, q, userId, chatId); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// renderHighlight экранирует текст сообщения и оборачивает совпадения в <mark>
+func renderHighlight(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, highlightStart, "<mark>")
+	return strings.ReplaceAll(s, highlightStop, "</mark>")
+}
+
+func queryInt(c *gin.Context, key string, def int) (int, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return def, nil
+	}
+	return strconv.Atoi(raw)
+}
@@ -0,0 +1,85 @@
+package chat
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderHighlight(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "привет всем", "привет всем"},
+		{"match", "встреча \x02завтра\x03 в 10", "встреча <mark>завтра</mark> в 10"},
+		{"several matches", "\x02a\x03 и \x02b\x03", "<mark>a</mark> и <mark>b</mark>"},
+		{"markup is escaped", "<b>\x02жирный\x03</b> & <script>", "&lt;b&gt;<mark>жирный</mark>&lt;/b&gt; &amp; &lt;script&gt;"},
+		{"quotes are escaped", "\"\x02a\x03'", "&#34;<mark>a</mark>&#39;"},
+		{"match inside markup stays escaped", "<\x02img\x03 src=x onerror=alert(1)>", "&lt;<mark>img</mark> src=x onerror=alert(1)&gt;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderHighlight(tt.in); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSearchMessages(t *testing.T) {
+	columns := []string{"id", "chat_id", "chat_name", "sender", "content", "created_at", "rank", "highlight"}
+	tests := []struct {
+		name    string
+		found   int
+		limit   int
+		offset  int
+		total   int
+		counted bool
+	}{
+		{"nothing found", 0, 20, 0, 0, true},
+		{"single page", 3, 20, 0, 3, false},
+		{"last page", 3, 5, 10, 13, false},
+		{"full page", 5, 5, 0, 42, true},
+		{"page past the end", 0, 5, 50, 42, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counted := false
+			db := newFakeDB(t, func(query string, args []driver.Value) (*fakeRows, error) {
+				if strings.HasPrefix(query, "SELECT COUNT(*)") {
+					counted = true
+					return fakeRow([]string{"count"}, int64(tt.total)), nil
+				}
+				// маркеры подсветки вырезаются из текста до ts_headline
+				if !strings.Contains(query, "translate(m.content, $4 || $5, '')") || args[3] != highlightStart || args[4] != highlightStop {
+					t.Fatalf("expected markers to be stripped from content, got %q %v", query, args)
+				}
+				rows := noRows(columns...)
+				for i := 0; i < tt.found; i++ {
+					rows.values = append(rows.values, []driver.Value{int64(i + 1), int64(1), "chat", "ann", "<b>", time.Now(), 0.5, "\x02<b>\x03"})
+				}
+				return rows, nil
+			})
+
+			page, err := searchMessages(db, 1, "b", nil, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if len(page.Results) != tt.found || page.Total != tt.total || counted != tt.counted {
+				t.Fatalf("expected %d results of %d (counted %v), got %d of %d (counted %v)",
+					tt.found, tt.total, tt.counted, len(page.Results), page.Total, counted)
+			}
+			if page.Results == nil {
+				t.Fatal("expected empty results to encode as an array")
+			}
+			for _, result := range page.Results {
+				if result.Highlight != "<mark>&lt;b&gt;</mark>" {
+					t.Fatalf("unexpected highlight %q", result.Highlight)
+				}
+			}
+		})
+	}
+}
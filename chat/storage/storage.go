@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore хранит бинарное содержимое вложений по ключу
+type BlobStore interface {
+	// Put сохраняет содержимое r под ключом key
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get открывает содержимое по ключу, вызывающий обязан закрыть reader
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет содержимое по ключу
+	Delete(ctx context.Context, key string) error
+}
@@ -1,6 +1,7 @@
 package codes
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/yeqown/go-qrcode/v2"
@@ -24,3 +25,27 @@ func Generate(contains string, path string) {
 		fmt.Printf("could not save image: %v", err)
 	}
 }
+
+// GeneratePNG рисует QR-код с текстом contains в памяти и возвращает его в формате PNG
+func GeneratePNG(contains string) ([]byte, error) {
+	qrc, err := qrcode.New(contains)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate QRCode: %w", err)
+	}
+
+	var buf bytes.Buffer
+	w := standard.NewWithWriter(nopCloser{&buf}, standard.WithBuiltinImageEncoder(standard.PNG_FORMAT))
+	if err := qrc.Save(w); err != nil {
+		return nil, fmt.Errorf("could not save image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// nopCloser даёт буферу Close, которого ждёт standard.Writer
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -8,6 +8,7 @@ type Config struct {
 	Server              Server              `mapstructure:"server"`
 	Database            Database            `mapstructure:"database"`
 	NotificationService NotificationService `mapstructure:"notification_service"`
+	CheckIn             CheckIn             `mapstructure:"check_in"`
 }
 
 type Server struct {
@@ -29,6 +30,12 @@ type NotificationService struct {
 	GRPCPort int    `mapstructure:"grpc_port"`
 }
 
+// CheckIn — ключ, которым подписываются токены отметки о прибытии.
+// Ключ не хранится в config.yaml и берётся из переменной окружения CHECK_IN_SECRET
+type CheckIn struct {
+	Secret string `mapstructure:"secret"`
+}
+
 func Load() (*Config, error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -37,6 +44,9 @@ func Load() (*Config, error) {
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
 	}
+	if err := viper.BindEnv("check_in.secret", "CHECK_IN_SECRET"); err != nil {
+		return nil, err
+	}
 
 	var config Config
 	if err := viper.Unmarshal(&config); err != nil {
@@ -7,24 +7,46 @@ import (
 )
 
 type Event struct {
-	ID        string    `json:"id"`
-	Type      string    `json:"type"`
-	Source    string    `json:"source"`
-	Payload   []byte    `json:"payload"`
-	Status    string    `json:"status"`
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Source  string `json:"source"`
+	Payload []byte `json:"payload"`
+	Status  string `json:"status"`
+
+	// Описание мероприятия, на которое записываются в очередь
+	Title         string        `json:"title"`
+	Description   string        `json:"description"`
+	Venue         string        `json:"venue"`
+	StartsAt      time.Time     `json:"starts_at"`
+	EndsAt        time.Time     `json:"ends_at"`
+	Timezone      string        `json:"timezone"`
+	Capacity      int           `json:"capacity"`
+	OrganizerID   string        `json:"organizer_id"`
+	Visibility    Visibility    `json:"visibility"`
+	State         EventState    `json:"state"`
+	QueueState    QueueState    `json:"queue_state"`
+	QueueSettings QueueSettings `json:"queue_settings"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
 func NewEvent(eventType string, source string, payload []byte) *Event {
+	now := time.Now()
 	return &Event{
-		ID:        uuid.New().String(),
-		Type:      eventType,
-		Source:    source,
-		Payload:   payload,
-		Status:    "pending",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		Source:     source,
+		Payload:    payload,
+		Status:     "pending",
+		StartsAt:   now,
+		EndsAt:     now,
+		Timezone:   "UTC",
+		Visibility: VisibilityPublic,
+		State:      StateDraft,
+		QueueState: QueueStateOpen,
+		CreatedAt:  now,
+		UpdatedAt:  now,
 	}
 }
 
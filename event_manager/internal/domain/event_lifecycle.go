@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeScheduled — тип записи events для мероприятий, созданных организатором
+const EventTypeScheduled = "scheduled"
+
+var (
+	ErrEventNotFound     = errors.New("event not found")
+	ErrInvalidEvent      = errors.New("invalid event")
+	ErrInvalidTransition = errors.New("invalid event state transition")
+	ErrEventClosed       = errors.New("event is finished or cancelled")
+	ErrNotOrganizer      = errors.New("only the organizer can manage the event")
+	ErrEventFull         = errors.New("no places left at the event")
+)
+
+// EventState — этап жизненного цикла мероприятия
+type EventState string
+
+const (
+	StateDraft     EventState = "draft"
+	StatePublished EventState = "published"
+	StateOngoing   EventState = "ongoing"
+	StateFinished  EventState = "finished"
+	StateCancelled EventState = "cancelled"
+)
+
+// Visibility определяет, кому мероприятие видно в списке
+type Visibility string
+
+const (
+	VisibilityPublic  Visibility = "public"
+	VisibilityPrivate Visibility = "private"
+)
+
+// eventTransitions — разрешённые переходы между состояниями мероприятия
+var eventTransitions = map[EventState][]EventState{
+	StateDraft:     {StatePublished, StateCancelled},
+	StatePublished: {StateOngoing, StateCancelled},
+	StateOngoing:   {StateFinished, StateCancelled},
+}
+
+// CanTransition сообщает, можно ли перевести мероприятие из from в to
+func CanTransition(from, to EventState) bool {
+	for _, next := range eventTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition возвращает ErrInvalidTransition, если из from в to перейти нельзя
+func CheckTransition(from, to EventState) error {
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// Closed сообщает, что мероприятие завершено или отменено и больше не меняется
+func (s EventState) Closed() bool {
+	return s == StateFinished || s == StateCancelled
+}
+
+// QueueAfter возвращает состояние очереди после перевода мероприятия в состояние to:
+// завершение и отмена закрывают набор в открытую или приостановленную очередь
+func QueueAfter(queue QueueState, to EventState) QueueState {
+	if !to.Closed() {
+		return queue
+	}
+	if changed, err := QueueClose.Check(queue); err != nil || !changed {
+		return queue
+	}
+	return QueueClose.To
+}
+
+// EventDetails — редактируемые организатором поля мероприятия
+type EventDetails struct {
+	Title       string
+	Description string
+	Venue       string
+	StartsAt    time.Time
+	EndsAt      time.Time
+	Timezone    string
+	// Capacity — число мест, 0 — без ограничения
+	Capacity   int
+	Visibility Visibility
+}
+
+// NewScheduledEvent создаёт черновик мероприятия от имени организатора
+func NewScheduledEvent(organizerID string, details EventDetails) (*Event, error) {
+	now := time.Now()
+	event := &Event{
+		ID:          uuid.New().String(),
+		Type:        EventTypeScheduled,
+		Source:      organizerID,
+		Payload:     []byte{},
+		Status:      string(StatusProcessed),
+		OrganizerID: organizerID,
+		State:       StateDraft,
+		QueueState:  QueueStateOpen,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if strings.TrimSpace(organizerID) == "" {
+		return nil, fmt.Errorf("%w: organizer is required", ErrInvalidEvent)
+	}
+	if err := event.Apply(details); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// Apply проверяет и применяет новые данные мероприятия
+func (e *Event) Apply(details EventDetails) error {
+	if e.State.Closed() {
+		return ErrEventClosed
+	}
+	if err := details.Validate(); err != nil {
+		return err
+	}
+	e.Title = strings.TrimSpace(details.Title)
+	e.Description = details.Description
+	e.Venue = strings.TrimSpace(details.Venue)
+	// время хранится в UTC, часовой пояс площадки — отдельно в Timezone
+	e.StartsAt = details.StartsAt.UTC()
+	e.EndsAt = details.EndsAt.UTC()
+	e.Timezone = details.Timezone
+	e.Capacity = details.Capacity
+	e.Visibility = details.Visibility
+	e.UpdatedAt = time.Now()
+	return nil
+}
+
+// VisibleTo сообщает, может ли пользователь видеть мероприятие
+func (e *Event) VisibleTo(userID string) bool {
+	if userID != "" && e.OrganizerID == userID {
+		return true
+	}
+	return e.Visibility == VisibilityPublic && e.State != StateDraft
+}
+
+func (d *EventDetails) Validate() error {
+	if d.Timezone == "" {
+		d.Timezone = "UTC"
+	}
+	if d.Visibility == "" {
+		d.Visibility = VisibilityPublic
+	}
+
+	switch {
+	case strings.TrimSpace(d.Title) == "":
+		return fmt.Errorf("%w: title is required", ErrInvalidEvent)
+	case d.StartsAt.IsZero() || d.EndsAt.IsZero():
+		return fmt.Errorf("%w: start and end times are required", ErrInvalidEvent)
+	case !d.EndsAt.After(d.StartsAt):
+		return fmt.Errorf("%w: event must end after it starts", ErrInvalidEvent)
+	case d.Capacity < 0:
+		return fmt.Errorf("%w: capacity must not be negative", ErrInvalidEvent)
+	case d.Visibility != VisibilityPublic && d.Visibility != VisibilityPrivate:
+		return fmt.Errorf("%w: unknown visibility %q", ErrInvalidEvent, d.Visibility)
+	}
+	if _, err := time.LoadLocation(d.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidEvent, d.Timezone)
+	}
+	return nil
+}
+
+// AcceptsQueue сообщает, можно ли встать в очередь мероприятия. Для мероприятий организатора
+// очередь открыта только после публикации и до завершения
+func (e *Event) AcceptsQueue() error {
+	if err := e.QueueState.AcceptsJoins(); err != nil {
+		return err
+	}
+	if e.State.Closed() {
+		return ErrQueueClosed
+	}
+	if e.Type == EventTypeScheduled && e.State == StateDraft {
+		return ErrQueueClosed
+	}
+	return nil
+}
+
+// AcceptsCalls сообщает, можно ли вызывать пользователей: после завершения или отмены
+// мероприятия никого не вызывают, даже если в очереди ещё стоят
+func (e *Event) AcceptsCalls() error {
+	if e.State.Closed() {
+		return ErrEventClosed
+	}
+	return e.QueueState.AcceptsCalls()
+}
@@ -2,66 +2,230 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"time"
 )
 
+var (
+	ErrAlreadyInQueue = errors.New("user is already in the queue")
+	ErrNotInQueue     = errors.New("user is not in the queue")
+	ErrQueueEmpty     = errors.New("no users in queue")
+	ErrQueueClosed    = errors.New("queue is closed for new entries")
+	ErrQueueFull      = errors.New("queue is full")
+	ErrJoinLimit      = errors.New("user has reached the join limit for this queue")
+	ErrNotActive      = errors.New("user is not being served")
+	ErrNotYourTurn    = errors.New("it is not the user's turn yet")
+	ErrInvalidCheckIn = errors.New("invalid check-in token")
+	ErrCounterBusy    = errors.New("counter is already serving a user")
+	ErrNotWaiting     = errors.New("user is no longer waiting to be called")
+)
+
 // EventQueue представляет запись в очереди событий
 type EventQueue struct {
-	ID        string    `json:"id"`
-	EventID   string    `json:"event_id"`
-	UserID    string    `json:"user_id"`
-	Status    string    `json:"status"`
-	Position  int       `json:"position"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        string     `json:"id"`
+	EventID   string     `json:"event_id"`
+	UserID    string     `json:"user_id"`
+	Status    string     `json:"status"`
+	Position  int        `json:"position"`
+	Ticket    int64      `json:"ticket"`
+	Lane      string     `json:"lane,omitempty"`
+	Counter   string     `json:"counter,omitempty"`    // стойка, к которой вызван пользователь
+	CalledAt  *time.Time `json:"called_at,omitempty"`  // когда пользователя вызвали
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // до какого момента вызванный должен подойти
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	// EstimatedWait — оценка ожидания до вызова; репозиторий её не заполняет, её считает сервис
+	EstimatedWait time.Duration `json:"estimated_wait,omitempty"`
 }
 
 // EventQueueStatus представляет возможные статусы записи в очереди
 type EventQueueStatus string
 
 const (
-	QueueStatusWaiting   EventQueueStatus = "waiting"
-	QueueStatusActive    EventQueueStatus = "active"
-	QueueStatusCompleted EventQueueStatus = "completed"
-	QueueStatusCancelled EventQueueStatus = "cancelled"
+	QueueStatusWaiting EventQueueStatus = "waiting"
+	// QueueStatusWaitlisted — живая очередь заполнена, пользователь ждёт освободившегося места
+	QueueStatusWaitlisted EventQueueStatus = "waitlisted"
+	QueueStatusActive     EventQueueStatus = "active"
+	QueueStatusCompleted  EventQueueStatus = "completed"
+	QueueStatusCancelled  EventQueueStatus = "cancelled"
+	// QueueStatusNoShow — вызванный пользователь не подошёл вовремя или оператор его пропустил
+	QueueStatusNoShow EventQueueStatus = "no_show"
 )
 
-// EventQueueRepository определяет методы для работы с очередью событий
+// QueueSettings — ограничения очереди мероприятия, 0 означает «без ограничения»
+type QueueSettings struct {
+	// MaxActive — сколько человек одновременно стоит в живой очереди, остальные попадают в лист ожидания
+	MaxActive int `json:"max_active"`
+	// MaxTotal — сколько человек всего ждёт в живой очереди и в листе ожидания вместе
+	MaxTotal int `json:"max_total"`
+	// MaxPerUser — сколько раз один пользователь может встать в очередь мероприятия
+	MaxPerUser int `json:"max_per_user"`
+	// TurnTimeout — сколько вызванный пользователь может не подходить, прежде чем его пропустят
+	TurnTimeout time.Duration `json:"turn_timeout"`
+	// Policy — как чередуются полосы; по умолчанию строгий приоритет
+	Policy QueuePolicy `json:"policy,omitempty"`
+	// Lanes — полосы приоритета по убыванию; без них очередь обслуживается строго по талонам
+	Lanes []QueueLane `json:"lanes,omitempty"`
+	// Counters — стойки, к которым вызывают пользователей; без них подходит любая стойка
+	Counters []string `json:"counters,omitempty"`
+}
+
+func (s QueueSettings) Validate() error {
+	switch {
+	case s.MaxActive < 0 || s.MaxTotal < 0 || s.MaxPerUser < 0 || s.TurnTimeout < 0:
+		return fmt.Errorf("%w: queue limits must not be negative", ErrInvalidEvent)
+	case s.MaxTotal > 0 && s.MaxActive > s.MaxTotal:
+		return fmt.Errorf("%w: active queue cannot be larger than the total limit", ErrInvalidEvent)
+	}
+	seen := make(map[string]bool, len(s.Counters))
+	for _, counter := range s.Counters {
+		if counter == "" || seen[counter] {
+			return fmt.Errorf("%w: counter names must be unique and not empty", ErrInvalidEvent)
+		}
+		seen[counter] = true
+	}
+	return s.validateLanes()
+}
+
+// CheckCounter проверяет стойку, к которой вызывают пользователя: если организатор перечислил
+// стойки, подходят только они. Пустая стойка — вызов без привязки к месту
+func (s QueueSettings) CheckCounter(counter string) error {
+	if counter == "" || len(s.Counters) == 0 || slices.Contains(s.Counters, counter) {
+		return nil
+	}
+	return fmt.Errorf("%w: unknown service counter %q", ErrInvalidEvent, counter)
+}
+
+// QueueUpdate — состояние записи пользователя, которое получают подписчики очереди
+type QueueUpdate struct {
+	EventID       string        `json:"event_id"`
+	UserID        string        `json:"user_id"`
+	Status        string        `json:"status"`
+	Position      int           `json:"position"`
+	EstimatedWait time.Duration `json:"estimated_wait"`
+	Counter       string        `json:"counter,omitempty"`
+	QueueState    QueueState    `json:"queue_state"`
+	ExpiresAt     *time.Time    `json:"expires_at,omitempty"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// CounterStats — работа одной стойки: кого она обслуживает сейчас и сколько обслужила
+type CounterStats struct {
+	Counter string `json:"counter"`
+	// Serving — пользователь, вызванный к стойке и ещё не обслуженный
+	Serving string `json:"serving,omitempty"`
+	Served  int    `json:"served"`
+	NoShows int    `json:"no_shows"`
+	// AverageService — среднее время от вызова до завершения обслуживания
+	AverageService time.Duration `json:"average_service"`
+}
+
+// InQueue сообщает, что запись ещё ждёт своей очереди или обслуживается
+func (q *EventQueue) InQueue() bool {
+	return q.Waiting() || q.Status == string(QueueStatusActive)
+}
+
+// Waiting сообщает, что пользователь ещё не вызван: стоит в живой очереди или в листе ожидания
+func (q *EventQueue) Waiting() bool {
+	return q.Status == string(QueueStatusWaiting) || q.Status == string(QueueStatusWaitlisted)
+}
+
+// Finished сообщает, что запись больше не изменится сама: пользователь обслужен, вышел или не явился
+func (q *EventQueue) Finished() bool {
+	switch EventQueueStatus(q.Status) {
+	case QueueStatusCompleted, QueueStatusCancelled, QueueStatusNoShow:
+		return true
+	}
+	return false
+}
+
+// EventQueueRepository определяет методы для работы с очередью событий.
+// Порядок в очереди задаёт Ticket — возрастающий номер, который запись получает при
+// вступлении. Position вычисляется по нему при чтении: у ожидающих записей это место
+// в своей полосе начиная с 1, у записей листа ожидания — место в листе ожидания, у остальных — 0.
+// Место в общем порядке вызова сервис получает из LaneState.
+// Все изменения очереди атомарны, ProcessNext
+// никогда не отдаёт одну запись двум обработчикам
 type EventQueueRepository interface {
-	// Save сохраняет запись в очереди
-	Save(queue *EventQueue) error
-	// FindByEventID находит все записи в очереди для конкретного события
-	FindByEventID(eventID string) ([]*EventQueue, error)
-	// FindByUserID находит все записи в очереди для конкретного пользователя
-	FindByUserID(userID string) ([]*EventQueue, error)
-	// Update обновляет запись в очереди
-	Update(queue *EventQueue) error
-	// Delete удаляет запись из очереди
-	Delete(id string) error
-	// GetNext получает следующую запись в очереди для события
-	GetNext(eventID string) (*EventQueue, error)
-	// GetPosition получает позицию пользователя в очереди
-	GetPosition(eventID string, userID string) (int, error)
+	// Join ставит пользователя в конец очереди, а если живая очередь заполнена — в лист ожидания;
+	// пользователь, который уже вышел из очереди или был обслужен, может встать в неё снова.
+	// capacity — число мест мероприятия: места занимают стоящие в очереди и обслуженные
+	// пользователи, при ненулевом capacity сверх него встать нельзя (ErrEventFull)
+	Join(ctx context.Context, eventID, userID, lane string, capacity int, settings QueueSettings) (*EventQueue, error)
+	// SetLane переводит ещё не вызванную запись в полосу lane. Талон сохраняется, поэтому в новой
+	// полосе пользователь стоит по времени своего вступления; вызванным и вышедшим — ErrNotWaiting
+	SetLane(ctx context.Context, eventID, userID, lane string) (*EventQueue, error)
+	// Leave убирает пользователя из очереди и сдвигает тех, кто стоял за ним
+	Leave(ctx context.Context, eventID, userID string) error
 	// GetByEventID находит все записи в очереди для конкретного события
 	GetByEventID(ctx context.Context, eventID string) ([]*EventQueue, error)
-	// GetUserPosition получает позицию пользователя в очереди
-	GetUserPosition(ctx context.Context, eventID, userID string) (int, error)
-	// ProcessNext обрабатывает следующую запись в очереди
-	ProcessNext(ctx context.Context, eventID string) (*EventQueue, error)
+	// GetEntry возвращает запись пользователя в любом статусе
+	GetEntry(ctx context.Context, eventID, userID string) (*EventQueue, error)
+	// ProcessNext вызывает к стойке counter первую запись полосы, которую выбирает политика
+	// очереди; при ненулевом TurnTimeout пользователю назначается срок, до которого он должен подойти.
+	// Стойка обслуживает одного пользователя за раз, иначе ErrCounterBusy; пустая стойка не проверяется
+	ProcessNext(ctx context.Context, eventID, counter string, settings QueueSettings) (*EventQueue, error)
+	// Activate вызывает пользователя, только если он следующий по порядку вызова, иначе ErrNotYourTurn
+	Activate(ctx context.Context, eventID, userID, counter string, settings QueueSettings) (*EventQueue, error)
+	// LaneState возвращает число ожидающих по полосам и состояние обхода полос
+	LaneState(ctx context.Context, eventID string) (*LaneState, error)
+	// Finish завершает обслуживание вызванного пользователя со статусом completed или no_show;
+	// длительность завершённого обслуживания записывается в историю стойки
+	Finish(ctx context.Context, eventID, userID string, status EventQueueStatus) (*EventQueue, error)
+	// RecentServices возвращает не более limit последних завершённых обслуживаний события
+	RecentServices(ctx context.Context, eventID string, limit int) ([]ServiceRecord, error)
+	// ExpireTurns отмечает неявившимися не более limit вызванных пользователей, чей срок
+	// истёк к now, и возвращает их
+	ExpireTurns(ctx context.Context, now time.Time, limit int) ([]*EventQueue, error)
+	// CounterStats возвращает статистику стоек события по текущим записям очереди
+	CounterStats(ctx context.Context, eventID string) ([]*CounterStats, error)
+	// Promote переводит записи из листа ожидания в живую очередь, пока в ней есть места,
+	// и возвращает переведённые записи
+	Promote(ctx context.Context, eventID string, maxActive int) ([]*EventQueue, error)
 }
 
 // EventQueueService определяет бизнес-логику для работы с очередью событий
 type EventQueueService interface {
-	// JoinQueue добавляет пользователя в очередь события
-	JoinQueue(eventID string, userID string) error
+	// JoinQueue добавляет пользователя в общую полосу очереди события
+	JoinQueue(ctx context.Context, eventID, userID string) (*EventQueue, error)
+	// AssignLane переводит ожидающего пользователя в полосу приоритета; доступно организатору operatorID
+	AssignLane(ctx context.Context, eventID, userID, lane, operatorID string) (*EventQueue, error)
 	// LeaveQueue удаляет пользователя из очереди события
-	LeaveQueue(eventID string, userID string) error
+	LeaveQueue(ctx context.Context, eventID, userID string) error
 	// GetQueueStatus получает статус очереди для события
-	GetQueueStatus(eventID string) ([]*EventQueue, error)
-	// GetUserPosition получает позицию пользователя в очереди
-	GetUserPosition(eventID string, userID string) (int, error)
-	// ProcessNext обрабатывает следующую запись в очереди
-	ProcessNext(eventID string) error
-	// CloseQueue закрывает набор в очередь для события
-	CloseQueue(eventID string) error
+	GetQueueStatus(ctx context.Context, eventID string) ([]*EventQueue, error)
+	// GetUserPosition возвращает запись пользователя с его позицией и статусом
+	GetUserPosition(ctx context.Context, eventID, userID string) (*EventQueue, error)
+	// ProcessNext вызывает следующего пользователя к стойке counter; доступно организатору operatorID
+	ProcessNext(ctx context.Context, eventID, counter, operatorID string) (*EventQueue, error)
+	// CompleteEntry отмечает вызванного пользователя обслуженным; доступно организатору operatorID
+	CompleteEntry(ctx context.Context, eventID, userID, operatorID string) (*EventQueue, error)
+	// SkipEntry отмечает вызванного пользователя неявившимся и вызывает следующего к той же стойке;
+	// next равен nil, если ждать некому. Доступно организатору operatorID
+	SkipEntry(ctx context.Context, eventID, userID, operatorID string) (skipped, next *EventQueue, err error)
+	// CheckInToken выдаёт подписанный токен отметки о прибытии для текущей записи пользователя
+	CheckInToken(ctx context.Context, eventID, userID string) (string, error)
+	// CheckIn отмечает прибытие по токену от имени организатора operatorID:
+	// первый в очереди вызывается, вызванный — обслуживается
+	CheckIn(ctx context.Context, token, operatorID, counter string) (*EventQueue, error)
+	// CounterStats возвращает, кого обслуживает каждая стойка и сколько она уже обслужила
+	CounterStats(ctx context.Context, eventID string) ([]*CounterStats, error)
+	// CloseQueue закрывает набор в очередь для события и возвращает новое состояние очереди:
+	// closed или drained, если в ней уже никого нет. Состоянием очереди управляет только организатор
+	CloseQueue(ctx context.Context, eventID, userID string) (QueueState, error)
+	// PauseQueue приостанавливает открытую очередь: вступления и вызовы отклоняются
+	PauseQueue(ctx context.Context, eventID, userID string) (QueueState, error)
+	// ResumeQueue снимает очередь с паузы
+	ResumeQueue(ctx context.Context, eventID, userID string) (QueueState, error)
+	// ReopenQueue снова открывает закрытую или опустевшую очередь
+	ReopenQueue(ctx context.Context, eventID, userID string) (QueueState, error)
+	// ArchiveQueue убирает опустевшую очередь в архив
+	ArchiveQueue(ctx context.Context, eventID, userID string) (QueueState, error)
+	// UpdateQueueSettings меняет ограничения очереди; доступно только организатору
+	UpdateQueueSettings(ctx context.Context, eventID, userID string, settings QueueSettings) (*QueueSettings, error)
+	// WatchQueue передаёт в send состояние записи пользователя при каждом его изменении,
+	// пока запись не завершится или не отменится ctx
+	WatchQueue(ctx context.Context, eventID, userID string, send func(*QueueUpdate) error) error
 }
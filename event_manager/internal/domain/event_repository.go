@@ -5,8 +5,20 @@ import "context"
 type EventRepository interface {
 	Save(ctx context.Context, event *Event) error
 	GetByID(ctx context.Context, id string) (*Event, error)
+	// Update сохраняет данные мероприятия. Этап жизненного цикла, состояние и настройки очереди
+	// меняются только через TransitionEvent, TransitionQueue и UpdateQueueSettings
 	Update(ctx context.Context, event *Event) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context) ([]*Event, error)
 	GetAll(ctx context.Context) ([]*Event, error)
+	// ListVisible возвращает опубликованные публичные мероприятия и все мероприятия организатора userID
+	ListVisible(ctx context.Context, userID string) ([]*Event, error)
+	// TransitionEvent проверяет переход мероприятия в состояние to по текущему состоянию и применяет
+	// его атомарно. Завершение и отмена в той же операции закрывают набор в очередь, см. QueueAfter
+	TransitionEvent(ctx context.Context, eventID string, to EventState) error
+	// TransitionQueue проверяет переход по текущему состоянию очереди и применяет его атомарно.
+	// changed == false, если очередь уже была в нужном состоянии
+	TransitionQueue(ctx context.Context, eventID string, transition QueueTransition) (changed bool, err error)
+	// UpdateQueueSettings сохраняет только настройки очереди мероприятия
+	UpdateQueueSettings(ctx context.Context, eventID string, settings QueueSettings) error
 }
@@ -19,9 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	EventService_ProcessEvent_FullMethodName   = "/gen.EventService/ProcessEvent"
-	EventService_GetEventStatus_FullMethodName = "/gen.EventService/GetEventStatus"
-	EventService_GetAllEvents_FullMethodName   = "/gen.EventService/GetAllEvents"
+	EventService_ProcessEvent_FullMethodName     = "/gen.EventService/ProcessEvent"
+	EventService_GetEventStatus_FullMethodName   = "/gen.EventService/GetEventStatus"
+	EventService_GetAllEvents_FullMethodName     = "/gen.EventService/GetAllEvents"
+	EventService_CreateEvent_FullMethodName      = "/gen.EventService/CreateEvent"
+	EventService_UpdateEvent_FullMethodName      = "/gen.EventService/UpdateEvent"
+	EventService_GetEvent_FullMethodName         = "/gen.EventService/GetEvent"
+	EventService_ListEvents_FullMethodName       = "/gen.EventService/ListEvents"
+	EventService_ChangeEventState_FullMethodName = "/gen.EventService/ChangeEventState"
 )
 
 // EventServiceClient is the client API for EventService service.
@@ -33,6 +38,15 @@ type EventServiceClient interface {
 	ProcessEvent(ctx context.Context, in *ProcessEventRequest, opts ...grpc.CallOption) (*ProcessEventResponse, error)
 	GetEventStatus(ctx context.Context, in *GetEventStatusRequest, opts ...grpc.CallOption) (*GetEventStatusResponse, error)
 	GetAllEvents(ctx context.Context, in *GetAllEventsRequest, opts ...grpc.CallOption) (*GetAllEventsResponse, error)
+	// Создание мероприятия организатором, мероприятие создаётся черновиком
+	CreateEvent(ctx context.Context, in *CreateEventRequest, opts ...grpc.CallOption) (*Event, error)
+	// Изменение описания, времени и вместимости мероприятия
+	UpdateEvent(ctx context.Context, in *UpdateEventRequest, opts ...grpc.CallOption) (*Event, error)
+	GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*Event, error)
+	// Список мероприятий, доступных пользователю
+	ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
+	// Перевод мероприятия в другое состояние жизненного цикла
+	ChangeEventState(ctx context.Context, in *ChangeEventStateRequest, opts ...grpc.CallOption) (*Event, error)
 }
 
 type eventServiceClient struct {
@@ -73,6 +87,56 @@ func (c *eventServiceClient) GetAllEvents(ctx context.Context, in *GetAllEventsR
 	return out, nil
 }
 
+func (c *eventServiceClient) CreateEvent(ctx context.Context, in *CreateEventRequest, opts ...grpc.CallOption) (*Event, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Event)
+	err := c.cc.Invoke(ctx, EventService_CreateEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) UpdateEvent(ctx context.Context, in *UpdateEventRequest, opts ...grpc.CallOption) (*Event, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Event)
+	err := c.cc.Invoke(ctx, EventService_UpdateEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*Event, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Event)
+	err := c.cc.Invoke(ctx, EventService_GetEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*ListEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_ListEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) ChangeEventState(ctx context.Context, in *ChangeEventStateRequest, opts ...grpc.CallOption) (*Event, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Event)
+	err := c.cc.Invoke(ctx, EventService_ChangeEventState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EventServiceServer is the server API for EventService service.
 // All implementations must embed UnimplementedEventServiceServer
 // for forward compatibility.
@@ -82,6 +146,15 @@ type EventServiceServer interface {
 	ProcessEvent(context.Context, *ProcessEventRequest) (*ProcessEventResponse, error)
 	GetEventStatus(context.Context, *GetEventStatusRequest) (*GetEventStatusResponse, error)
 	GetAllEvents(context.Context, *GetAllEventsRequest) (*GetAllEventsResponse, error)
+	// Создание мероприятия организатором, мероприятие создаётся черновиком
+	CreateEvent(context.Context, *CreateEventRequest) (*Event, error)
+	// Изменение описания, времени и вместимости мероприятия
+	UpdateEvent(context.Context, *UpdateEventRequest) (*Event, error)
+	GetEvent(context.Context, *GetEventRequest) (*Event, error)
+	// Список мероприятий, доступных пользователю
+	ListEvents(context.Context, *ListEventsRequest) (*ListEventsResponse, error)
+	// Перевод мероприятия в другое состояние жизненного цикла
+	ChangeEventState(context.Context, *ChangeEventStateRequest) (*Event, error)
 	mustEmbedUnimplementedEventServiceServer()
 }
 
@@ -101,6 +174,21 @@ func (UnimplementedEventServiceServer) GetEventStatus(context.Context, *GetEvent
 func (UnimplementedEventServiceServer) GetAllEvents(context.Context, *GetAllEventsRequest) (*GetAllEventsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetAllEvents not implemented")
 }
+func (UnimplementedEventServiceServer) CreateEvent(context.Context, *CreateEventRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateEvent not implemented")
+}
+func (UnimplementedEventServiceServer) UpdateEvent(context.Context, *UpdateEventRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateEvent not implemented")
+}
+func (UnimplementedEventServiceServer) GetEvent(context.Context, *GetEventRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEvent not implemented")
+}
+func (UnimplementedEventServiceServer) ListEvents(context.Context, *ListEventsRequest) (*ListEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEvents not implemented")
+}
+func (UnimplementedEventServiceServer) ChangeEventState(context.Context, *ChangeEventStateRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangeEventState not implemented")
+}
 func (UnimplementedEventServiceServer) mustEmbedUnimplementedEventServiceServer() {}
 func (UnimplementedEventServiceServer) testEmbeddedByValue()                      {}
 
@@ -176,6 +264,96 @@ func _EventService_GetAllEvents_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_CreateEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).CreateEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_CreateEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).CreateEvent(ctx, req.(*CreateEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_UpdateEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).UpdateEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_UpdateEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).UpdateEvent(ctx, req.(*UpdateEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_GetEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).GetEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_GetEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).GetEvent(ctx, req.(*GetEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_ListEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ListEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ListEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ListEvents(ctx, req.(*ListEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_ChangeEventState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangeEventStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ChangeEventState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ChangeEventState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ChangeEventState(ctx, req.(*ChangeEventStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EventService_ServiceDesc is the grpc.ServiceDesc for EventService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -195,18 +373,50 @@ var EventService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetAllEvents",
 			Handler:    _EventService_GetAllEvents_Handler,
 		},
+		{
+			MethodName: "CreateEvent",
+			Handler:    _EventService_CreateEvent_Handler,
+		},
+		{
+			MethodName: "UpdateEvent",
+			Handler:    _EventService_UpdateEvent_Handler,
+		},
+		{
+			MethodName: "GetEvent",
+			Handler:    _EventService_GetEvent_Handler,
+		},
+		{
+			MethodName: "ListEvents",
+			Handler:    _EventService_ListEvents_Handler,
+		},
+		{
+			MethodName: "ChangeEventState",
+			Handler:    _EventService_ChangeEventState_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/event.proto",
 }
 
 const (
-	EventQueueService_JoinQueue_FullMethodName       = "/gen.EventQueueService/JoinQueue"
-	EventQueueService_LeaveQueue_FullMethodName      = "/gen.EventQueueService/LeaveQueue"
-	EventQueueService_GetQueueStatus_FullMethodName  = "/gen.EventQueueService/GetQueueStatus"
-	EventQueueService_GetUserPosition_FullMethodName = "/gen.EventQueueService/GetUserPosition"
-	EventQueueService_ProcessNext_FullMethodName     = "/gen.EventQueueService/ProcessNext"
-	EventQueueService_CloseQueue_FullMethodName      = "/gen.EventQueueService/CloseQueue"
+	EventQueueService_JoinQueue_FullMethodName           = "/gen.EventQueueService/JoinQueue"
+	EventQueueService_LeaveQueue_FullMethodName          = "/gen.EventQueueService/LeaveQueue"
+	EventQueueService_GetQueueStatus_FullMethodName      = "/gen.EventQueueService/GetQueueStatus"
+	EventQueueService_GetUserPosition_FullMethodName     = "/gen.EventQueueService/GetUserPosition"
+	EventQueueService_ProcessNext_FullMethodName         = "/gen.EventQueueService/ProcessNext"
+	EventQueueService_CloseQueue_FullMethodName          = "/gen.EventQueueService/CloseQueue"
+	EventQueueService_WatchQueue_FullMethodName          = "/gen.EventQueueService/WatchQueue"
+	EventQueueService_UpdateQueueSettings_FullMethodName = "/gen.EventQueueService/UpdateQueueSettings"
+	EventQueueService_AssignLane_FullMethodName          = "/gen.EventQueueService/AssignLane"
+	EventQueueService_CompleteEntry_FullMethodName       = "/gen.EventQueueService/CompleteEntry"
+	EventQueueService_SkipEntry_FullMethodName           = "/gen.EventQueueService/SkipEntry"
+	EventQueueService_GetCheckInToken_FullMethodName     = "/gen.EventQueueService/GetCheckInToken"
+	EventQueueService_CheckIn_FullMethodName             = "/gen.EventQueueService/CheckIn"
+	EventQueueService_GetCounterStats_FullMethodName     = "/gen.EventQueueService/GetCounterStats"
+	EventQueueService_PauseQueue_FullMethodName          = "/gen.EventQueueService/PauseQueue"
+	EventQueueService_ResumeQueue_FullMethodName         = "/gen.EventQueueService/ResumeQueue"
+	EventQueueService_ReopenQueue_FullMethodName         = "/gen.EventQueueService/ReopenQueue"
+	EventQueueService_ArchiveQueue_FullMethodName        = "/gen.EventQueueService/ArchiveQueue"
 )
 
 // EventQueueServiceClient is the client API for EventQueueService service.
@@ -227,6 +437,30 @@ type EventQueueServiceClient interface {
 	ProcessNext(ctx context.Context, in *ProcessNextRequest, opts ...grpc.CallOption) (*ProcessNextResponse, error)
 	// Закрытие набора в очередь для события
 	CloseQueue(ctx context.Context, in *CloseQueueRequest, opts ...grpc.CallOption) (*CloseQueueResponse, error)
+	// Подписка на изменения позиции и статуса пользователя в очереди
+	WatchQueue(ctx context.Context, in *WatchQueueRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[QueueUpdate], error)
+	// Изменение ограничений очереди организатором
+	UpdateQueueSettings(ctx context.Context, in *UpdateQueueSettingsRequest, opts ...grpc.CallOption) (*QueueSettings, error)
+	// Перевод ожидающего пользователя в полосу приоритета организатором
+	AssignLane(ctx context.Context, in *AssignLaneRequest, opts ...grpc.CallOption) (*AssignLaneResponse, error)
+	// Завершение обслуживания вызванного пользователя
+	CompleteEntry(ctx context.Context, in *CompleteEntryRequest, opts ...grpc.CallOption) (*CompleteEntryResponse, error)
+	// Пропуск неявившегося пользователя с вызовом следующего
+	SkipEntry(ctx context.Context, in *SkipEntryRequest, opts ...grpc.CallOption) (*SkipEntryResponse, error)
+	// Токен и QR-код для отметки о прибытии
+	GetCheckInToken(ctx context.Context, in *GetCheckInTokenRequest, opts ...grpc.CallOption) (*GetCheckInTokenResponse, error)
+	// Отметка о прибытии по токену или фотографии QR-кода
+	CheckIn(ctx context.Context, in *CheckInRequest, opts ...grpc.CallOption) (*CheckInResponse, error)
+	// Кого обслуживает каждая стойка и сколько она обслужила
+	GetCounterStats(ctx context.Context, in *GetCounterStatsRequest, opts ...grpc.CallOption) (*GetCounterStatsResponse, error)
+	// Пауза очереди: никто не встаёт и никого не вызывают, места сохраняются
+	PauseQueue(ctx context.Context, in *QueueStateRequest, opts ...grpc.CallOption) (*QueueStateResponse, error)
+	// Снятие очереди с паузы
+	ResumeQueue(ctx context.Context, in *QueueStateRequest, opts ...grpc.CallOption) (*QueueStateResponse, error)
+	// Повторное открытие закрытой или опустевшей очереди
+	ReopenQueue(ctx context.Context, in *QueueStateRequest, opts ...grpc.CallOption) (*QueueStateResponse, error)
+	// Перенос опустевшей очереди в архив
+	ArchiveQueue(ctx context.Context, in *QueueStateRequest, opts ...grpc.CallOption) (*QueueStateResponse, error)
 }
 
 type eventQueueServiceClient struct {
@@ -297,6 +531,135 @@ func (c *eventQueueServiceClient) CloseQueue(ctx context.Context, in *CloseQueue
 	return out, nil
 }
 
+func (c *eventQueueServiceClient) WatchQueue(ctx context.Context, in *WatchQueueRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[QueueUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EventQueueService_ServiceDesc.Streams[0], EventQueueService_WatchQueue_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchQueueRequest, QueueUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EventQueueService_WatchQueueClient = grpc.ServerStreamingClient[QueueUpdate]
+
+func (c *eventQueueServiceClient) UpdateQueueSettings(ctx context.Context, in *UpdateQueueSettingsRequest, opts ...grpc.CallOption) (*QueueSettings, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueueSettings)
+	err := c.cc.Invoke(ctx, EventQueueService_UpdateQueueSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventQueueServiceClient) AssignLane(ctx context.Context, in *AssignLaneRequest, opts ...grpc.CallOption) (*AssignLaneResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AssignLaneResponse)
+	err := c.cc.Invoke(ctx, EventQueueService_AssignLane_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventQueueServiceClient) CompleteEntry(ctx context.Context, in *CompleteEntryRequest, opts ...grpc.CallOption) (*CompleteEntryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompleteEntryResponse)
+	err := c.cc.Invoke(ctx, EventQueueService_CompleteEntry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventQueueServiceClient) SkipEntry(ctx context.Context, in *SkipEntryRequest, opts ...grpc.CallOption) (*SkipEntryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SkipEntryResponse)
+	err := c.cc.Invoke(ctx, EventQueueService_SkipEntry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventQueueServiceClient) GetCheckInToken(ctx context.Context, in *GetCheckInTokenRequest, opts ...grpc.CallOption) (*GetCheckInTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCheckInTokenResponse)
+	err := c.cc.Invoke(ctx, EventQueueService_GetCheckInToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventQueueServiceClient) CheckIn(ctx context.Context, in *CheckInRequest, opts ...grpc.CallOption) (*CheckInResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckInResponse)
+	err := c.cc.Invoke(ctx, EventQueueService_CheckIn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventQueueServiceClient) GetCounterStats(ctx context.Context, in *GetCounterStatsRequest, opts ...grpc.CallOption) (*GetCounterStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCounterStatsResponse)
+	err := c.cc.Invoke(ctx, EventQueueService_GetCounterStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventQueueServiceClient) PauseQueue(ctx context.Context, in *QueueStateRequest, opts ...grpc.CallOption) (*QueueStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueueStateResponse)
+	err := c.cc.Invoke(ctx, EventQueueService_PauseQueue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventQueueServiceClient) ResumeQueue(ctx context.Context, in *QueueStateRequest, opts ...grpc.CallOption) (*QueueStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueueStateResponse)
+	err := c.cc.Invoke(ctx, EventQueueService_ResumeQueue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventQueueServiceClient) ReopenQueue(ctx context.Context, in *QueueStateRequest, opts ...grpc.CallOption) (*QueueStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueueStateResponse)
+	err := c.cc.Invoke(ctx, EventQueueService_ReopenQueue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventQueueServiceClient) ArchiveQueue(ctx context.Context, in *QueueStateRequest, opts ...grpc.CallOption) (*QueueStateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueueStateResponse)
+	err := c.cc.Invoke(ctx, EventQueueService_ArchiveQueue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EventQueueServiceServer is the server API for EventQueueService service.
 // All implementations must embed UnimplementedEventQueueServiceServer
 // for forward compatibility.
@@ -315,6 +678,30 @@ type EventQueueServiceServer interface {
 	ProcessNext(context.Context, *ProcessNextRequest) (*ProcessNextResponse, error)
 	// Закрытие набора в очередь для события
 	CloseQueue(context.Context, *CloseQueueRequest) (*CloseQueueResponse, error)
+	// Подписка на изменения позиции и статуса пользователя в очереди
+	WatchQueue(*WatchQueueRequest, grpc.ServerStreamingServer[QueueUpdate]) error
+	// Изменение ограничений очереди организатором
+	UpdateQueueSettings(context.Context, *UpdateQueueSettingsRequest) (*QueueSettings, error)
+	// Перевод ожидающего пользователя в полосу приоритета организатором
+	AssignLane(context.Context, *AssignLaneRequest) (*AssignLaneResponse, error)
+	// Завершение обслуживания вызванного пользователя
+	CompleteEntry(context.Context, *CompleteEntryRequest) (*CompleteEntryResponse, error)
+	// Пропуск неявившегося пользователя с вызовом следующего
+	SkipEntry(context.Context, *SkipEntryRequest) (*SkipEntryResponse, error)
+	// Токен и QR-код для отметки о прибытии
+	GetCheckInToken(context.Context, *GetCheckInTokenRequest) (*GetCheckInTokenResponse, error)
+	// Отметка о прибытии по токену или фотографии QR-кода
+	CheckIn(context.Context, *CheckInRequest) (*CheckInResponse, error)
+	// Кого обслуживает каждая стойка и сколько она обслужила
+	GetCounterStats(context.Context, *GetCounterStatsRequest) (*GetCounterStatsResponse, error)
+	// Пауза очереди: никто не встаёт и никого не вызывают, места сохраняются
+	PauseQueue(context.Context, *QueueStateRequest) (*QueueStateResponse, error)
+	// Снятие очереди с паузы
+	ResumeQueue(context.Context, *QueueStateRequest) (*QueueStateResponse, error)
+	// Повторное открытие закрытой или опустевшей очереди
+	ReopenQueue(context.Context, *QueueStateRequest) (*QueueStateResponse, error)
+	// Перенос опустевшей очереди в архив
+	ArchiveQueue(context.Context, *QueueStateRequest) (*QueueStateResponse, error)
 	mustEmbedUnimplementedEventQueueServiceServer()
 }
 
@@ -343,6 +730,42 @@ func (UnimplementedEventQueueServiceServer) ProcessNext(context.Context, *Proces
 func (UnimplementedEventQueueServiceServer) CloseQueue(context.Context, *CloseQueueRequest) (*CloseQueueResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CloseQueue not implemented")
 }
+func (UnimplementedEventQueueServiceServer) WatchQueue(*WatchQueueRequest, grpc.ServerStreamingServer[QueueUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchQueue not implemented")
+}
+func (UnimplementedEventQueueServiceServer) UpdateQueueSettings(context.Context, *UpdateQueueSettingsRequest) (*QueueSettings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateQueueSettings not implemented")
+}
+func (UnimplementedEventQueueServiceServer) AssignLane(context.Context, *AssignLaneRequest) (*AssignLaneResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AssignLane not implemented")
+}
+func (UnimplementedEventQueueServiceServer) CompleteEntry(context.Context, *CompleteEntryRequest) (*CompleteEntryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompleteEntry not implemented")
+}
+func (UnimplementedEventQueueServiceServer) SkipEntry(context.Context, *SkipEntryRequest) (*SkipEntryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SkipEntry not implemented")
+}
+func (UnimplementedEventQueueServiceServer) GetCheckInToken(context.Context, *GetCheckInTokenRequest) (*GetCheckInTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCheckInToken not implemented")
+}
+func (UnimplementedEventQueueServiceServer) CheckIn(context.Context, *CheckInRequest) (*CheckInResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckIn not implemented")
+}
+func (UnimplementedEventQueueServiceServer) GetCounterStats(context.Context, *GetCounterStatsRequest) (*GetCounterStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCounterStats not implemented")
+}
+func (UnimplementedEventQueueServiceServer) PauseQueue(context.Context, *QueueStateRequest) (*QueueStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseQueue not implemented")
+}
+func (UnimplementedEventQueueServiceServer) ResumeQueue(context.Context, *QueueStateRequest) (*QueueStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeQueue not implemented")
+}
+func (UnimplementedEventQueueServiceServer) ReopenQueue(context.Context, *QueueStateRequest) (*QueueStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReopenQueue not implemented")
+}
+func (UnimplementedEventQueueServiceServer) ArchiveQueue(context.Context, *QueueStateRequest) (*QueueStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ArchiveQueue not implemented")
+}
 func (UnimplementedEventQueueServiceServer) mustEmbedUnimplementedEventQueueServiceServer() {}
 func (UnimplementedEventQueueServiceServer) testEmbeddedByValue()                           {}
 
@@ -472,6 +895,215 @@ func _EventQueueService_CloseQueue_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventQueueService_WatchQueue_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchQueueRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventQueueServiceServer).WatchQueue(m, &grpc.GenericServerStream[WatchQueueRequest, QueueUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EventQueueService_WatchQueueServer = grpc.ServerStreamingServer[QueueUpdate]
+
+func _EventQueueService_UpdateQueueSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateQueueSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventQueueServiceServer).UpdateQueueSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventQueueService_UpdateQueueSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventQueueServiceServer).UpdateQueueSettings(ctx, req.(*UpdateQueueSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventQueueService_AssignLane_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssignLaneRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventQueueServiceServer).AssignLane(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventQueueService_AssignLane_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventQueueServiceServer).AssignLane(ctx, req.(*AssignLaneRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventQueueService_CompleteEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompleteEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventQueueServiceServer).CompleteEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventQueueService_CompleteEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventQueueServiceServer).CompleteEntry(ctx, req.(*CompleteEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventQueueService_SkipEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SkipEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventQueueServiceServer).SkipEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventQueueService_SkipEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventQueueServiceServer).SkipEntry(ctx, req.(*SkipEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventQueueService_GetCheckInToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCheckInTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventQueueServiceServer).GetCheckInToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventQueueService_GetCheckInToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventQueueServiceServer).GetCheckInToken(ctx, req.(*GetCheckInTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventQueueService_CheckIn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckInRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventQueueServiceServer).CheckIn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventQueueService_CheckIn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventQueueServiceServer).CheckIn(ctx, req.(*CheckInRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventQueueService_GetCounterStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCounterStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventQueueServiceServer).GetCounterStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventQueueService_GetCounterStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventQueueServiceServer).GetCounterStats(ctx, req.(*GetCounterStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventQueueService_PauseQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueueStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventQueueServiceServer).PauseQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventQueueService_PauseQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventQueueServiceServer).PauseQueue(ctx, req.(*QueueStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventQueueService_ResumeQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueueStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventQueueServiceServer).ResumeQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventQueueService_ResumeQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventQueueServiceServer).ResumeQueue(ctx, req.(*QueueStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventQueueService_ReopenQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueueStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventQueueServiceServer).ReopenQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventQueueService_ReopenQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventQueueServiceServer).ReopenQueue(ctx, req.(*QueueStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventQueueService_ArchiveQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueueStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventQueueServiceServer).ArchiveQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventQueueService_ArchiveQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventQueueServiceServer).ArchiveQueue(ctx, req.(*QueueStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EventQueueService_ServiceDesc is the grpc.ServiceDesc for EventQueueService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -503,7 +1135,57 @@ var EventQueueService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CloseQueue",
 			Handler:    _EventQueueService_CloseQueue_Handler,
 		},
+		{
+			MethodName: "UpdateQueueSettings",
+			Handler:    _EventQueueService_UpdateQueueSettings_Handler,
+		},
+		{
+			MethodName: "AssignLane",
+			Handler:    _EventQueueService_AssignLane_Handler,
+		},
+		{
+			MethodName: "CompleteEntry",
+			Handler:    _EventQueueService_CompleteEntry_Handler,
+		},
+		{
+			MethodName: "SkipEntry",
+			Handler:    _EventQueueService_SkipEntry_Handler,
+		},
+		{
+			MethodName: "GetCheckInToken",
+			Handler:    _EventQueueService_GetCheckInToken_Handler,
+		},
+		{
+			MethodName: "CheckIn",
+			Handler:    _EventQueueService_CheckIn_Handler,
+		},
+		{
+			MethodName: "GetCounterStats",
+			Handler:    _EventQueueService_GetCounterStats_Handler,
+		},
+		{
+			MethodName: "PauseQueue",
+			Handler:    _EventQueueService_PauseQueue_Handler,
+		},
+		{
+			MethodName: "ResumeQueue",
+			Handler:    _EventQueueService_ResumeQueue_Handler,
+		},
+		{
+			MethodName: "ReopenQueue",
+			Handler:    _EventQueueService_ReopenQueue_Handler,
+		},
+		{
+			MethodName: "ArchiveQueue",
+			Handler:    _EventQueueService_ArchiveQueue_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchQueue",
+			Handler:       _EventQueueService_WatchQueue_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/event.proto",
 }
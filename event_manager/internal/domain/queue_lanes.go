@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// QueuePolicy определяет, как ProcessNext чередует полосы очереди
+type QueuePolicy string
+
+const (
+	// QueuePolicyStrict всегда вызывает из самой приоритетной непустой полосы
+	QueuePolicyStrict QueuePolicy = "strict"
+	// QueuePolicyWeighted обходит полосы по кругу и вызывает из каждой подряд не больше её веса
+	QueuePolicyWeighted QueuePolicy = "weighted"
+)
+
+// QueueLane — класс приоритета записей, например staff, accessibility или vip
+type QueueLane struct {
+	Name string `json:"name"`
+	// Weight — сколько человек подряд вызывается из полосы при взвешенной политике, не меньше 1
+	Weight int `json:"weight"`
+}
+
+// LaneCursor — место, на котором остановился взвешенный обход: полоса и сколько из неё
+// уже вызвано подряд
+type LaneCursor struct {
+	Lane  string `json:"lane"`
+	Calls int    `json:"calls"`
+}
+
+// LaneState — всё, что нужно, чтобы предсказать порядок вызова: сколько человек ждёт
+// в каждой полосе и где остановился обход
+type LaneState struct {
+	Cursor  LaneCursor
+	Waiting map[string]int
+}
+
+// JoinLane выбирает полосу записи. Без явного выбора запись попадает в последнюю,
+// наименее приоритетную полосу; без настроенных полос все записи стоят в одной безымянной
+func (s QueueSettings) JoinLane(name string) (string, error) {
+	if name == "" {
+		if len(s.Lanes) == 0 {
+			return "", nil
+		}
+		return s.Lanes[len(s.Lanes)-1].Name, nil
+	}
+	for _, lane := range s.Lanes {
+		if lane.Name == name {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown queue lane %q", ErrInvalidEvent, name)
+}
+
+// NextLane выбирает полосу, из которой будет вызван следующий пользователь, и возвращает
+// обход после этого вызова. ok == false, если ждать некому
+func (s QueueSettings) NextLane(cursor LaneCursor, waiting map[string]int) (lane string, next LaneCursor, ok bool) {
+	lanes := s.laneOrder(waiting)
+	if s.Policy != QueuePolicyWeighted {
+		for _, lane := range lanes {
+			if waiting[lane.Name] > 0 {
+				return lane.Name, cursor, true
+			}
+		}
+		return "", cursor, false
+	}
+
+	current := -1
+	for i, lane := range lanes {
+		if lane.Name == cursor.Lane {
+			current = i
+			break
+		}
+	}
+	if current >= 0 && waiting[cursor.Lane] > 0 && cursor.Calls < lanes[current].weight() {
+		return cursor.Lane, LaneCursor{Lane: cursor.Lane, Calls: cursor.Calls + 1}, true
+	}
+	// ход переходит к следующей непустой полосе; последней проверяется текущая
+	for i := 1; i <= len(lanes); i++ {
+		lane := lanes[(current+i+len(lanes))%len(lanes)]
+		if waiting[lane.Name] > 0 {
+			return lane.Name, LaneCursor{Lane: lane.Name, Calls: 1}, true
+		}
+	}
+	return "", cursor, false
+}
+
+// LanePositions предсказывает порядок вызова: для каждой полосы возвращает места в общей
+// очереди, которые займут её записи по порядку талонов
+func (s QueueSettings) LanePositions(state LaneState) map[string][]int {
+	remaining := make(map[string]int, len(state.Waiting))
+	total := 0
+	for lane, count := range state.Waiting {
+		remaining[lane] = count
+		total += count
+	}
+
+	positions := make(map[string][]int, len(remaining))
+	cursor := state.Cursor
+	for position := 1; position <= total; position++ {
+		lane, next, ok := s.NextLane(cursor, remaining)
+		if !ok {
+			break
+		}
+		positions[lane] = append(positions[lane], position)
+		remaining[lane]--
+		cursor = next
+	}
+	return positions
+}
+
+// laneOrder возвращает полосы по убыванию приоритета. Полосы, убранные из настроек,
+// пока в них кто-то ждёт, обслуживаются последними
+func (s QueueSettings) laneOrder(waiting map[string]int) []QueueLane {
+	lanes := append([]QueueLane(nil), s.Lanes...)
+	if len(lanes) == 0 {
+		lanes = append(lanes, QueueLane{})
+	}
+	known := make(map[string]bool, len(lanes))
+	for _, lane := range lanes {
+		known[lane.Name] = true
+	}
+	var removed []string
+	for name, count := range waiting {
+		if count > 0 && !known[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		lanes = append(lanes, QueueLane{Name: name})
+	}
+	return lanes
+}
+
+func (s QueueSettings) validateLanes() error {
+	switch s.Policy {
+	case "", QueuePolicyStrict, QueuePolicyWeighted:
+	default:
+		return fmt.Errorf("%w: unknown queue policy %q", ErrInvalidEvent, s.Policy)
+	}
+	names := make(map[string]bool, len(s.Lanes))
+	for _, lane := range s.Lanes {
+		switch {
+		case lane.Name == "":
+			return fmt.Errorf("%w: queue lane name is required", ErrInvalidEvent)
+		case names[lane.Name]:
+			return fmt.Errorf("%w: duplicate queue lane %q", ErrInvalidEvent, lane.Name)
+		case lane.Weight < 0:
+			return fmt.Errorf("%w: queue lane weight must not be negative", ErrInvalidEvent)
+		}
+		names[lane.Name] = true
+	}
+	return nil
+}
+
+func (l QueueLane) weight() int {
+	return max(l.Weight, 1)
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrQueuePaused            = errors.New("queue is paused")
+	ErrQueueArchived          = errors.New("queue is archived")
+	ErrInvalidQueueTransition = errors.New("invalid queue state transition")
+)
+
+// QueueState — этап жизни очереди мероприятия
+type QueueState string
+
+const (
+	// QueueStateOpen — очередь принимает новых пользователей и вызывает вставших
+	QueueStateOpen QueueState = "open"
+	// QueueStatePaused — очередь временно никого не принимает и не вызывает
+	QueueStatePaused QueueState = "paused"
+	// QueueStateClosed — новых пользователей нет, вставшие дообслуживаются
+	QueueStateClosed QueueState = "closed"
+	// QueueStateDrained — очередь закрыта и в ней больше никто не ждёт
+	QueueStateDrained QueueState = "drained"
+	// QueueStateArchived — очередь убрана в архив и больше не меняется
+	QueueStateArchived QueueState = "archived"
+)
+
+// QueueTransition — действие над очередью: в какое состояние оно переводит и из каких
+type QueueTransition struct {
+	To   QueueState
+	From []QueueState
+}
+
+var (
+	// QueuePause приостанавливает открытую очередь
+	QueuePause = QueueTransition{To: QueueStatePaused, From: []QueueState{QueueStateOpen}}
+	// QueueResume снимает очередь с паузы
+	QueueResume = QueueTransition{To: QueueStateOpen, From: []QueueState{QueueStatePaused}}
+	// QueueClose закрывает набор в открытую или приостановленную очередь
+	QueueClose = QueueTransition{To: QueueStateClosed, From: []QueueState{QueueStateOpen, QueueStatePaused}}
+	// QueueReopen снова открывает закрытую или опустевшую очередь
+	QueueReopen = QueueTransition{To: QueueStateOpen, From: []QueueState{QueueStateClosed, QueueStateDrained}}
+	// QueueDrain отмечает, что в закрытой очереди больше никто не стоит
+	QueueDrain = QueueTransition{To: QueueStateDrained, From: []QueueState{QueueStateClosed}}
+	// QueueArchive убирает опустевшую очередь в архив
+	QueueArchive = QueueTransition{To: QueueStateArchived, From: []QueueState{QueueStateDrained}}
+)
+
+// Check проверяет переход из текущего состояния current. changed == false, если очередь
+// уже в нужном состоянии и менять ничего не надо
+func (t QueueTransition) Check(current QueueState) (changed bool, err error) {
+	if current == t.To {
+		return false, nil
+	}
+	if !slices.Contains(t.From, current) {
+		return false, fmt.Errorf("%w: %s -> %s", ErrInvalidQueueTransition, current, t.To)
+	}
+	return true, nil
+}
+
+// AcceptsJoins сообщает, можно ли встать в очередь в этом состоянии
+func (s QueueState) AcceptsJoins() error {
+	switch s {
+	case QueueStateOpen:
+		return nil
+	case QueueStatePaused:
+		return ErrQueuePaused
+	case QueueStateArchived:
+		return ErrQueueArchived
+	}
+	return ErrQueueClosed
+}
+
+// AcceptsCalls сообщает, можно ли вызывать пользователей в этом состоянии
+func (s QueueState) AcceptsCalls() error {
+	switch s {
+	case QueueStatePaused:
+		return ErrQueuePaused
+	case QueueStateArchived:
+		return ErrQueueArchived
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
+)
+
+// checkInClaims — то, что зашито в токен: конкретная запись в очереди.
+// Билет отличает запись от прежних записей того же пользователя, поэтому старый токен
+// после повторной постановки в очередь уже не подходит
+type checkInClaims struct {
+	EventID string `json:"e"`
+	UserID  string `json:"u"`
+	Ticket  int64  `json:"t"`
+}
+
+// minSecretLength — ключ короче 32 байт слабее самого HMAC-SHA256
+const minSecretLength = 32
+
+// CheckInSigner подписывает токены отметки о прибытии HMAC-SHA256, чтобы их нельзя было подделать
+type CheckInSigner struct {
+	secret []byte
+}
+
+// NewCheckInSigner отказывается работать с пустым, коротким или оставленным из примера ключом
+func NewCheckInSigner(secret string) (*CheckInSigner, error) {
+	switch {
+	case secret == "":
+		return nil, errors.New("check-in secret is required")
+	case strings.HasPrefix(secret, "change-me"):
+		return nil, errors.New("check-in secret is still the placeholder value")
+	case len(secret) < minSecretLength:
+		return nil, fmt.Errorf("check-in secret must be at least %d bytes", minSecretLength)
+	}
+	return &CheckInSigner{secret: []byte(secret)}, nil
+}
+
+// Sign возвращает токен вида base64(claims).base64(подпись)
+func (s *CheckInSigner) Sign(queue *domain.EventQueue) (string, error) {
+	payload, err := json.Marshal(checkInClaims{EventID: queue.EventID, UserID: queue.UserID, Ticket: queue.Ticket})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.mac(encoded)), nil
+}
+
+// Verify проверяет подпись и возвращает содержимое токена
+func (s *CheckInSigner) Verify(token string) (*checkInClaims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, domain.ErrInvalidCheckIn
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.mac(encoded)) {
+		return nil, domain.ErrInvalidCheckIn
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, domain.ErrInvalidCheckIn
+	}
+	var claims checkInClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.EventID == "" || claims.UserID == "" {
+		return nil, domain.ErrInvalidCheckIn
+	}
+	return &claims, nil
+}
+
+func (s *CheckInSigner) mac(encoded string) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(encoded))
+	return h.Sum(nil)
+}
@@ -2,28 +2,658 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
+	"github.com/XRS0/ToTalkB/event_manager/internal/infrastructure/notification"
 )
 
+// serviceHistory — по скольким последним обслуживаниям оценивается ожидание
+const serviceHistory = 20
+
 type EventQueueService struct {
 	queueRepo domain.EventQueueRepository
+	eventRepo domain.EventRepository
+	notifier  *notification.Client
+	signer    *CheckInSigner
+	feed      *QueueFeed
 }
 
-func NewEventQueueService(repo domain.EventQueueRepository) *EventQueueService {
+func NewEventQueueService(repo domain.EventQueueRepository, eventRepo domain.EventRepository, notifier *notification.Client, signer *CheckInSigner) *EventQueueService {
 	return &EventQueueService{
 		queueRepo: repo,
+		eventRepo: eventRepo,
+		notifier:  notifier,
+		signer:    signer,
+		feed:      NewQueueFeed(),
+	}
+}
+
+// JoinQueue ставит пользователя в общую полосу очереди, если набор в неё открыт. Когда живая
+// очередь заполнена, пользователь попадает в лист ожидания. В приоритетные полосы переводит
+// только организатор через AssignLane
+func (s *EventQueueService) JoinQueue(ctx context.Context, eventID, userID string) (*domain.EventQueue, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%w: user is required", domain.ErrInvalidEvent)
+	}
+	event, err := s.event(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if err := event.AcceptsQueue(); err != nil {
+		return nil, err
+	}
+	lane, err := event.QueueSettings.JoinLane("")
+	if err != nil {
+		return nil, err
+	}
+	queue, err := s.queueRepo.Join(ctx, eventID, userID, lane, event.Capacity, event.QueueSettings)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(queue)
+	// пользователь уже в очереди, поэтому сбой пересчёта места не отменяет вступление
+	if err := s.estimate(ctx, event, queue); err != nil {
+		log.Printf("Failed to compute queue position: %v", err)
+	}
+	return queue, nil
+}
+
+// AssignLane переводит ещё не вызванного пользователя в полосу lane по решению организатора operatorID.
+// Пустая полоса возвращает его в общую
+func (s *EventQueueService) AssignLane(ctx context.Context, eventID, userID, lane, operatorID string) (*domain.EventQueue, error) {
+	event, err := s.organizerEvent(ctx, eventID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	lane, err = event.QueueSettings.JoinLane(lane)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := s.queueRepo.SetLane(ctx, eventID, userID, lane)
+	if err != nil {
+		return nil, err
+	}
+	// перевод меняет порядок вызова, подписчики пересчитывают свои места
+	s.feed.Publish(QueueChange{EventID: eventID})
+	if err := s.estimate(ctx, event, queue); err != nil {
+		log.Printf("Failed to compute queue position: %v", err)
+	}
+	return queue, nil
+}
+
+func (s *EventQueueService) LeaveQueue(ctx context.Context, eventID, userID string) error {
+	event, err := s.event(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	if err := s.queueRepo.Leave(ctx, eventID, userID); err != nil {
+		return err
 	}
+	s.feed.Publish(QueueChange{EventID: eventID, UserID: userID, Status: string(domain.QueueStatusCancelled)})
+	s.promote(ctx, event)
+	s.drain(ctx, eventID)
+	return nil
 }
 
+// GetQueueStatus возвращает записи очереди; ожидающие идут в порядке, в котором их вызовут
 func (s *EventQueueService) GetQueueStatus(ctx context.Context, eventID string) ([]*domain.EventQueue, error) {
-	return s.queueRepo.GetByEventID(ctx, eventID)
+	event, err := s.event(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	queues, err := s.queueRepo.GetByEventID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.estimate(ctx, event, queues...); err != nil {
+		return nil, err
+	}
+	// ожидающие стоят в начале списка, переставляем их по месту в общем порядке вызова
+	waiting := 0
+	for waiting < len(queues) && queues[waiting].Status == string(domain.QueueStatusWaiting) {
+		waiting++
+	}
+	sort.SliceStable(queues[:waiting], func(i, j int) bool { return queues[i].Position < queues[j].Position })
+	return queues, nil
+}
+
+// GetUserPosition возвращает запись пользователя, который ещё стоит в очереди или обслуживается
+func (s *EventQueueService) GetUserPosition(ctx context.Context, eventID, userID string) (*domain.EventQueue, error) {
+	event, err := s.event(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := s.queueRepo.GetEntry(ctx, eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !queue.InQueue() {
+		return nil, domain.ErrNotInQueue
+	}
+	if err := s.estimate(ctx, event, queue); err != nil {
+		return nil, err
+	}
+	return queue, nil
+}
+
+// ProcessNext вызывает следующего к стойке counter по решению организатора operatorID;
+// без стойки вызовы не привязываются к месту
+func (s *EventQueueService) ProcessNext(ctx context.Context, eventID, counter, operatorID string) (*domain.EventQueue, error) {
+	event, err := s.organizerEvent(ctx, eventID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := event.QueueSettings.CheckCounter(counter); err != nil {
+		return nil, err
+	}
+	return s.callNext(ctx, event, counter)
+}
+
+// CompleteEntry отмечает вызванного пользователя обслуженным по решению организатора operatorID
+func (s *EventQueueService) CompleteEntry(ctx context.Context, eventID, userID, operatorID string) (*domain.EventQueue, error) {
+	if _, err := s.organizerEvent(ctx, eventID, operatorID); err != nil {
+		return nil, err
+	}
+	queue, err := s.queueRepo.Finish(ctx, eventID, userID, domain.QueueStatusCompleted)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(queue)
+	s.drain(ctx, eventID)
+	return queue, nil
+}
+
+// SkipEntry отмечает вызванного пользователя неявившимся по решению организатора operatorID
+// и вызывает следующего к той же стойке
+func (s *EventQueueService) SkipEntry(ctx context.Context, eventID, userID, operatorID string) (*domain.EventQueue, *domain.EventQueue, error) {
+	event, err := s.organizerEvent(ctx, eventID, operatorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	skipped, err := s.queueRepo.Finish(ctx, eventID, userID, domain.QueueStatusNoShow)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.publish(skipped)
+	next := s.handOver(ctx, event, skipped.Counter)
+	s.drain(ctx, eventID)
+	return skipped, next, nil
+}
+
+// CheckInToken выдаёт токен для QR-кода, по которому пользователь отмечается на месте.
+// Токен привязан к текущей записи и после выхода из очереди перестаёт действовать
+func (s *EventQueueService) CheckInToken(ctx context.Context, eventID, userID string) (string, error) {
+	queue, err := s.GetUserPosition(ctx, eventID, userID)
+	if err != nil {
+		return "", err
+	}
+	return s.signer.Sign(queue)
+}
+
+// CheckIn отмечает прибытие по токену. Отмечает организатор мероприятия operatorID:
+// первый в живой очереди становится обслуживаемым, уже вызванный — обслуженным; остальным ещё рано
+func (s *EventQueueService) CheckIn(ctx context.Context, token, operatorID, counter string) (*domain.EventQueue, error) {
+	claims, err := s.signer.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.organizerEvent(ctx, claims.EventID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := s.queueRepo.GetEntry(ctx, claims.EventID, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if queue.Ticket != claims.Ticket {
+		return nil, domain.ErrInvalidCheckIn
+	}
+
+	switch domain.EventQueueStatus(queue.Status) {
+	case domain.QueueStatusActive:
+		return s.CompleteEntry(ctx, event.ID, queue.UserID, operatorID)
+	case domain.QueueStatusWaiting:
+		// вызвать к себе по отметке можно только у настоящей стойки
+		if counter == "" {
+			return nil, fmt.Errorf("%w: counter is required to check in", domain.ErrInvalidEvent)
+		}
+		if err := event.QueueSettings.CheckCounter(counter); err != nil {
+			return nil, err
+		}
+		if err := event.AcceptsCalls(); err != nil {
+			return nil, err
+		}
+		queue, err := s.queueRepo.Activate(ctx, event.ID, queue.UserID, counter, event.QueueSettings)
+		if err != nil {
+			return nil, err
+		}
+		s.called(ctx, event, queue)
+		return queue, nil
+	case domain.QueueStatusWaitlisted:
+		return nil, domain.ErrNotYourTurn
+	}
+	return nil, domain.ErrNotInQueue
+}
+
+// CounterStats возвращает, кого обслуживает каждая стойка, сколько она обслужила
+// и сколько в среднем длится обслуживание
+func (s *EventQueueService) CounterStats(ctx context.Context, eventID string) ([]*domain.CounterStats, error) {
+	if _, err := s.event(ctx, eventID); err != nil {
+		return nil, err
+	}
+	return s.queueRepo.CounterStats(ctx, eventID)
+}
+
+// ExpireTurns отмечает неявившимися вызванных пользователей с истёкшим сроком
+// и передаёт их очередь следующим. Возвращает, сколько записей обработано
+func (s *EventQueueService) ExpireTurns(ctx context.Context, now time.Time, limit int) (int, error) {
+	expired, err := s.queueRepo.ExpireTurns(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	events := make(map[string]*domain.Event)
+	for _, queue := range expired {
+		s.publish(queue)
+		event, ok := events[queue.EventID]
+		if !ok {
+			if event, err = s.eventRepo.GetByID(ctx, queue.EventID); err != nil {
+				log.Printf("Failed to load event %s for expired turn: %v", queue.EventID, err)
+				continue
+			}
+			events[queue.EventID] = event
+		}
+		s.notifyUser(ctx, "queue_no_show", event, queue)
+		s.handOver(ctx, event, queue.Counter)
+	}
+	for eventID := range events {
+		s.drain(ctx, eventID)
+	}
+	return len(expired), nil
+}
+
+// callNext вызывает следующего пользователя: назначает ему срок, оповещает его
+// и отдаёт освободившееся в живой очереди место листу ожидания
+func (s *EventQueueService) callNext(ctx context.Context, event *domain.Event, counter string) (*domain.EventQueue, error) {
+	if err := event.AcceptsCalls(); err != nil {
+		return nil, err
+	}
+	queue, err := s.queueRepo.ProcessNext(ctx, event.ID, counter, event.QueueSettings)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyUser(ctx, "queue_turn", event, queue)
+	s.called(ctx, event, queue)
+	return queue, nil
+}
+
+// called сообщает о вызове пользователя и отдаёт его место листу ожидания
+func (s *EventQueueService) called(ctx context.Context, event *domain.Event, queue *domain.EventQueue) {
+	s.publish(queue)
+	s.promote(ctx, event)
+}
+
+// handOver вызывает следующего к освободившейся стойке вместо пропущенного; пустая или
+// приостановленная очередь и завершённое мероприятие здесь не ошибка
+func (s *EventQueueService) handOver(ctx context.Context, event *domain.Event, counter string) *domain.EventQueue {
+	next, err := s.callNext(ctx, event, counter)
+	if err != nil {
+		if !errors.Is(err, domain.ErrQueueEmpty) && !errors.Is(err, domain.ErrQueuePaused) && !errors.Is(err, domain.ErrEventClosed) {
+			log.Printf("Failed to call next user for event %s: %v", event.ID, err)
+		}
+		return nil
+	}
+	return next
+}
+
+// CloseQueue закрывает набор: новые пользователи встать не смогут, вставшие дождутся своей очереди.
+// Когда в очереди никого не останется, она станет опустевшей
+func (s *EventQueueService) CloseQueue(ctx context.Context, eventID, userID string) (domain.QueueState, error) {
+	state, err := s.changeQueueState(ctx, eventID, userID, domain.QueueClose)
+	if err != nil {
+		return "", err
+	}
+	if drained := s.drain(ctx, eventID); drained {
+		state = domain.QueueStateDrained
+	}
+	return state, nil
+}
+
+// PauseQueue приостанавливает очередь: встать в неё нельзя и никого не вызывают,
+// но места вставших сохраняются
+func (s *EventQueueService) PauseQueue(ctx context.Context, eventID, userID string) (domain.QueueState, error) {
+	return s.changeQueueState(ctx, eventID, userID, domain.QueuePause)
+}
+
+// ResumeQueue снимает очередь с паузы
+func (s *EventQueueService) ResumeQueue(ctx context.Context, eventID, userID string) (domain.QueueState, error) {
+	return s.changeQueueState(ctx, eventID, userID, domain.QueueResume)
+}
+
+// ReopenQueue снова открывает набор в закрытую или опустевшую очередь
+func (s *EventQueueService) ReopenQueue(ctx context.Context, eventID, userID string) (domain.QueueState, error) {
+	return s.changeQueueState(ctx, eventID, userID, domain.QueueReopen)
+}
+
+// ArchiveQueue убирает опустевшую очередь в архив, после этого она больше не меняется
+func (s *EventQueueService) ArchiveQueue(ctx context.Context, eventID, userID string) (domain.QueueState, error) {
+	return s.changeQueueState(ctx, eventID, userID, domain.QueueArchive)
+}
+
+// changeQueueState выполняет переход по просьбе организатора и сообщает о нём стоящим в очереди.
+// Повторный перевод в текущее состояние ничего не меняет
+func (s *EventQueueService) changeQueueState(ctx context.Context, eventID, userID string, transition domain.QueueTransition) (domain.QueueState, error) {
+	event, err := s.organizerEvent(ctx, eventID, userID)
+	if err != nil {
+		return "", err
+	}
+	// переход проверяется в хранилище по текущему состоянию, а не по прочитанному выше
+	changed, err := s.eventRepo.TransitionQueue(ctx, eventID, transition)
+	if err != nil {
+		return "", err
+	}
+	if changed {
+		event.QueueState = transition.To
+		s.queueStateChanged(ctx, event)
+	}
+	return transition.To, nil
+}
+
+// EventClosed сообщает всем, кто ещё стоит в очереди, что мероприятие завершено или отменено:
+// набор в очередь к этому моменту уже закрыт, а вызывать их больше не будут
+func (s *EventQueueService) EventClosed(ctx context.Context, event *domain.Event) {
+	s.feed.Publish(QueueChange{EventID: event.ID})
+	queues, err := s.queueRepo.GetByEventID(ctx, event.ID)
+	if err != nil {
+		log.Printf("Failed to load queue of event %s: %v", event.ID, err)
+		return
+	}
+	for _, queue := range queues {
+		if queue.InQueue() {
+			s.notifyUser(ctx, "event_"+string(event.State), event, queue)
+		}
+	}
+	s.drain(ctx, event.ID)
+}
+
+// drain переводит закрытую очередь, в которой больше никто не стоит, в опустевшую
+func (s *EventQueueService) drain(ctx context.Context, eventID string) bool {
+	event, err := s.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		log.Printf("Failed to load event %s to drain its queue: %v", eventID, err)
+		return false
+	}
+	if event.QueueState != domain.QueueStateClosed {
+		return false
+	}
+	queues, err := s.queueRepo.GetByEventID(ctx, eventID)
+	if err != nil {
+		log.Printf("Failed to load queue of event %s: %v", eventID, err)
+		return false
+	}
+	if slices.ContainsFunc(queues, (*domain.EventQueue).InQueue) {
+		return false
+	}
+	// очередь могли снова открыть после чтения, тогда переход не пройдёт
+	changed, err := s.eventRepo.TransitionQueue(ctx, eventID, domain.QueueDrain)
+	if err != nil {
+		if !errors.Is(err, domain.ErrInvalidQueueTransition) {
+			log.Printf("Failed to mark queue of event %s drained: %v", eventID, err)
+		}
+		return false
+	}
+	if !changed {
+		return false
+	}
+	event.QueueState = domain.QueueStateDrained
+	s.queueStateChanged(ctx, event)
+	return true
+}
+
+// queueStateChanged сообщает подписчикам о новом состоянии очереди, а каждому ждущему своей
+// очереди — отдельным уведомлением. Сбой уведомлений не отменяет смену состояния
+func (s *EventQueueService) queueStateChanged(ctx context.Context, event *domain.Event) {
+	s.feed.Publish(QueueChange{EventID: event.ID})
+	queues, err := s.queueRepo.GetByEventID(ctx, event.ID)
+	if err != nil {
+		log.Printf("Failed to load queue of event %s: %v", event.ID, err)
+		return
+	}
+	var waiting []*domain.EventQueue
+	for _, queue := range queues {
+		if queue.Waiting() {
+			waiting = append(waiting, queue)
+		}
+	}
+	if err := s.estimate(ctx, event, waiting...); err != nil {
+		log.Printf("Failed to compute queue positions: %v", err)
+	}
+	for _, queue := range waiting {
+		s.notifyUser(ctx, "queue_state", event, queue)
+	}
+}
+
+// UpdateQueueSettings меняет ограничения очереди. Уже стоящих пользователей новые лимиты
+// не выгоняют, а освободившиеся места сразу достаются листу ожидания
+func (s *EventQueueService) UpdateQueueSettings(ctx context.Context, eventID, userID string, settings domain.QueueSettings) (*domain.QueueSettings, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	event, err := s.organizerEvent(ctx, eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+	// пишем только настройки, чтобы не затереть параллельную смену состояния очереди
+	if err := s.eventRepo.UpdateQueueSettings(ctx, eventID, settings); err != nil {
+		return nil, err
+	}
+	event.QueueSettings = settings
+	// смена полос меняет порядок вызова, подписчики пересчитывают свои места
+	s.feed.Publish(QueueChange{EventID: eventID})
+	s.promote(ctx, event)
+	return &event.QueueSettings, nil
+}
+
+// WatchQueue отправляет текущее состояние записи, а затем каждое его изменение.
+// Поток завершается, когда пользователь обслужен или вышел из очереди
+func (s *EventQueueService) WatchQueue(ctx context.Context, eventID, userID string, send func(*domain.QueueUpdate) error) error {
+	if _, err := s.event(ctx, eventID); err != nil {
+		return err
+	}
+	// подписываемся до первого чтения, чтобы не пропустить изменение между ними
+	changes, cancel := s.feed.Subscribe(eventID)
+	defer cancel()
+
+	var last *domain.QueueUpdate
+	for {
+		// событие перечитывается вместе с записью: место зависит от текущих настроек полос
+		event, err := s.eventRepo.GetByID(ctx, eventID)
+		if err != nil {
+			return err
+		}
+		queue, err := s.queueRepo.GetEntry(ctx, eventID, userID)
+		if err != nil {
+			return err
+		}
+		if err := s.estimate(ctx, event, queue); err != nil {
+			return err
+		}
+		update := s.update(event, queue)
+		if last == nil || update.Status != last.Status || update.Position != last.Position ||
+			update.EstimatedWait != last.EstimatedWait || update.QueueState != last.QueueState {
+			if err := send(update); err != nil {
+				return err
+			}
+			last = update
+		}
+		if queue.Finished() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changes:
+		}
+	}
+}
+
+func (s *EventQueueService) update(event *domain.Event, queue *domain.EventQueue) *domain.QueueUpdate {
+	return &domain.QueueUpdate{
+		QueueState:    event.QueueState,
+		EventID:       queue.EventID,
+		UserID:        queue.UserID,
+		Status:        queue.Status,
+		Position:      queue.Position,
+		EstimatedWait: queue.EstimatedWait,
+		Counter:       queue.Counter,
+		ExpiresAt:     queue.ExpiresAt,
+		UpdatedAt:     queue.UpdatedAt,
+	}
+}
+
+// promote переводит пользователей из листа ожидания на освободившиеся места и сообщает им об этом.
+// Сбой здесь не отменяет уже выполненное действие: перевод повторится при следующем изменении очереди
+func (s *EventQueueService) promote(ctx context.Context, event *domain.Event) {
+	promoted, err := s.queueRepo.Promote(ctx, event.ID, event.QueueSettings.MaxActive)
+	if err != nil {
+		log.Printf("Failed to promote waitlisted users: %v", err)
+		return
+	}
+	if err := s.estimate(ctx, event, promoted...); err != nil {
+		log.Printf("Failed to compute queue positions: %v", err)
+	}
+	for _, queue := range promoted {
+		s.publish(queue)
+		s.notifyUser(ctx, "queue_promoted", event, queue)
+	}
+}
+
+// notifyUser отправляет уведомление о записи в очереди её владельцу
+func (s *EventQueueService) notifyUser(ctx context.Context, notificationType string, event *domain.Event, queue *domain.EventQueue) {
+	if s.notifier == nil {
+		return
+	}
+	userID, err := strconv.Atoi(queue.UserID)
+	if err != nil {
+		log.Printf("Cannot notify queue user %q: %v", queue.UserID, err)
+		return
+	}
+	fields := map[string]interface{}{
+		"event_id": event.ID,
+		"title":    event.Title,
+		"status":   queue.Status,
+		"position": queue.Position,
+		// ждущие узнают, что очередь приостановлена или закрыта, из любого уведомления
+		"queue_state": event.QueueState,
+	}
+	if queue.Counter != "" {
+		fields["counter"] = queue.Counter
+	}
+	if queue.ExpiresAt != nil {
+		fields["expires_at"] = queue.ExpiresAt.Format(time.RFC3339)
+	}
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		log.Printf("Failed to marshal notification payload: %v", err)
+		return
+	}
+	if _, err := s.notifier.SendToUser(ctx, int32(userID), notificationType, payload); err != nil {
+		log.Printf("Failed to send notification: %v", err)
+	}
+}
+
+// estimate заменяет места ожидающих в их полосах на места в общем порядке вызова,
+// который предсказывает политика очереди, и оценивает ожидание по недавним обслуживаниям.
+// Лист ожидания дождётся всей живой очереди, поэтому его места считаются за ней
+func (s *EventQueueService) estimate(ctx context.Context, event *domain.Event, queues ...*domain.EventQueue) error {
+	var pending []*domain.EventQueue
+	for _, queue := range queues {
+		if queue.Waiting() {
+			pending = append(pending, queue)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	state, err := s.queueRepo.LaneState(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+	records, err := s.queueRepo.RecentServices(ctx, event.ID, serviceHistory)
+	if err != nil {
+		return err
+	}
+
+	order := event.QueueSettings.LanePositions(*state)
+	waiting := 0
+	for _, count := range state.Waiting {
+		waiting += count
+	}
+	counters, err := s.counters(ctx, event)
+	if err != nil {
+		return err
+	}
+	pace := domain.EstimateWait(records, counters)
+	for _, queue := range pending {
+		if queue.Status == string(domain.QueueStatusWaitlisted) {
+			queue.EstimatedWait = pace.For(waiting + queue.Position)
+			continue
+		}
+		// запись и состояние полос читаются не атомарно, место вне полосы оставляем как есть
+		if lane := order[queue.Lane]; queue.Position >= 1 && queue.Position <= len(lane) {
+			queue.Position = lane[queue.Position-1]
+		}
+		queue.EstimatedWait = pace.For(queue.Position)
+	}
+	return nil
 }
 
-func (s *EventQueueService) GetUserPosition(ctx context.Context, eventID, userID string) (int, error) {
-	return s.queueRepo.GetUserPosition(ctx, eventID, userID)
+// counters считает стойки, которые вызывают пользователей: настроенные в очереди,
+// а без настроенных — те, что сейчас кого-то обслуживают
+func (s *EventQueueService) counters(ctx context.Context, event *domain.Event) (int, error) {
+	if len(event.QueueSettings.Counters) > 0 {
+		return len(event.QueueSettings.Counters), nil
+	}
+	stats, err := s.queueRepo.CounterStats(ctx, event.ID)
+	if err != nil {
+		return 0, err
+	}
+	active := 0
+	for _, counter := range stats {
+		if counter.Serving != "" {
+			active++
+		}
+	}
+	return active, nil
 }
 
-func (s *EventQueueService) ProcessNext(ctx context.Context, eventID string) (*domain.EventQueue, error) {
-	return s.queueRepo.ProcessNext(ctx, eventID)
+func (s *EventQueueService) publish(queue *domain.EventQueue) {
+	s.feed.Publish(QueueChange{EventID: queue.EventID, UserID: queue.UserID, Status: queue.Status})
+}
+
+func (s *EventQueueService) event(ctx context.Context, eventID string) (*domain.Event, error) {
+	if err := validateEventID(eventID); err != nil {
+		return nil, err
+	}
+	return s.eventRepo.GetByID(ctx, eventID)
+}
+
+// organizerEvent загружает мероприятие для действия, доступного только его организатору
+func (s *EventQueueService) organizerEvent(ctx context.Context, eventID, userID string) (*domain.Event, error) {
+	event, err := s.event(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event.OrganizerID == "" || event.OrganizerID != userID {
+		return nil, domain.ErrNotOrganizer
+	}
+	return event, nil
 }
@@ -2,26 +2,101 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
 	"github.com/XRS0/ToTalkB/event_manager/internal/domain/gen"
 	"github.com/XRS0/ToTalkB/event_manager/internal/infrastructure/notification"
+
+	"github.com/google/uuid"
 )
 
 type EventService struct {
 	eventRepo domain.EventRepository
 	notifier  *notification.Client
+	handlers  map[string]EventHandler
+	closed    ClosedEventHandler
+}
+
+// EventHandler обрабатывает события определённого типа, поступившие через ProcessEvent
+type EventHandler interface {
+	Handle(event *domain.Event) error
+}
+
+// ClosedEventHandler узнаёт о завершении или отмене мероприятия, когда новое состояние уже сохранено
+type ClosedEventHandler interface {
+	EventClosed(ctx context.Context, event *domain.Event)
 }
 
 func NewEventService(repo domain.EventRepository, notifier *notification.Client) *EventService {
 	return &EventService{
 		eventRepo: repo,
 		notifier:  notifier,
+		handlers:  make(map[string]EventHandler),
+	}
+}
+
+// RegisterHandler задаёт обработчик для типа событий, вызывать до запуска сервера
+func (s *EventService) RegisterHandler(eventType string, handler EventHandler) {
+	s.handlers[eventType] = handler
+}
+
+// OnEventClosed задаёт, кому сообщать о завершении и отмене мероприятий, вызывать до запуска сервера
+func (s *EventService) OnEventClosed(handler ClosedEventHandler) {
+	s.closed = handler
+}
+
+// ProcessEvent сохраняет входящее событие и передаёт его обработчику его типа
+func (s *EventService) ProcessEvent(ctx context.Context, eventType, source string, payload []byte) (*domain.Event, error) {
+	if eventType == "" {
+		return nil, fmt.Errorf("%w: type is required", domain.ErrInvalidEvent)
+	}
+	if eventType == domain.EventTypeScheduled {
+		return nil, fmt.Errorf("%w: type %q is reserved for organizer events", domain.ErrInvalidEvent, eventType)
+	}
+	event := domain.NewEvent(eventType, source, payload)
+	if err := s.eventRepo.Save(ctx, event); err != nil {
+		return nil, fmt.Errorf("failed to save event: %w", err)
+	}
+	s.notify(ctx, "event_created", event)
+
+	handler, exists := s.handlers[event.Type]
+	if !exists {
+		return event, nil
+	}
+
+	event.Status = string(domain.StatusProcessed)
+	if err := handler.Handle(event); err != nil {
+		log.Printf("Failed to handle event %s: %v", event.ID, err)
+		event.Status = string(domain.StatusFailed)
+	}
+	event.UpdatedAt = time.Now()
+	if err := s.eventRepo.Update(ctx, event); err != nil {
+		return nil, fmt.Errorf("failed to update event status: %w", err)
+	}
+	return event, nil
+}
+
+// GetEventStatus возвращает входящее событие. Мероприятия организаторов сюда не попадают:
+// их видимость проверяют GetEvent и ListEvents
+func (s *EventService) GetEventStatus(ctx context.Context, id string) (*domain.Event, error) {
+	if err := validateEventID(id); err != nil {
+		return nil, err
 	}
+	event, err := s.eventRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event.Type == domain.EventTypeScheduled {
+		return nil, fmt.Errorf("%w: %s", domain.ErrEventNotFound, id)
+	}
+	return event, nil
 }
 
+// GetAllEvents возвращает все входящие события без мероприятий организаторов
 func (s *EventService) GetAllEvents(ctx context.Context) ([]*gen.Event, error) {
 	events, err := s.eventRepo.GetAll(ctx)
 	if err != nil {
@@ -30,16 +105,212 @@ func (s *EventService) GetAllEvents(ctx context.Context) ([]*gen.Event, error) {
 
 	result := make([]*gen.Event, 0, len(events))
 	for _, event := range events {
-		result = append(result, &gen.Event{
-			Id:        event.ID,
-			Type:      event.Type,
-			Source:    event.Source,
-			Payload:   event.Payload,
-			Status:    event.Status,
-			CreatedAt: event.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: event.UpdatedAt.Format(time.RFC3339),
-		})
+		if event.Type == domain.EventTypeScheduled {
+			continue
+		}
+		result = append(result, EventToProto(event))
 	}
 
 	return result, nil
 }
+
+// CreateEvent создаёт черновик мероприятия; опубликовать его можно через ChangeEventState
+func (s *EventService) CreateEvent(ctx context.Context, organizerID string, details domain.EventDetails) (*domain.Event, error) {
+	event, err := domain.NewScheduledEvent(organizerID, details)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.eventRepo.Save(ctx, event); err != nil {
+		return nil, fmt.Errorf("failed to save event: %w", err)
+	}
+	return event, nil
+}
+
+// UpdateEvent меняет данные мероприятия, доступно только организатору
+func (s *EventService) UpdateEvent(ctx context.Context, id, userID string, details domain.EventDetails) (*domain.Event, error) {
+	event, err := s.organizerEvent(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := event.Apply(details); err != nil {
+		return nil, err
+	}
+	if err := s.eventRepo.Update(ctx, event); err != nil {
+		return nil, fmt.Errorf("failed to update event: %w", err)
+	}
+	return event, nil
+}
+
+// GetEvent возвращает мероприятие. Черновик видит только организатор,
+// закрытое мероприятие доступно по идентификатору, но не попадает в списки
+func (s *EventService) GetEvent(ctx context.Context, id, userID string) (*domain.Event, error) {
+	if err := validateEventID(id); err != nil {
+		return nil, err
+	}
+	event, err := s.eventRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event.State == domain.StateDraft && event.OrganizerID != userID {
+		return nil, domain.ErrEventNotFound
+	}
+	return event, nil
+}
+
+func (s *EventService) ListEvents(ctx context.Context, userID string) ([]*domain.Event, error) {
+	events, err := s.eventRepo.ListVisible(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	return events, nil
+}
+
+// ChangeEventState переводит мероприятие по жизненному циклу и оповещает о публикации и отмене.
+// Завершение и отмена закрывают очередь, а стоящие в ней получают уведомление
+func (s *EventService) ChangeEventState(ctx context.Context, id, userID string, state domain.EventState) (*domain.Event, error) {
+	if _, err := s.organizerEvent(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	// переход проверяется в хранилище по текущему состоянию, а не по прочитанному выше
+	if err := s.eventRepo.TransitionEvent(ctx, id, state); err != nil {
+		return nil, err
+	}
+	event, err := s.eventRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if state == domain.StatePublished || state == domain.StateCancelled {
+		s.notify(ctx, "event_"+string(state), event)
+	}
+	if state.Closed() && s.closed != nil {
+		s.closed.EventClosed(ctx, event)
+	}
+	return event, nil
+}
+
+func (s *EventService) organizerEvent(ctx context.Context, id, userID string) (*domain.Event, error) {
+	if err := validateEventID(id); err != nil {
+		return nil, err
+	}
+	event, err := s.eventRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event.OrganizerID == "" || event.OrganizerID != userID {
+		return nil, domain.ErrNotOrganizer
+	}
+	return event, nil
+}
+
+func (s *EventService) notify(ctx context.Context, notificationType string, event *domain.Event) {
+	if s.notifier == nil {
+		return
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"event_id":  event.ID,
+		"type":      event.Type,
+		"source":    event.Source,
+		"title":     event.Title,
+		"state":     event.State,
+		"starts_at": event.StartsAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("Failed to marshal notification payload: %v", err)
+		return
+	}
+	if _, err := s.notifier.SendNotification(ctx, notificationType, payload); err != nil {
+		log.Printf("Failed to send notification: %v", err)
+	}
+}
+
+// validateEventID отсекает идентификаторы, которых не может быть в таблице events
+func validateEventID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrEventNotFound, id)
+	}
+	return nil
+}
+
+// EventToProto переводит мероприятие в сообщение gRPC
+func EventToProto(event *domain.Event) *gen.Event {
+	return &gen.Event{
+		Id:            event.ID,
+		Type:          event.Type,
+		Source:        event.Source,
+		Payload:       event.Payload,
+		Status:        event.Status,
+		CreatedAt:     event.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     event.UpdatedAt.Format(time.RFC3339),
+		Title:         event.Title,
+		Description:   event.Description,
+		Venue:         event.Venue,
+		StartsAt:      event.StartsAt.Format(time.RFC3339),
+		EndsAt:        event.EndsAt.Format(time.RFC3339),
+		Timezone:      event.Timezone,
+		Capacity:      int32(event.Capacity),
+		OrganizerId:   event.OrganizerID,
+		Visibility:    string(event.Visibility),
+		State:         string(event.State),
+		QueueState:    string(event.QueueState),
+		QueueSettings: QueueSettingsToProto(event.QueueSettings),
+	}
+}
+
+func QueueSettingsToProto(settings domain.QueueSettings) *gen.QueueSettings {
+	lanes := make([]*gen.QueueLane, len(settings.Lanes))
+	for i, lane := range settings.Lanes {
+		lanes[i] = &gen.QueueLane{Name: lane.Name, Weight: int32(lane.Weight)}
+	}
+	return &gen.QueueSettings{
+		MaxActive:          int32(settings.MaxActive),
+		MaxTotal:           int32(settings.MaxTotal),
+		MaxPerUser:         int32(settings.MaxPerUser),
+		TurnTimeoutSeconds: int32(settings.TurnTimeout / time.Second),
+		Policy:             string(settings.Policy),
+		Lanes:              lanes,
+		Counters:           settings.Counters,
+	}
+}
+
+// QueueSettingsFromProto разбирает ограничения очереди; отсутствующие настройки снимают ограничения
+func QueueSettingsFromProto(settings *gen.QueueSettings) domain.QueueSettings {
+	var lanes []domain.QueueLane
+	for _, lane := range settings.GetLanes() {
+		lanes = append(lanes, domain.QueueLane{Name: lane.Name, Weight: int(lane.Weight)})
+	}
+	return domain.QueueSettings{
+		MaxActive:   int(settings.GetMaxActive()),
+		MaxTotal:    int(settings.GetMaxTotal()),
+		MaxPerUser:  int(settings.GetMaxPerUser()),
+		TurnTimeout: time.Duration(settings.GetTurnTimeoutSeconds()) * time.Second,
+		Policy:      domain.QueuePolicy(settings.GetPolicy()),
+		Lanes:       lanes,
+		Counters:    settings.GetCounters(),
+	}
+}
+
+// EventDetailsFromProto разбирает редактируемые поля мероприятия из запроса gRPC
+func EventDetailsFromProto(details *gen.EventDetails) (domain.EventDetails, error) {
+	if details == nil {
+		return domain.EventDetails{}, fmt.Errorf("%w: details are required", domain.ErrInvalidEvent)
+	}
+	startsAt, err := time.Parse(time.RFC3339, details.StartsAt)
+	if err != nil {
+		return domain.EventDetails{}, fmt.Errorf("%w: invalid starts_at", domain.ErrInvalidEvent)
+	}
+	endsAt, err := time.Parse(time.RFC3339, details.EndsAt)
+	if err != nil {
+		return domain.EventDetails{}, fmt.Errorf("%w: invalid ends_at", domain.ErrInvalidEvent)
+	}
+	return domain.EventDetails{
+		Title:       details.Title,
+		Description: details.Description,
+		Venue:       details.Venue,
+		StartsAt:    startsAt,
+		EndsAt:      endsAt,
+		Timezone:    details.Timezone,
+		Capacity:    int(details.Capacity),
+		Visibility:  domain.Visibility(details.Visibility),
+	}, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"sync"
+)
+
+// feedBuffer — сколько непрочитанных изменений держит один подписчик
+const feedBuffer = 16
+
+// QueueChange сообщает подписчикам, что очередь события изменилась
+type QueueChange struct {
+	EventID string
+	UserID  string
+	Status  string
+}
+
+// QueueFeed — внутренняя лента изменений очередей в пределах процесса.
+// Каждое изменение очереди публикуется сюда, подписчики перечитывают своё состояние
+type QueueFeed struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan QueueChange]struct{}
+}
+
+func NewQueueFeed() *QueueFeed {
+	return &QueueFeed{
+		subscribers: make(map[string]map[chan QueueChange]struct{}),
+	}
+}
+
+// Subscribe подписывает на изменения очереди события; cancel нужно вызвать после использования
+func (f *QueueFeed) Subscribe(eventID string) (<-chan QueueChange, func()) {
+	ch := make(chan QueueChange, feedBuffer)
+
+	f.mu.Lock()
+	if f.subscribers[eventID] == nil {
+		f.subscribers[eventID] = make(map[chan QueueChange]struct{})
+	}
+	f.subscribers[eventID][ch] = struct{}{}
+	f.mu.Unlock()
+
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.subscribers[eventID], ch)
+		if len(f.subscribers[eventID]) == 0 {
+			delete(f.subscribers, eventID)
+		}
+	}
+}
+
+// Publish рассылает изменение подписчикам очереди, не блокируясь на медленных.
+// Пропуск безопасен: заполненный буфер и так разбудит подписчика, а перечитает он свежее состояние
+func (f *QueueFeed) Publish(change QueueChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subscribers[change.EventID] {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	turnCheckInterval = 15 * time.Second
+	turnBatchSize     = 100
+)
+
+// TurnScheduler в фоне отмечает неявившимися вызванных пользователей, чей срок истёк,
+// и вызывает вместо них следующих
+type TurnScheduler struct {
+	queues   *EventQueueService
+	interval time.Duration
+}
+
+func NewTurnScheduler(queues *EventQueueService) *TurnScheduler {
+	return &TurnScheduler{queues: queues, interval: turnCheckInterval}
+}
+
+// Run проверяет сроки раз в interval до отмены ctx
+func (s *TurnScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.expire(ctx)
+		}
+	}
+}
+
+// expire разбирает просрочки пачками, пока они не кончатся
+func (s *TurnScheduler) expire(ctx context.Context) {
+	for {
+		expired, err := s.queues.ExpireTurns(ctx, time.Now(), turnBatchSize)
+		if err != nil {
+			log.Printf("Failed to expire queue turns: %v", err)
+			return
+		}
+		if expired < turnBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// ServiceRecord — одно завершённое обслуживание: у какой стойки и сколько оно длилось
+type ServiceRecord struct {
+	Counter     string        `json:"counter,omitempty"`
+	Duration    time.Duration `json:"duration"`
+	CompletedAt time.Time     `json:"completed_at"`
+}
+
+// WaitEstimate — темп очереди, по которому оценивается ожидание
+type WaitEstimate struct {
+	// Service — скользящее среднее длительности обслуживания
+	Service time.Duration
+	// Counters — сколько стоек обслуживают очередь параллельно, не меньше 1
+	Counters int
+}
+
+// EstimateWait вычисляет темп очереди по недавним обслуживаниям и числу стоек counters,
+// которые вызывают пользователей параллельно; меньше одной стойки не бывает
+func EstimateWait(records []ServiceRecord, counters int) WaitEstimate {
+	counters = max(counters, 1)
+	if len(records) == 0 {
+		return WaitEstimate{Counters: counters}
+	}
+	var total time.Duration
+	for _, record := range records {
+		total += record.Duration
+	}
+	return WaitEstimate{
+		Service:  total / time.Duration(len(records)),
+		Counters: counters,
+	}
+}
+
+// For оценивает ожидание пользователя на месте position: стойки вызывают людей
+// параллельно, поэтому перед ним пройдёт ceil(position / Counters) кругов обслуживания.
+// Пока обслуживаний не было, оценки нет
+func (e WaitEstimate) For(position int) time.Duration {
+	if position <= 0 || e.Service <= 0 {
+		return 0
+	}
+	counters := max(e.Counters, 1)
+	rounds := (position + counters - 1) / counters
+	return time.Duration(rounds) * e.Service
+}
@@ -0,0 +1,255 @@
+package grpc_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain/gen"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serveAll вызывает следующего из параллельных обработчиков, пока очередь не опустеет,
+// и возвращает, сколько раз был вызван каждый пользователь
+func serveAll(t *testing.T, c clients, eventID string, operators int) map[string]int {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		served = make(map[string]int)
+		wg     sync.WaitGroup
+	)
+	for i := 0; i < operators; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				next, err := c.queues.ProcessNext(context.Background(), &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+				if status.Code(err) == codes.FailedPrecondition {
+					return
+				}
+				if err != nil {
+					t.Errorf("process next: %v", err)
+					return
+				}
+				mu.Lock()
+				served[next.Queue.UserId]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return served
+}
+
+// requireConsistentQueue проверяет, что у ожидающих позиции идут подряд с 1 без повторов,
+// и возвращает их в порядке очереди
+func requireConsistentQueue(t *testing.T, c clients, eventID string) []*gen.EventQueue {
+	t.Helper()
+	queueStatus, err := c.queues.GetQueueStatus(context.Background(), &gen.GetQueueStatusRequest{EventId: eventID})
+	requireOK(t, err)
+
+	users := make(map[string]bool)
+	var waiting []*gen.EventQueue
+	for _, q := range queueStatus.Queues {
+		if users[q.UserId] {
+			t.Fatalf("user %s has several queue entries", q.UserId)
+		}
+		users[q.UserId] = true
+		if q.Status != string(domain.QueueStatusWaiting) {
+			if q.Position != 0 {
+				t.Fatalf("%s entry of %s has position %d", q.Status, q.UserId, q.Position)
+			}
+			continue
+		}
+		waiting = append(waiting, q)
+		if q.Position != int32(len(waiting)) {
+			t.Fatalf("expected %s at position %d, got %d", q.UserId, len(waiting), q.Position)
+		}
+	}
+	return waiting
+}
+
+func TestConcurrentJoin(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		const users = 50
+		eventID := publishedEvent(t, c, "1")
+
+		positions := make([]int32, users)
+		var wg sync.WaitGroup
+		for i := 0; i < users; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				joined, err := c.queues.JoinQueue(context.Background(), &gen.JoinQueueRequest{EventId: eventID, UserId: fmt.Sprintf("u%d", i)})
+				if err != nil {
+					t.Errorf("join u%d: %v", i, err)
+					return
+				}
+				positions[i] = joined.Position
+			}(i)
+		}
+		wg.Wait()
+
+		seen := make(map[int32]bool)
+		for i, position := range positions {
+			if position < 1 || position > users || seen[position] {
+				t.Fatalf("u%d got invalid or duplicate position %d", i, position)
+			}
+			seen[position] = true
+		}
+		if waiting := requireConsistentQueue(t, c, eventID); len(waiting) != users {
+			t.Fatalf("expected %d waiting, got %d", users, len(waiting))
+		}
+	})
+}
+
+func TestConcurrentDuplicateJoin(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		const attempts = 20
+		eventID := publishedEvent(t, c, "1")
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			accepted int
+		)
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := c.queues.JoinQueue(context.Background(), &gen.JoinQueueRequest{EventId: eventID, UserId: "same"})
+				switch status.Code(err) {
+				case codes.OK:
+					mu.Lock()
+					accepted++
+					mu.Unlock()
+				case codes.AlreadyExists:
+				default:
+					t.Errorf("join: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if accepted != 1 {
+			t.Fatalf("expected exactly one accepted join, got %d", accepted)
+		}
+		requireConsistentQueue(t, c, eventID)
+	})
+}
+
+func TestConcurrentProcessNext(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		const users = 40
+		eventID := publishedEvent(t, c, "1")
+		for i := 0; i < users; i++ {
+			_, err := c.queues.JoinQueue(context.Background(), &gen.JoinQueueRequest{EventId: eventID, UserId: fmt.Sprintf("u%d", i)})
+			requireOK(t, err)
+		}
+
+		served := serveAll(t, c, eventID, 8)
+		if len(served) != users {
+			t.Fatalf("expected %d users served, got %d", users, len(served))
+		}
+		for user, times := range served {
+			if times != 1 {
+				t.Fatalf("%s was handed out %d times", user, times)
+			}
+		}
+		if waiting := requireConsistentQueue(t, c, eventID); len(waiting) != 0 {
+			t.Fatalf("expected empty queue, got %d waiting", len(waiting))
+		}
+	})
+}
+
+func TestConcurrentQueueMix(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		const initial, joiners, leavers = 30, 30, 15
+		ctx := context.Background()
+		eventID := publishedEvent(t, c, "1")
+		for i := 0; i < initial; i++ {
+			_, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: fmt.Sprintf("u%d", i)})
+			requireOK(t, err)
+		}
+
+		var (
+			mu     sync.Mutex
+			served = make(map[string]int)
+			wg     sync.WaitGroup
+		)
+		for i := 0; i < joiners; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: fmt.Sprintf("n%d", i)})
+				if err != nil {
+					t.Errorf("join n%d: %v", i, err)
+				}
+			}(i)
+		}
+		// уходят пользователи из конца очереди, их как раз могут вызывать параллельно
+		for i := initial - leavers; i < initial; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := c.queues.LeaveQueue(ctx, &gen.LeaveQueueRequest{EventId: eventID, UserId: fmt.Sprintf("u%d", i)})
+				if err != nil {
+					t.Errorf("leave u%d: %v", i, err)
+				}
+			}(i)
+		}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 5; j++ {
+					next, err := c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+					if status.Code(err) == codes.FailedPrecondition {
+						continue
+					}
+					if err != nil {
+						t.Errorf("process next: %v", err)
+						return
+					}
+					mu.Lock()
+					served[next.Queue.UserId]++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		for user, times := range served {
+			if times != 1 {
+				t.Fatalf("%s was handed out %d times", user, times)
+			}
+		}
+		waiting := requireConsistentQueue(t, c, eventID)
+
+		queueStatus, err := c.queues.GetQueueStatus(ctx, &gen.GetQueueStatusRequest{EventId: eventID})
+		requireOK(t, err)
+		if len(queueStatus.Queues) != initial+joiners {
+			t.Fatalf("expected %d entries, got %d", initial+joiners, len(queueStatus.Queues))
+		}
+		for _, q := range queueStatus.Queues {
+			if served[q.UserId] > 0 && q.Status == string(domain.QueueStatusWaiting) {
+				t.Fatalf("served user %s is still waiting", q.UserId)
+			}
+		}
+
+		// всё, что осталось, разбирается без потерь и повторов
+		rest := serveAll(t, c, eventID, 4)
+		if len(rest) != len(waiting) {
+			t.Fatalf("expected %d users served, got %d", len(waiting), len(rest))
+		}
+		for user, times := range rest {
+			if times != 1 || served[user] > 0 {
+				t.Fatalf("%s was handed out more than once", user)
+			}
+		}
+	})
+}
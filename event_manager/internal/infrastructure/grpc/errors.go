@@ -0,0 +1,40 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError переводит ошибки сервисов в коды gRPC
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrEventNotFound), errors.Is(err, domain.ErrNotInQueue):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrInvalidEvent), errors.Is(err, domain.ErrInvalidCheckIn):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrAlreadyInQueue):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, domain.ErrEventClosed),
+		errors.Is(err, domain.ErrQueueClosed), errors.Is(err, domain.ErrQueueEmpty), errors.Is(err, domain.ErrNotActive),
+		errors.Is(err, domain.ErrNotYourTurn), errors.Is(err, domain.ErrCounterBusy), errors.Is(err, domain.ErrNotWaiting),
+		errors.Is(err, domain.ErrQueueArchived), errors.Is(err, domain.ErrInvalidQueueTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrQueuePaused):
+		// пауза временная: клиент может повторить запрос позже
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, domain.ErrQueueFull), errors.Is(err, domain.ErrJoinLimit), errors.Is(err, domain.ErrEventFull):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, domain.ErrNotOrganizer):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -2,11 +2,13 @@ package grpc
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
 	"github.com/XRS0/ToTalkB/event_manager/internal/domain/gen"
 	"github.com/XRS0/ToTalkB/event_manager/internal/domain/services"
+	"github.com/XRS0/ToTalkB/event_manager/internal/infrastructure/qr"
 )
 
 type EventQueueServer struct {
@@ -19,21 +21,70 @@ func NewEventQueueServer(service *services.EventQueueService) *EventQueueServer
 }
 
 func toProtoEventQueue(queue *domain.EventQueue) *gen.EventQueue {
+	if queue == nil {
+		return nil
+	}
 	return &gen.EventQueue{
-		Id:        queue.ID,
-		EventId:   queue.EventID,
-		UserId:    queue.UserID,
-		Status:    queue.Status,
-		Position:  int32(queue.Position),
-		CreatedAt: queue.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: queue.UpdatedAt.Format(time.RFC3339),
+		Id:                   queue.ID,
+		EventId:              queue.EventID,
+		UserId:               queue.UserID,
+		Status:               queue.Status,
+		Position:             int32(queue.Position),
+		CreatedAt:            queue.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:            queue.UpdatedAt.Format(time.RFC3339),
+		ExpiresAt:            formatOptionalTime(queue.ExpiresAt),
+		Lane:                 queue.Lane,
+		CounterId:            queue.Counter,
+		CalledAt:             formatOptionalTime(queue.CalledAt),
+		EstimatedWaitSeconds: waitSeconds(queue.EstimatedWait),
+	}
+}
+
+// waitSeconds округляет оценку ожидания до целых секунд вверх, чтобы ненулевая оценка не стала нулём
+func waitSeconds(wait time.Duration) int64 {
+	return int64((wait + time.Second - 1) / time.Second)
+}
+
+// formatOptionalTime форматирует необязательное время; отсутствующее становится пустой строкой
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (s *EventQueueServer) JoinQueue(ctx context.Context, req *gen.JoinQueueRequest) (*gen.JoinQueueResponse, error) {
+	queue, err := s.service.JoinQueue(ctx, req.EventId, req.UserId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.JoinQueueResponse{
+		QueueId:              queue.ID,
+		Position:             int32(queue.Position),
+		Status:               queue.Status,
+		EstimatedWaitSeconds: waitSeconds(queue.EstimatedWait),
+	}, nil
+}
+
+func (s *EventQueueServer) AssignLane(ctx context.Context, req *gen.AssignLaneRequest) (*gen.AssignLaneResponse, error) {
+	queue, err := s.service.AssignLane(ctx, req.EventId, req.UserId, req.Lane, req.OperatorId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.AssignLaneResponse{Queue: toProtoEventQueue(queue)}, nil
+}
+
+func (s *EventQueueServer) LeaveQueue(ctx context.Context, req *gen.LeaveQueueRequest) (*gen.LeaveQueueResponse, error) {
+	if err := s.service.LeaveQueue(ctx, req.EventId, req.UserId); err != nil {
+		return nil, statusError(err)
 	}
+	return &gen.LeaveQueueResponse{Success: true}, nil
 }
 
 func (s *EventQueueServer) GetQueueStatus(ctx context.Context, req *gen.GetQueueStatusRequest) (*gen.GetQueueStatusResponse, error) {
 	queues, err := s.service.GetQueueStatus(ctx, req.EventId)
 	if err != nil {
-		return nil, err
+		return nil, statusError(err)
 	}
 
 	protoQueues := make([]*gen.EventQueue, len(queues))
@@ -44,17 +95,143 @@ func (s *EventQueueServer) GetQueueStatus(ctx context.Context, req *gen.GetQueue
 }
 
 func (s *EventQueueServer) GetUserPosition(ctx context.Context, req *gen.GetUserPositionRequest) (*gen.GetUserPositionResponse, error) {
-	position, err := s.service.GetUserPosition(ctx, req.EventId, req.UserId)
+	queue, err := s.service.GetUserPosition(ctx, req.EventId, req.UserId)
 	if err != nil {
-		return nil, err
+		return nil, statusError(err)
 	}
-	return &gen.GetUserPositionResponse{Position: int32(position)}, nil
+	return &gen.GetUserPositionResponse{
+		Position:             int32(queue.Position),
+		Status:               queue.Status,
+		EstimatedWaitSeconds: waitSeconds(queue.EstimatedWait),
+	}, nil
 }
 
 func (s *EventQueueServer) ProcessNext(ctx context.Context, req *gen.ProcessNextRequest) (*gen.ProcessNextResponse, error) {
-	queue, err := s.service.ProcessNext(ctx, req.EventId)
+	queue, err := s.service.ProcessNext(ctx, req.EventId, req.CounterId, req.UserId)
 	if err != nil {
-		return nil, err
+		return nil, statusError(err)
 	}
 	return &gen.ProcessNextResponse{Queue: toProtoEventQueue(queue)}, nil
 }
+
+func (s *EventQueueServer) CloseQueue(ctx context.Context, req *gen.CloseQueueRequest) (*gen.CloseQueueResponse, error) {
+	state, err := s.service.CloseQueue(ctx, req.EventId, req.UserId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.CloseQueueResponse{Success: true, State: string(state)}, nil
+}
+
+func (s *EventQueueServer) PauseQueue(ctx context.Context, req *gen.QueueStateRequest) (*gen.QueueStateResponse, error) {
+	return queueStateResponse(s.service.PauseQueue(ctx, req.EventId, req.UserId))
+}
+
+func (s *EventQueueServer) ResumeQueue(ctx context.Context, req *gen.QueueStateRequest) (*gen.QueueStateResponse, error) {
+	return queueStateResponse(s.service.ResumeQueue(ctx, req.EventId, req.UserId))
+}
+
+func (s *EventQueueServer) ReopenQueue(ctx context.Context, req *gen.QueueStateRequest) (*gen.QueueStateResponse, error) {
+	return queueStateResponse(s.service.ReopenQueue(ctx, req.EventId, req.UserId))
+}
+
+func (s *EventQueueServer) ArchiveQueue(ctx context.Context, req *gen.QueueStateRequest) (*gen.QueueStateResponse, error) {
+	return queueStateResponse(s.service.ArchiveQueue(ctx, req.EventId, req.UserId))
+}
+
+func queueStateResponse(state domain.QueueState, err error) (*gen.QueueStateResponse, error) {
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.QueueStateResponse{State: string(state)}, nil
+}
+
+func (s *EventQueueServer) WatchQueue(req *gen.WatchQueueRequest, stream gen.EventQueueService_WatchQueueServer) error {
+	err := s.service.WatchQueue(stream.Context(), req.EventId, req.UserId, func(update *domain.QueueUpdate) error {
+		return stream.Send(&gen.QueueUpdate{
+			EventId:              update.EventID,
+			UserId:               update.UserID,
+			Status:               update.Status,
+			Position:             int32(update.Position),
+			EstimatedWaitSeconds: waitSeconds(update.EstimatedWait),
+			UpdatedAt:            update.UpdatedAt.Format(time.RFC3339),
+			ExpiresAt:            formatOptionalTime(update.ExpiresAt),
+			CounterId:            update.Counter,
+			QueueState:           string(update.QueueState),
+		})
+	})
+	if err != nil {
+		return statusError(err)
+	}
+	return nil
+}
+
+func (s *EventQueueServer) UpdateQueueSettings(ctx context.Context, req *gen.UpdateQueueSettingsRequest) (*gen.QueueSettings, error) {
+	settings, err := s.service.UpdateQueueSettings(ctx, req.EventId, req.UserId, services.QueueSettingsFromProto(req.Settings))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return services.QueueSettingsToProto(*settings), nil
+}
+
+func (s *EventQueueServer) CompleteEntry(ctx context.Context, req *gen.CompleteEntryRequest) (*gen.CompleteEntryResponse, error) {
+	queue, err := s.service.CompleteEntry(ctx, req.EventId, req.UserId, req.OperatorId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.CompleteEntryResponse{Queue: toProtoEventQueue(queue)}, nil
+}
+
+func (s *EventQueueServer) SkipEntry(ctx context.Context, req *gen.SkipEntryRequest) (*gen.SkipEntryResponse, error) {
+	skipped, next, err := s.service.SkipEntry(ctx, req.EventId, req.UserId, req.OperatorId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.SkipEntryResponse{Skipped: toProtoEventQueue(skipped), Next: toProtoEventQueue(next)}, nil
+}
+
+func (s *EventQueueServer) GetCheckInToken(ctx context.Context, req *gen.GetCheckInTokenRequest) (*gen.GetCheckInTokenResponse, error) {
+	token, err := s.service.CheckInToken(ctx, req.EventId, req.UserId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	image, err := qr.Render(token)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.GetCheckInTokenResponse{Token: token, QrImage: image}, nil
+}
+
+// CheckIn принимает токен напрямую или распознаёт его на фотографии QR-кода
+func (s *EventQueueServer) CheckIn(ctx context.Context, req *gen.CheckInRequest) (*gen.CheckInResponse, error) {
+	token := req.Token
+	if token == "" && len(req.Photo) > 0 {
+		scanned, err := qr.Scan(req.Photo)
+		if err != nil {
+			return nil, statusError(fmt.Errorf("%w: %v", domain.ErrInvalidCheckIn, err))
+		}
+		token = scanned
+	}
+	queue, err := s.service.CheckIn(ctx, token, req.UserId, req.CounterId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.CheckInResponse{Queue: toProtoEventQueue(queue)}, nil
+}
+
+func (s *EventQueueServer) GetCounterStats(ctx context.Context, req *gen.GetCounterStatsRequest) (*gen.GetCounterStatsResponse, error) {
+	counters, err := s.service.CounterStats(ctx, req.EventId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	protoCounters := make([]*gen.CounterStats, len(counters))
+	for i, stats := range counters {
+		protoCounters[i] = &gen.CounterStats{
+			CounterId:             stats.Counter,
+			ServingUserId:         stats.Serving,
+			Served:                int32(stats.Served),
+			NoShows:               int32(stats.NoShows),
+			AverageServiceSeconds: int64(stats.AverageService / time.Second),
+		}
+	}
+	return &gen.GetCounterStatsResponse{Counters: protoCounters}, nil
+}
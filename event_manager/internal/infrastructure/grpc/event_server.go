@@ -2,7 +2,9 @@ package grpc
 
 import (
 	"context"
+	"time"
 
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
 	"github.com/XRS0/ToTalkB/event_manager/internal/domain/gen"
 	"github.com/XRS0/ToTalkB/event_manager/internal/domain/services"
 )
@@ -16,10 +18,85 @@ func NewEventServer(service *services.EventService) *EventServer {
 	return &EventServer{service: service}
 }
 
+func (s *EventServer) ProcessEvent(ctx context.Context, req *gen.ProcessEventRequest) (*gen.ProcessEventResponse, error) {
+	event, err := s.service.ProcessEvent(ctx, req.Type, req.Source, req.Payload)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.ProcessEventResponse{Id: event.ID, Status: event.Status}, nil
+}
+
+func (s *EventServer) GetEventStatus(ctx context.Context, req *gen.GetEventStatusRequest) (*gen.GetEventStatusResponse, error) {
+	event, err := s.service.GetEventStatus(ctx, req.Id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.GetEventStatusResponse{
+		Id:        event.ID,
+		Type:      event.Type,
+		Source:    event.Source,
+		Status:    event.Status,
+		CreatedAt: event.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: event.UpdatedAt.Format(time.RFC3339),
+	}, nil
+}
+
 func (s *EventServer) GetAllEvents(ctx context.Context, req *gen.GetAllEventsRequest) (*gen.GetAllEventsResponse, error) {
 	events, err := s.service.GetAllEvents(ctx)
 	if err != nil {
-		return nil, err
+		return nil, statusError(err)
 	}
 	return &gen.GetAllEventsResponse{Events: events}, nil
 }
+
+func (s *EventServer) CreateEvent(ctx context.Context, req *gen.CreateEventRequest) (*gen.Event, error) {
+	details, err := services.EventDetailsFromProto(req.Details)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	event, err := s.service.CreateEvent(ctx, req.OrganizerId, details)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return services.EventToProto(event), nil
+}
+
+func (s *EventServer) UpdateEvent(ctx context.Context, req *gen.UpdateEventRequest) (*gen.Event, error) {
+	details, err := services.EventDetailsFromProto(req.Details)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	event, err := s.service.UpdateEvent(ctx, req.Id, req.UserId, details)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return services.EventToProto(event), nil
+}
+
+func (s *EventServer) GetEvent(ctx context.Context, req *gen.GetEventRequest) (*gen.Event, error) {
+	event, err := s.service.GetEvent(ctx, req.Id, req.UserId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return services.EventToProto(event), nil
+}
+
+func (s *EventServer) ListEvents(ctx context.Context, req *gen.ListEventsRequest) (*gen.ListEventsResponse, error) {
+	events, err := s.service.ListEvents(ctx, req.UserId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	response := &gen.ListEventsResponse{Events: make([]*gen.Event, len(events))}
+	for i, event := range events {
+		response.Events[i] = services.EventToProto(event)
+	}
+	return response, nil
+}
+
+func (s *EventServer) ChangeEventState(ctx context.Context, req *gen.ChangeEventStateRequest) (*gen.Event, error) {
+	event, err := s.service.ChangeEventState(ctx, req.Id, req.UserId, domain.EventState(req.State))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return services.EventToProto(event), nil
+}
@@ -0,0 +1,1033 @@
+package grpc_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain/gen"
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain/services"
+	grpcImpl "github.com/XRS0/ToTalkB/event_manager/internal/infrastructure/grpc"
+	"github.com/XRS0/ToTalkB/event_manager/internal/infrastructure/persistence/memory"
+	"github.com/XRS0/ToTalkB/event_manager/internal/infrastructure/persistence/postgres"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testDSNEnv — строка подключения к Postgres с применёнными миграциями из /schema.
+// Без неё тесты гоняются только на репозиториях в памяти
+const testDSNEnv = "EVENT_MANAGER_TEST_DSN"
+
+type clients struct {
+	events gen.EventServiceClient
+	queues gen.EventQueueServiceClient
+	// queueService даёт тестам запускать фоновые задачи, которые не доступны через gRPC
+	queueService *services.EventQueueService
+}
+
+type backend struct {
+	name  string
+	repos func(t *testing.T) (domain.EventRepository, domain.EventQueueRepository)
+}
+
+func backends() []backend {
+	result := []backend{{
+		name: "memory",
+		repos: func(t *testing.T) (domain.EventRepository, domain.EventQueueRepository) {
+			return memory.NewInMemoryEventRepository(), memory.NewInMemoryEventQueueRepository()
+		},
+	}}
+	if dsn := os.Getenv(testDSNEnv); dsn != "" {
+		result = append(result, backend{
+			name: "postgres",
+			repos: func(t *testing.T) (domain.EventRepository, domain.EventQueueRepository) {
+				db := openTestDB(t, dsn)
+				return postgres.NewEventRepository(db), postgres.NewEventQueueRepository(db)
+			},
+		})
+	}
+	return result
+}
+
+func openTestDB(t *testing.T, dsn string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec("TRUNCATE event_queues, events CASCADE"); err != nil {
+		t.Fatalf("truncate tables: %v", err)
+	}
+	return db
+}
+
+// startServer поднимает gRPC-сервер так же, как main, но поверх bufconn
+func startServer(t *testing.T, eventRepo domain.EventRepository, queueRepo domain.EventQueueRepository) clients {
+	t.Helper()
+
+	eventService := services.NewEventService(eventRepo, nil)
+	signer, err := services.NewCheckInSigner("test-secret-for-check-in-tokens!")
+	if err != nil {
+		t.Fatalf("create signer: %v", err)
+	}
+	queueService := services.NewEventQueueService(queueRepo, eventRepo, nil, signer)
+	eventService.OnEventClosed(queueService)
+
+	server := grpc.NewServer()
+	gen.RegisterEventServiceServer(server, grpcImpl.NewEventServer(eventService))
+	gen.RegisterEventQueueServiceServer(server, grpcImpl.NewEventQueueServer(queueService))
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return clients{
+		events:       gen.NewEventServiceClient(conn),
+		queues:       gen.NewEventQueueServiceClient(conn),
+		queueService: queueService,
+	}
+}
+
+func forEachBackend(t *testing.T, test func(t *testing.T, c clients)) {
+	for _, b := range backends() {
+		t.Run(b.name, func(t *testing.T) {
+			eventRepo, queueRepo := b.repos(t)
+			test(t, startServer(t, eventRepo, queueRepo))
+		})
+	}
+}
+
+func requireCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != code {
+		t.Fatalf("expected code %s, got %s (%v)", code, got, err)
+	}
+}
+
+func requireOK(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func eventDetails(title string) *gen.EventDetails {
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	return &gen.EventDetails{
+		Title:      title,
+		Venue:      "Main hall",
+		StartsAt:   start.Format(time.RFC3339),
+		EndsAt:     start.Add(2 * time.Hour).Format(time.RFC3339),
+		Timezone:   "Europe/Moscow",
+		Capacity:   100,
+		Visibility: string(domain.VisibilityPublic),
+	}
+}
+
+// publishedEvent создаёт и публикует мероприятие, в очередь которого можно вставать
+func publishedEvent(t *testing.T, c clients, organizer string) string {
+	t.Helper()
+	ctx := context.Background()
+	event, err := c.events.CreateEvent(ctx, &gen.CreateEventRequest{OrganizerId: organizer, Details: eventDetails("Open day")})
+	requireOK(t, err)
+	_, err = c.events.ChangeEventState(ctx, &gen.ChangeEventStateRequest{Id: event.Id, UserId: organizer, State: string(domain.StatePublished)})
+	requireOK(t, err)
+	return event.Id
+}
+
+func TestProcessEvent(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx := context.Background()
+
+		processed, err := c.events.ProcessEvent(ctx, &gen.ProcessEventRequest{Type: "signup", Source: "web", Payload: []byte(`{"a":1}`)})
+		requireOK(t, err)
+		if processed.Id == "" || processed.Status != string(domain.StatusPending) {
+			t.Fatalf("unexpected response: %+v", processed)
+		}
+
+		got, err := c.events.GetEventStatus(ctx, &gen.GetEventStatusRequest{Id: processed.Id})
+		requireOK(t, err)
+		if got.Type != "signup" || got.Source != "web" || got.Status != processed.Status {
+			t.Fatalf("unexpected status: %+v", got)
+		}
+
+		all, err := c.events.GetAllEvents(ctx, &gen.GetAllEventsRequest{})
+		requireOK(t, err)
+		if len(all.Events) != 1 || all.Events[0].Id != processed.Id {
+			t.Fatalf("expected the processed event in GetAllEvents, got %+v", all.Events)
+		}
+
+		_, err = c.events.ProcessEvent(ctx, &gen.ProcessEventRequest{Source: "web"})
+		requireCode(t, err, codes.InvalidArgument)
+		_, err = c.events.ProcessEvent(ctx, &gen.ProcessEventRequest{Type: domain.EventTypeScheduled, Source: "web"})
+		requireCode(t, err, codes.InvalidArgument)
+
+		// черновики и закрытые мероприятия не видны через API входящих событий
+		draft, err := c.events.CreateEvent(ctx, &gen.CreateEventRequest{OrganizerId: "1", Details: eventDetails("Draft")})
+		requireOK(t, err)
+		_, err = c.events.GetEventStatus(ctx, &gen.GetEventStatusRequest{Id: draft.Id})
+		requireCode(t, err, codes.NotFound)
+		all, err = c.events.GetAllEvents(ctx, &gen.GetAllEventsRequest{})
+		requireOK(t, err)
+		if len(all.Events) != 1 {
+			t.Fatalf("expected only the processed event in GetAllEvents, got %+v", all.Events)
+		}
+		_, err = c.events.GetEventStatus(ctx, &gen.GetEventStatusRequest{Id: "00000000-0000-0000-0000-000000000000"})
+		requireCode(t, err, codes.NotFound)
+		_, err = c.events.GetEventStatus(ctx, &gen.GetEventStatusRequest{Id: "not-a-uuid"})
+		requireCode(t, err, codes.NotFound)
+	})
+}
+
+func TestEventLifecycle(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx := context.Background()
+		const organizer, guest = "10", "20"
+
+		invalid := eventDetails("Broken")
+		invalid.EndsAt = invalid.StartsAt
+		_, err := c.events.CreateEvent(ctx, &gen.CreateEventRequest{OrganizerId: organizer, Details: invalid})
+		requireCode(t, err, codes.InvalidArgument)
+		invalid = eventDetails("Broken")
+		invalid.Timezone = "Mars/Olympus"
+		_, err = c.events.CreateEvent(ctx, &gen.CreateEventRequest{OrganizerId: organizer, Details: invalid})
+		requireCode(t, err, codes.InvalidArgument)
+
+		event, err := c.events.CreateEvent(ctx, &gen.CreateEventRequest{OrganizerId: organizer, Details: eventDetails("Meetup")})
+		requireOK(t, err)
+		if event.State != string(domain.StateDraft) || event.OrganizerId != organizer || event.Capacity != 100 || event.Timezone != "Europe/Moscow" {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+
+		// черновик виден только организатору
+		_, err = c.events.GetEvent(ctx, &gen.GetEventRequest{Id: event.Id, UserId: guest})
+		requireCode(t, err, codes.NotFound)
+		_, err = c.events.GetEvent(ctx, &gen.GetEventRequest{Id: event.Id, UserId: organizer})
+		requireOK(t, err)
+		listed, err := c.events.ListEvents(ctx, &gen.ListEventsRequest{UserId: guest})
+		requireOK(t, err)
+		if len(listed.Events) != 0 {
+			t.Fatalf("draft must not be listed for guests, got %d events", len(listed.Events))
+		}
+
+		details := eventDetails("Meetup #2")
+		details.Capacity = 120
+		_, err = c.events.UpdateEvent(ctx, &gen.UpdateEventRequest{Id: event.Id, UserId: guest, Details: details})
+		requireCode(t, err, codes.PermissionDenied)
+		updated, err := c.events.UpdateEvent(ctx, &gen.UpdateEventRequest{Id: event.Id, UserId: organizer, Details: details})
+		requireOK(t, err)
+		if updated.Title != "Meetup #2" || updated.Capacity != 120 {
+			t.Fatalf("update not applied: %+v", updated)
+		}
+
+		_, err = c.events.ChangeEventState(ctx, &gen.ChangeEventStateRequest{Id: event.Id, UserId: organizer, State: string(domain.StateFinished)})
+		requireCode(t, err, codes.FailedPrecondition)
+		_, err = c.events.ChangeEventState(ctx, &gen.ChangeEventStateRequest{Id: event.Id, UserId: guest, State: string(domain.StatePublished)})
+		requireCode(t, err, codes.PermissionDenied)
+
+		for _, state := range []domain.EventState{domain.StatePublished, domain.StateOngoing, domain.StateFinished} {
+			changed, err := c.events.ChangeEventState(ctx, &gen.ChangeEventStateRequest{Id: event.Id, UserId: organizer, State: string(state)})
+			requireOK(t, err)
+			if changed.State != string(state) {
+				t.Fatalf("expected state %s, got %s", state, changed.State)
+			}
+		}
+
+		listed, err = c.events.ListEvents(ctx, &gen.ListEventsRequest{UserId: guest})
+		requireOK(t, err)
+		if len(listed.Events) != 1 || listed.Events[0].Id != event.Id {
+			t.Fatalf("published event must be listed, got %+v", listed.Events)
+		}
+
+		_, err = c.events.UpdateEvent(ctx, &gen.UpdateEventRequest{Id: event.Id, UserId: organizer, Details: details})
+		requireCode(t, err, codes.FailedPrecondition)
+		_, err = c.events.ChangeEventState(ctx, &gen.ChangeEventStateRequest{Id: event.Id, UserId: organizer, State: string(domain.StateCancelled)})
+		requireCode(t, err, codes.FailedPrecondition)
+	})
+}
+
+func TestEventQueue(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx := context.Background()
+		eventID := publishedEvent(t, c, "1")
+
+		for i, user := range []string{"u1", "u2", "u3"} {
+			joined, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: user})
+			requireOK(t, err)
+			if joined.Position != int32(i+1) || joined.QueueId == "" {
+				t.Fatalf("%s: unexpected join response %+v", user, joined)
+			}
+		}
+		_, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u1"})
+		requireCode(t, err, codes.AlreadyExists)
+
+		_, err = c.queues.LeaveQueue(ctx, &gen.LeaveQueueRequest{EventId: eventID, UserId: "u2"})
+		requireOK(t, err)
+		_, err = c.queues.LeaveQueue(ctx, &gen.LeaveQueueRequest{EventId: eventID, UserId: "u2"})
+		requireCode(t, err, codes.NotFound)
+		requirePosition(t, c, eventID, "u3", 2)
+
+		// вышедший может встать снова, в конец очереди
+		rejoined, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u2"})
+		requireOK(t, err)
+		if rejoined.Position != 3 {
+			t.Fatalf("expected rejoin at position 3, got %d", rejoined.Position)
+		}
+
+		next, err := c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+		if next.Queue.UserId != "u1" || next.Queue.Status != string(domain.QueueStatusActive) {
+			t.Fatalf("expected u1 to be served, got %+v", next.Queue)
+		}
+		requirePosition(t, c, eventID, "u3", 1)
+		requirePosition(t, c, eventID, "u2", 2)
+
+		queueStatus, err := c.queues.GetQueueStatus(ctx, &gen.GetQueueStatusRequest{EventId: eventID})
+		requireOK(t, err)
+		var order []string
+		for _, q := range queueStatus.Queues {
+			if q.Status == string(domain.QueueStatusWaiting) {
+				order = append(order, q.UserId)
+			}
+		}
+		if len(order) != 2 || order[0] != "u3" || order[1] != "u2" {
+			t.Fatalf("unexpected waiting order %v", order)
+		}
+
+		_, err = c.queues.CloseQueue(ctx, &gen.CloseQueueRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u4"})
+		requireCode(t, err, codes.FailedPrecondition)
+
+		// закрытие набора не выгоняет тех, кто уже стоит
+		for _, want := range []string{"u3", "u2"} {
+			next, err := c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+			requireOK(t, err)
+			if next.Queue.UserId != want {
+				t.Fatalf("expected %s, got %s", want, next.Queue.UserId)
+			}
+		}
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireCode(t, err, codes.FailedPrecondition)
+	})
+}
+
+func TestEventQueueRejectsUnavailableEvents(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx := context.Background()
+
+		_, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: "00000000-0000-0000-0000-000000000000", UserId: "u1"})
+		requireCode(t, err, codes.NotFound)
+		_, err = c.queues.GetQueueStatus(ctx, &gen.GetQueueStatusRequest{EventId: "missing"})
+		requireCode(t, err, codes.NotFound)
+
+		draft, err := c.events.CreateEvent(ctx, &gen.CreateEventRequest{OrganizerId: "1", Details: eventDetails("Draft")})
+		requireOK(t, err)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: draft.Id, UserId: "u1"})
+		requireCode(t, err, codes.FailedPrecondition)
+
+		eventID := publishedEvent(t, c, "1")
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID})
+		requireCode(t, err, codes.InvalidArgument)
+		_, err = c.queues.GetUserPosition(ctx, &gen.GetUserPositionRequest{EventId: eventID, UserId: "u1"})
+		requireCode(t, err, codes.NotFound)
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireCode(t, err, codes.FailedPrecondition)
+
+		_, err = c.events.ChangeEventState(ctx, &gen.ChangeEventStateRequest{Id: eventID, UserId: "1", State: string(domain.StateCancelled)})
+		requireOK(t, err)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u1"})
+		requireCode(t, err, codes.FailedPrecondition)
+	})
+}
+
+func TestEventCapacity(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx := context.Background()
+		details := eventDetails("Small room")
+		details.Capacity = 2
+		event, err := c.events.CreateEvent(ctx, &gen.CreateEventRequest{OrganizerId: "1", Details: details})
+		requireOK(t, err)
+		_, err = c.events.ChangeEventState(ctx, &gen.ChangeEventStateRequest{Id: event.Id, UserId: "1", State: string(domain.StatePublished)})
+		requireOK(t, err)
+
+		for _, user := range []string{"u1", "u2"} {
+			_, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: event.Id, UserId: user})
+			requireOK(t, err)
+		}
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: event.Id, UserId: "u3"})
+		requireCode(t, err, codes.ResourceExhausted)
+
+		// ушедший освобождает место, а обслуженный продолжает его занимать
+		_, err = c.queues.LeaveQueue(ctx, &gen.LeaveQueueRequest{EventId: event.Id, UserId: "u2"})
+		requireOK(t, err)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: event.Id, UserId: "u3"})
+		requireOK(t, err)
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: event.Id, UserId: "1"})
+		requireOK(t, err)
+		_, err = c.queues.CompleteEntry(ctx, &gen.CompleteEntryRequest{EventId: event.Id, UserId: "u1", OperatorId: "1"})
+		requireOK(t, err)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: event.Id, UserId: "u2"})
+		requireCode(t, err, codes.ResourceExhausted)
+		// своё место пользователь может занять снова
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: event.Id, UserId: "u1"})
+		requireOK(t, err)
+	})
+}
+
+func TestClosedEventStopsQueue(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx := context.Background()
+		eventID := publishedEvent(t, c, "1")
+		for _, user := range []string{"u1", "u2", "u3"} {
+			_, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: user})
+			requireOK(t, err)
+		}
+		_, err := c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+
+		_, err = c.events.ChangeEventState(ctx, &gen.ChangeEventStateRequest{Id: eventID, UserId: "1", State: string(domain.StateOngoing)})
+		requireOK(t, err)
+		finished, err := c.events.ChangeEventState(ctx, &gen.ChangeEventStateRequest{Id: eventID, UserId: "1", State: string(domain.StateFinished)})
+		requireOK(t, err)
+		if finished.State != string(domain.StateFinished) || finished.QueueState != string(domain.QueueStateClosed) {
+			t.Fatalf("expected finished event with a closed queue, got %+v", finished)
+		}
+		_, err = c.events.ChangeEventState(ctx, &gen.ChangeEventStateRequest{Id: eventID, UserId: "1", State: string(domain.StateFinished)})
+		requireCode(t, err, codes.FailedPrecondition)
+
+		// после завершения никого не вызывают, но начатое обслуживание можно закончить
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireCode(t, err, codes.FailedPrecondition)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u4"})
+		requireCode(t, err, codes.FailedPrecondition)
+		_, err = c.queues.SkipEntry(ctx, &gen.SkipEntryRequest{EventId: eventID, UserId: "u1", OperatorId: "1"})
+		requireOK(t, err)
+		requirePosition(t, c, eventID, "u2", 1)
+
+		for _, user := range []string{"u2", "u3"} {
+			_, err := c.queues.LeaveQueue(ctx, &gen.LeaveQueueRequest{EventId: eventID, UserId: user})
+			requireOK(t, err)
+		}
+		event, err := c.events.GetEvent(ctx, &gen.GetEventRequest{Id: eventID, UserId: "1"})
+		requireOK(t, err)
+		if event.QueueState != string(domain.QueueStateDrained) {
+			t.Fatalf("expected drained queue, got %q", event.QueueState)
+		}
+	})
+}
+
+func requirePosition(t *testing.T, c clients, eventID, userID string, want int32) {
+	t.Helper()
+	position, err := c.queues.GetUserPosition(context.Background(), &gen.GetUserPositionRequest{EventId: eventID, UserId: userID})
+	requireOK(t, err)
+	if position.Position != want {
+		t.Fatalf("%s: expected position %d, got %d", userID, want, position.Position)
+	}
+}
+
+func TestWatchQueue(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		eventID := publishedEvent(t, c, "1")
+
+		missing, err := c.queues.WatchQueue(ctx, &gen.WatchQueueRequest{EventId: eventID, UserId: "nobody"})
+		requireOK(t, err)
+		_, err = missing.Recv()
+		requireCode(t, err, codes.NotFound)
+
+		for _, user := range []string{"u1", "u2"} {
+			_, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: user})
+			requireOK(t, err)
+		}
+
+		stream, err := c.queues.WatchQueue(ctx, &gen.WatchQueueRequest{EventId: eventID, UserId: "u2"})
+		requireOK(t, err)
+		expect := func(status domain.EventQueueStatus, position int32) {
+			t.Helper()
+			update, err := stream.Recv()
+			requireOK(t, err)
+			if update.Status != string(status) || update.Position != position || update.UserId != "u2" {
+				t.Fatalf("expected %s at %d, got %+v", status, position, update)
+			}
+		}
+		expect(domain.QueueStatusWaiting, 2)
+
+		// изменения чужих записей, не влияющие на позицию, не присылаются
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u3"})
+		requireOK(t, err)
+
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+		expect(domain.QueueStatusWaiting, 1)
+
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+		expect(domain.QueueStatusActive, 0)
+
+		_, err = c.queues.LeaveQueue(ctx, &gen.LeaveQueueRequest{EventId: eventID, UserId: "u2"})
+		requireOK(t, err)
+		expect(domain.QueueStatusCancelled, 0)
+
+		if _, err := stream.Recv(); err != io.EOF {
+			t.Fatalf("expected the stream to end, got %v", err)
+		}
+	})
+}
+
+func TestQueueWaitlist(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx := context.Background()
+		eventID := publishedEvent(t, c, "1")
+		settings := &gen.QueueSettings{MaxActive: 2, MaxTotal: 3, MaxPerUser: 2}
+
+		_, err := c.queues.UpdateQueueSettings(ctx, &gen.UpdateQueueSettingsRequest{EventId: eventID, UserId: "2", Settings: settings})
+		requireCode(t, err, codes.PermissionDenied)
+		_, err = c.queues.UpdateQueueSettings(ctx, &gen.UpdateQueueSettingsRequest{EventId: eventID, UserId: "1",
+			Settings: &gen.QueueSettings{MaxActive: 4, MaxTotal: 3}})
+		requireCode(t, err, codes.InvalidArgument)
+		_, err = c.queues.UpdateQueueSettings(ctx, &gen.UpdateQueueSettingsRequest{EventId: eventID, UserId: "1", Settings: settings})
+		requireOK(t, err)
+
+		event, err := c.events.GetEvent(ctx, &gen.GetEventRequest{Id: eventID, UserId: "2"})
+		requireOK(t, err)
+		if event.QueueSettings.GetMaxActive() != 2 || event.QueueSettings.GetMaxTotal() != 3 || event.QueueSettings.GetMaxPerUser() != 2 {
+			t.Fatalf("settings not stored: %+v", event.QueueSettings)
+		}
+
+		join := func(user string, status domain.EventQueueStatus, position int32) {
+			t.Helper()
+			joined, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: user})
+			requireOK(t, err)
+			if joined.Status != string(status) || joined.Position != position {
+				t.Fatalf("%s: expected %s at %d, got %s at %d", user, status, position, joined.Status, joined.Position)
+			}
+		}
+		join("u1", domain.QueueStatusWaiting, 1)
+		join("u2", domain.QueueStatusWaiting, 2)
+		join("u3", domain.QueueStatusWaitlisted, 1)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u4"})
+		requireCode(t, err, codes.ResourceExhausted)
+
+		// вызов первого освобождает место, и лист ожидания продвигается
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+		position, err := c.queues.GetUserPosition(ctx, &gen.GetUserPositionRequest{EventId: eventID, UserId: "u3"})
+		requireOK(t, err)
+		if position.Status != string(domain.QueueStatusWaiting) || position.Position != 2 {
+			t.Fatalf("expected u3 promoted to position 2, got %s at %d", position.Status, position.Position)
+		}
+
+		join("u4", domain.QueueStatusWaitlisted, 1)
+		_, err = c.queues.LeaveQueue(ctx, &gen.LeaveQueueRequest{EventId: eventID, UserId: "u2"})
+		requireOK(t, err)
+		position, err = c.queues.GetUserPosition(ctx, &gen.GetUserPositionRequest{EventId: eventID, UserId: "u4"})
+		requireOK(t, err)
+		if position.Status != string(domain.QueueStatusWaiting) || position.Position != 2 {
+			t.Fatalf("expected u4 promoted to position 2, got %s at %d", position.Status, position.Position)
+		}
+
+		// второе вступление разрешено, третье упирается в лимит на пользователя
+		join("u2", domain.QueueStatusWaitlisted, 1)
+		_, err = c.queues.LeaveQueue(ctx, &gen.LeaveQueueRequest{EventId: eventID, UserId: "u2"})
+		requireOK(t, err)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u2"})
+		requireCode(t, err, codes.ResourceExhausted)
+
+		// снятие ограничений сразу переводит весь лист ожидания
+		join("u5", domain.QueueStatusWaitlisted, 1)
+		_, err = c.queues.UpdateQueueSettings(ctx, &gen.UpdateQueueSettingsRequest{EventId: eventID, UserId: "1", Settings: &gen.QueueSettings{}})
+		requireOK(t, err)
+		position, err = c.queues.GetUserPosition(ctx, &gen.GetUserPositionRequest{EventId: eventID, UserId: "u5"})
+		requireOK(t, err)
+		if position.Status != string(domain.QueueStatusWaiting) || position.Position != 3 {
+			t.Fatalf("expected u5 promoted to position 3, got %s at %d", position.Status, position.Position)
+		}
+	})
+}
+
+func TestQueueTurns(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx := context.Background()
+		eventID := publishedEvent(t, c, "1")
+		_, err := c.queues.UpdateQueueSettings(ctx, &gen.UpdateQueueSettingsRequest{EventId: eventID, UserId: "1",
+			Settings: &gen.QueueSettings{TurnTimeoutSeconds: 60}})
+		requireOK(t, err)
+		for _, user := range []string{"u1", "u2", "u3"} {
+			_, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: user})
+			requireOK(t, err)
+		}
+
+		_, err = c.queues.CompleteEntry(ctx, &gen.CompleteEntryRequest{EventId: eventID, UserId: "u1", OperatorId: "1"})
+		requireCode(t, err, codes.FailedPrecondition)
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "u3"})
+		requireCode(t, err, codes.PermissionDenied)
+
+		called, err := c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+		if called.Queue.UserId != "u1" || called.Queue.ExpiresAt == "" {
+			t.Fatalf("expected u1 called with a deadline, got %+v", called.Queue)
+		}
+		// завершить или пропустить обслуживание может только организатор, но не сам пользователь
+		_, err = c.queues.CompleteEntry(ctx, &gen.CompleteEntryRequest{EventId: eventID, UserId: "u1", OperatorId: "u1"})
+		requireCode(t, err, codes.PermissionDenied)
+		_, err = c.queues.SkipEntry(ctx, &gen.SkipEntryRequest{EventId: eventID, UserId: "u1"})
+		requireCode(t, err, codes.PermissionDenied)
+		completed, err := c.queues.CompleteEntry(ctx, &gen.CompleteEntryRequest{EventId: eventID, UserId: "u1", OperatorId: "1"})
+		requireOK(t, err)
+		if completed.Queue.Status != string(domain.QueueStatusCompleted) || completed.Queue.ExpiresAt != "" {
+			t.Fatalf("unexpected completed entry %+v", completed.Queue)
+		}
+		_, err = c.queues.CompleteEntry(ctx, &gen.CompleteEntryRequest{EventId: eventID, UserId: "u1", OperatorId: "1"})
+		requireCode(t, err, codes.FailedPrecondition)
+
+		// пропуск вызванного сразу вызывает следующего
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+		skipped, err := c.queues.SkipEntry(ctx, &gen.SkipEntryRequest{EventId: eventID, UserId: "u2", OperatorId: "1"})
+		requireOK(t, err)
+		if skipped.Skipped.Status != string(domain.QueueStatusNoShow) || skipped.Next.GetUserId() != "u3" ||
+			skipped.Next.Status != string(domain.QueueStatusActive) {
+			t.Fatalf("unexpected skip result %+v", skipped)
+		}
+
+		// до срока никто не просрочен, после — u3 не явился, а вызывать больше некого
+		expired, err := c.queueService.ExpireTurns(ctx, time.Now(), 10)
+		requireOK(t, err)
+		if expired != 0 {
+			t.Fatalf("expected no expired turns yet, got %d", expired)
+		}
+		expired, err = c.queueService.ExpireTurns(ctx, time.Now().Add(61*time.Second), 10)
+		requireOK(t, err)
+		if expired != 1 {
+			t.Fatalf("expected one expired turn, got %d", expired)
+		}
+		_, err = c.queues.GetUserPosition(ctx, &gen.GetUserPositionRequest{EventId: eventID, UserId: "u3"})
+		requireCode(t, err, codes.NotFound)
+
+		// неявившийся может встать снова, и по истечении срока вызывается следующий
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u3"})
+		requireOK(t, err)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u4"})
+		requireOK(t, err)
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+		_, err = c.queueService.ExpireTurns(ctx, time.Now().Add(61*time.Second), 10)
+		requireOK(t, err)
+		position, err := c.queues.GetUserPosition(ctx, &gen.GetUserPositionRequest{EventId: eventID, UserId: "u4"})
+		requireOK(t, err)
+		if position.Status != string(domain.QueueStatusActive) {
+			t.Fatalf("expected u4 to be called after u3 expired, got %s", position.Status)
+		}
+	})
+}
+
+func TestCheckIn(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx := context.Background()
+		eventID := publishedEvent(t, c, "1")
+		_, err := c.queues.UpdateQueueSettings(ctx, &gen.UpdateQueueSettingsRequest{
+			EventId:  eventID,
+			UserId:   "1",
+			Settings: &gen.QueueSettings{Counters: []string{"A", "B"}},
+		})
+		requireOK(t, err)
+		for _, user := range []string{"u1", "u2"} {
+			_, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: user})
+			requireOK(t, err)
+		}
+
+		_, err = c.queues.GetCheckInToken(ctx, &gen.GetCheckInTokenRequest{EventId: eventID, UserId: "nobody"})
+		requireCode(t, err, codes.NotFound)
+		first, err := c.queues.GetCheckInToken(ctx, &gen.GetCheckInTokenRequest{EventId: eventID, UserId: "u1"})
+		requireOK(t, err)
+		if first.Token == "" || len(first.QrImage) == 0 {
+			t.Fatalf("expected token with qr image, got %q and %d bytes", first.Token, len(first.QrImage))
+		}
+		second, err := c.queues.GetCheckInToken(ctx, &gen.GetCheckInTokenRequest{EventId: eventID, UserId: "u2"})
+		requireOK(t, err)
+
+		_, err = c.queues.CheckIn(ctx, &gen.CheckInRequest{})
+		requireCode(t, err, codes.InvalidArgument)
+		_, err = c.queues.CheckIn(ctx, &gen.CheckInRequest{Token: first.Token + "x", UserId: "1"})
+		requireCode(t, err, codes.InvalidArgument)
+		_, err = c.queues.CheckIn(ctx, &gen.CheckInRequest{Photo: []byte("not an image"), UserId: "1"})
+		requireCode(t, err, codes.InvalidArgument)
+
+		// отмечает прибытие только организатор, а не сам пользователь
+		_, err = c.queues.CheckIn(ctx, &gen.CheckInRequest{Token: first.Token, UserId: "u1"})
+		requireCode(t, err, codes.PermissionDenied)
+
+		// второй в очереди ещё не может пройти вперёд первого
+		_, err = c.queues.CheckIn(ctx, &gen.CheckInRequest{Token: second.Token, UserId: "1", CounterId: "A"})
+		requireCode(t, err, codes.FailedPrecondition)
+
+		// вызвать к себе можно только у одной из стоек мероприятия
+		_, err = c.queues.CheckIn(ctx, &gen.CheckInRequest{Token: first.Token, UserId: "1"})
+		requireCode(t, err, codes.InvalidArgument)
+		_, err = c.queues.CheckIn(ctx, &gen.CheckInRequest{Token: first.Token, UserId: "1", CounterId: "Z"})
+		requireCode(t, err, codes.InvalidArgument)
+
+		// первый отмечается по фотографии своего QR-кода и становится обслуживаемым
+		checked, err := c.queues.CheckIn(ctx, &gen.CheckInRequest{Photo: first.QrImage, UserId: "1", CounterId: "A"})
+		requireOK(t, err)
+		if checked.Queue.UserId != "u1" || checked.Queue.Status != string(domain.QueueStatusActive) || checked.Queue.CounterId != "A" {
+			t.Fatalf("expected u1 to become active, got %+v", checked.Queue)
+		}
+		checked, err = c.queues.CheckIn(ctx, &gen.CheckInRequest{Token: first.Token, UserId: "1"})
+		requireOK(t, err)
+		if checked.Queue.Status != string(domain.QueueStatusCompleted) {
+			t.Fatalf("expected u1 to be completed, got %s", checked.Queue.Status)
+		}
+		_, err = c.queues.CheckIn(ctx, &gen.CheckInRequest{Token: first.Token, UserId: "1"})
+		requireCode(t, err, codes.NotFound)
+
+		// после повторной постановки в очередь старый токен не действует
+		_, err = c.queues.LeaveQueue(ctx, &gen.LeaveQueueRequest{EventId: eventID, UserId: "u2"})
+		requireOK(t, err)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u2"})
+		requireOK(t, err)
+		_, err = c.queues.CheckIn(ctx, &gen.CheckInRequest{Token: second.Token, UserId: "1"})
+		requireCode(t, err, codes.InvalidArgument)
+		renewed, err := c.queues.GetCheckInToken(ctx, &gen.GetCheckInTokenRequest{EventId: eventID, UserId: "u2"})
+		requireOK(t, err)
+		checked, err = c.queues.CheckIn(ctx, &gen.CheckInRequest{Token: renewed.Token, UserId: "1", CounterId: "B"})
+		requireOK(t, err)
+		if checked.Queue.Status != string(domain.QueueStatusActive) {
+			t.Fatalf("expected u2 to become active, got %s", checked.Queue.Status)
+		}
+	})
+}
+
+// requireCallOrder проверяет, что ожидающие перечислены и пронумерованы в порядке будущего вызова,
+// а затем вызывает их по одному и сверяет, что ProcessNext идёт в том же порядке
+func requireCallOrder(t *testing.T, c clients, eventID string, want []string) {
+	t.Helper()
+	ctx := context.Background()
+	for i := range want {
+		waiting := requireConsistentQueue(t, c, eventID)
+		if len(waiting) != len(want)-i {
+			t.Fatalf("expected %d waiting, got %d", len(want)-i, len(waiting))
+		}
+		for j, q := range waiting {
+			if q.UserId != want[i+j] {
+				t.Fatalf("expected %s at position %d, got %s", want[i+j], j+1, q.UserId)
+			}
+		}
+		next, err := c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+		if next.Queue.UserId != want[i] {
+			t.Fatalf("expected %s to be called, got %s", want[i], next.Queue.UserId)
+		}
+	}
+}
+
+func TestQueueLanes(t *testing.T) {
+	join := func(t *testing.T, c clients, eventID string, users map[string][]string) {
+		t.Helper()
+		// пользователи встают в общую полосу, а в приоритетные их переводит организатор
+		for _, lane := range []string{"", "vip", "staff"} {
+			for _, user := range users[lane] {
+				_, err := c.queues.JoinQueue(context.Background(), &gen.JoinQueueRequest{EventId: eventID, UserId: user})
+				requireOK(t, err)
+				if lane == "" {
+					continue
+				}
+				_, err = c.queues.AssignLane(context.Background(), &gen.AssignLaneRequest{EventId: eventID, UserId: user, Lane: lane, OperatorId: "1"})
+				requireOK(t, err)
+			}
+		}
+	}
+	lanes := func(policy string, weights ...int32) *gen.QueueSettings {
+		settings := &gen.QueueSettings{Policy: policy}
+		for i, name := range []string{"staff", "vip", "general"} {
+			settings.Lanes = append(settings.Lanes, &gen.QueueLane{Name: name, Weight: weights[i]})
+		}
+		return settings
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		forEachBackend(t, func(t *testing.T, c clients) {
+			ctx := context.Background()
+			eventID := publishedEvent(t, c, "1")
+			_, err := c.queues.UpdateQueueSettings(ctx, &gen.UpdateQueueSettingsRequest{EventId: eventID, UserId: "1",
+				Settings: lanes("strict", 0, 0, 0)})
+			requireOK(t, err)
+
+			join(t, c, eventID, map[string][]string{"": {"g1", "g2"}, "vip": {"v1"}, "staff": {"s1"}})
+			_, err = c.queues.AssignLane(ctx, &gen.AssignLaneRequest{EventId: eventID, UserId: "g2", Lane: "press", OperatorId: "1"})
+			requireCode(t, err, codes.InvalidArgument)
+			_, err = c.queues.AssignLane(ctx, &gen.AssignLaneRequest{EventId: eventID, UserId: "g2", Lane: "staff", OperatorId: "g2"})
+			requireCode(t, err, codes.PermissionDenied)
+			_, err = c.queues.AssignLane(ctx, &gen.AssignLaneRequest{EventId: eventID, UserId: "x", Lane: "staff", OperatorId: "1"})
+			requireCode(t, err, codes.NotFound)
+			position, err := c.queues.GetUserPosition(ctx, &gen.GetUserPositionRequest{EventId: eventID, UserId: "g1"})
+			requireOK(t, err)
+			if position.Position != 3 {
+				t.Fatalf("expected g1 behind staff and vip at position 3, got %d", position.Position)
+			}
+			requireCallOrder(t, c, eventID, []string{"s1", "v1", "g1", "g2"})
+			_, err = c.queues.AssignLane(ctx, &gen.AssignLaneRequest{EventId: eventID, UserId: "g2", Lane: "staff", OperatorId: "1"})
+			requireCode(t, err, codes.FailedPrecondition)
+		})
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		forEachBackend(t, func(t *testing.T, c clients) {
+			ctx := context.Background()
+			eventID := publishedEvent(t, c, "1")
+			_, err := c.queues.UpdateQueueSettings(ctx, &gen.UpdateQueueSettingsRequest{EventId: eventID, UserId: "1",
+				Settings: lanes("weighted", 1, 2, 1)})
+			requireOK(t, err)
+
+			_, err = c.queues.UpdateQueueSettings(ctx, &gen.UpdateQueueSettingsRequest{EventId: eventID, UserId: "1",
+				Settings: &gen.QueueSettings{Policy: "random"}})
+			requireCode(t, err, codes.InvalidArgument)
+
+			join(t, c, eventID, map[string][]string{"": {"g1", "g2", "g3"}, "vip": {"v1", "v2", "v3"}, "staff": {"s1"}})
+			requireCallOrder(t, c, eventID, []string{"s1", "v1", "v2", "g1", "v3", "g2", "g3"})
+		})
+	})
+}
+
+func TestQueueCounters(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx := context.Background()
+		eventID := publishedEvent(t, c, "1")
+		for _, user := range []string{"u1", "u2", "u3", "u4"} {
+			_, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: user})
+			requireOK(t, err)
+		}
+
+		next, err := c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, CounterId: "A", UserId: "1"})
+		requireOK(t, err)
+		if next.Queue.UserId != "u1" || next.Queue.CounterId != "A" || next.Queue.CalledAt == "" {
+			t.Fatalf("expected u1 called to A, got %+v", next.Queue)
+		}
+		// стойка обслуживает одного человека за раз, остальные стойки работают параллельно
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, CounterId: "A", UserId: "1"})
+		requireCode(t, err, codes.FailedPrecondition)
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, CounterId: "B", UserId: "1"})
+		requireOK(t, err)
+
+		queueStatus, err := c.queues.GetQueueStatus(ctx, &gen.GetQueueStatusRequest{EventId: eventID})
+		requireOK(t, err)
+		serving := make(map[string]string)
+		for _, q := range queueStatus.Queues {
+			if q.Status == string(domain.QueueStatusActive) {
+				serving[q.CounterId] = q.UserId
+			}
+		}
+		if len(serving) != 2 || serving["A"] != "u1" || serving["B"] != "u2" {
+			t.Fatalf("unexpected active entries by counter: %v", serving)
+		}
+
+		// после завершения стойка свободна, а пропуск вызывает следующего к той же стойке
+		_, err = c.queues.CompleteEntry(ctx, &gen.CompleteEntryRequest{EventId: eventID, UserId: "u1", OperatorId: "1"})
+		requireOK(t, err)
+		skipped, err := c.queues.SkipEntry(ctx, &gen.SkipEntryRequest{EventId: eventID, UserId: "u2", OperatorId: "1"})
+		requireOK(t, err)
+		if skipped.Next.GetUserId() != "u3" || skipped.Next.CounterId != "B" {
+			t.Fatalf("expected u3 called to B, got %+v", skipped.Next)
+		}
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, CounterId: "A", UserId: "1"})
+		requireOK(t, err)
+
+		// перечисленные организатором стойки ограничивают вызовы, без списка подходит любая
+		_, err = c.queues.CompleteEntry(ctx, &gen.CompleteEntryRequest{EventId: eventID, UserId: "u4", OperatorId: "1"})
+		requireOK(t, err)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u5"})
+		requireOK(t, err)
+		_, err = c.queues.UpdateQueueSettings(ctx, &gen.UpdateQueueSettingsRequest{EventId: eventID, UserId: "1",
+			Settings: &gen.QueueSettings{Counters: []string{"A", "B"}}})
+		requireOK(t, err)
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, CounterId: "C", UserId: "1"})
+		requireCode(t, err, codes.InvalidArgument)
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, CounterId: "A", UserId: "1"})
+		requireOK(t, err)
+
+		stats, err := c.queues.GetCounterStats(ctx, &gen.GetCounterStatsRequest{EventId: eventID})
+		requireOK(t, err)
+		if len(stats.Counters) != 2 {
+			t.Fatalf("expected stats for 2 counters, got %d", len(stats.Counters))
+		}
+		a, b := stats.Counters[0], stats.Counters[1]
+		if a.CounterId != "A" || a.ServingUserId != "u5" || a.Served != 2 || a.NoShows != 0 {
+			t.Fatalf("unexpected stats for A: %+v", a)
+		}
+		if b.CounterId != "B" || b.ServingUserId != "u3" || b.Served != 0 || b.NoShows != 1 {
+			t.Fatalf("unexpected stats for B: %+v", b)
+		}
+	})
+}
+
+func TestWaitEstimate(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx := context.Background()
+		eventID := publishedEvent(t, c, "1")
+		_, err := c.queues.UpdateQueueSettings(ctx, &gen.UpdateQueueSettingsRequest{EventId: eventID, UserId: "1",
+			Settings: &gen.QueueSettings{Counters: []string{"A", "B"}}})
+		requireOK(t, err)
+		for _, user := range []string{"u1", "u2", "u3", "u4", "u5"} {
+			_, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: user})
+			requireOK(t, err)
+		}
+		wait := func(userID string) int64 {
+			t.Helper()
+			position, err := c.queues.GetUserPosition(ctx, &gen.GetUserPositionRequest{EventId: eventID, UserId: userID})
+			requireOK(t, err)
+			return position.EstimatedWaitSeconds
+		}
+
+		// пока никто не обслужен, оценивать не по чему
+		if got := wait("u5"); got != 0 {
+			t.Fatalf("expected no estimate without history, got %d", got)
+		}
+
+		// две стойки обслуживают по человеку примерно за 0.6 секунды
+		for _, counter := range []string{"A", "B"} {
+			_, err := c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, CounterId: counter, UserId: "1"})
+			requireOK(t, err)
+		}
+		time.Sleep(600 * time.Millisecond)
+		for _, user := range []string{"u1", "u2"} {
+			_, err := c.queues.CompleteEntry(ctx, &gen.CompleteEntryRequest{EventId: eventID, UserId: user, OperatorId: "1"})
+			requireOK(t, err)
+		}
+
+		// первые двое пройдут за один круг обслуживания, третий — за два
+		first, second, third := wait("u3"), wait("u4"), wait("u5")
+		if first != 1 || second != 1 || third != 2 {
+			t.Fatalf("expected estimates 1, 1, 2 seconds, got %d, %d, %d", first, second, third)
+		}
+		joined, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u6"})
+		requireOK(t, err)
+		if joined.EstimatedWaitSeconds != 2 {
+			t.Fatalf("expected new user to wait 2 seconds, got %d", joined.EstimatedWaitSeconds)
+		}
+		queueStatus, err := c.queues.GetQueueStatus(ctx, &gen.GetQueueStatusRequest{EventId: eventID})
+		requireOK(t, err)
+		for _, q := range queueStatus.Queues {
+			if q.Status == string(domain.QueueStatusWaiting) && q.EstimatedWaitSeconds == 0 {
+				t.Fatalf("expected estimate for waiting %s in queue status", q.UserId)
+			}
+		}
+
+		// без настроенных стоек параллельно работают только те, что сейчас кого-то обслуживают
+		_, err = c.queues.UpdateQueueSettings(ctx, &gen.UpdateQueueSettingsRequest{EventId: eventID, UserId: "1",
+			Settings: &gen.QueueSettings{}})
+		requireOK(t, err)
+		if got := wait("u6"); got != 3 {
+			t.Fatalf("expected u6 to wait 3 seconds at a single counter, got %d", got)
+		}
+		for _, counter := range []string{"A", "B"} {
+			_, err := c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, CounterId: counter, UserId: "1"})
+			requireOK(t, err)
+		}
+		if fifth, sixth := wait("u5"), wait("u6"); fifth != 1 || sixth != 1 {
+			t.Fatalf("expected estimates 1, 1 seconds at two busy counters, got %d, %d", fifth, sixth)
+		}
+	})
+}
+
+func TestQueueState(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, c clients) {
+		ctx := context.Background()
+		eventID := publishedEvent(t, c, "1")
+		request := &gen.QueueStateRequest{EventId: eventID, UserId: "1"}
+		for _, user := range []string{"u1", "u2"} {
+			_, err := c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: user})
+			requireOK(t, err)
+		}
+
+		// состоянием очереди управляет только организатор
+		_, err := c.queues.PauseQueue(ctx, &gen.QueueStateRequest{EventId: eventID, UserId: "u1"})
+		requireCode(t, err, codes.PermissionDenied)
+		_, err = c.queues.CloseQueue(ctx, &gen.CloseQueueRequest{EventId: eventID})
+		requireCode(t, err, codes.PermissionDenied)
+
+		// на паузе никто не встаёт и никого не вызывают, но места сохраняются
+		paused, err := c.queues.PauseQueue(ctx, request)
+		requireOK(t, err)
+		if paused.State != string(domain.QueueStatePaused) {
+			t.Fatalf("expected paused queue, got %q", paused.State)
+		}
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u3"})
+		requireCode(t, err, codes.Unavailable)
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireCode(t, err, codes.Unavailable)
+		requirePosition(t, c, eventID, "u2", 2)
+		_, err = c.queues.ReopenQueue(ctx, request)
+		requireCode(t, err, codes.FailedPrecondition)
+
+		_, err = c.queues.ResumeQueue(ctx, request)
+		requireOK(t, err)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u3"})
+		requireOK(t, err)
+		_, err = c.queues.ProcessNext(ctx, &gen.ProcessNextRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+
+		// закрытая очередь дообслуживает вставших и пустеет, когда уходит последний
+		closed, err := c.queues.CloseQueue(ctx, &gen.CloseQueueRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+		if closed.State != string(domain.QueueStateClosed) {
+			t.Fatalf("expected closed queue, got %q", closed.State)
+		}
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u4"})
+		requireCode(t, err, codes.FailedPrecondition)
+		_, err = c.queues.ArchiveQueue(ctx, request)
+		requireCode(t, err, codes.FailedPrecondition)
+		_, err = c.queues.LeaveQueue(ctx, &gen.LeaveQueueRequest{EventId: eventID, UserId: "u3"})
+		requireOK(t, err)
+		_, err = c.queues.SkipEntry(ctx, &gen.SkipEntryRequest{EventId: eventID, UserId: "u1", OperatorId: "1"})
+		requireOK(t, err)
+		_, err = c.queues.CompleteEntry(ctx, &gen.CompleteEntryRequest{EventId: eventID, UserId: "u2", OperatorId: "1"})
+		requireOK(t, err)
+
+		event, err := c.events.GetEvent(ctx, &gen.GetEventRequest{Id: eventID, UserId: "1"})
+		requireOK(t, err)
+		if event.QueueState != string(domain.QueueStateDrained) {
+			t.Fatalf("expected drained queue, got %q", event.QueueState)
+		}
+
+		// опустевшую очередь можно открыть снова или убрать в архив
+		reopened, err := c.queues.ReopenQueue(ctx, request)
+		requireOK(t, err)
+		if reopened.State != string(domain.QueueStateOpen) {
+			t.Fatalf("expected open queue, got %q", reopened.State)
+		}
+		closed, err = c.queues.CloseQueue(ctx, &gen.CloseQueueRequest{EventId: eventID, UserId: "1"})
+		requireOK(t, err)
+		if closed.State != string(domain.QueueStateDrained) {
+			t.Fatalf("expected empty queue to drain on close, got %q", closed.State)
+		}
+		_, err = c.queues.ArchiveQueue(ctx, request)
+		requireOK(t, err)
+		_, err = c.queues.JoinQueue(ctx, &gen.JoinQueueRequest{EventId: eventID, UserId: "u4"})
+		requireCode(t, err, codes.FailedPrecondition)
+		_, err = c.queues.ReopenQueue(ctx, request)
+		requireCode(t, err, codes.FailedPrecondition)
+	})
+}
@@ -1,8 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"time"
 
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain/gen"
 	"github.com/XRS0/ToTalkB/event_manager/internal/domain/services"
 
 	"github.com/gin-gonic/gin"
@@ -18,12 +23,124 @@ func NewEventHandler(eventService *services.EventService) *EventHandler {
 	}
 }
 
+type eventDetailsInput struct {
+	Title       string    `json:"title" binding:"required"`
+	Description string    `json:"description"`
+	Venue       string    `json:"venue"`
+	StartsAt    time.Time `json:"starts_at" binding:"required"`
+	EndsAt      time.Time `json:"ends_at" binding:"required"`
+	Timezone    string    `json:"timezone"`
+	Capacity    int       `json:"capacity"`
+	Visibility  string    `json:"visibility"`
+}
+
+func (i eventDetailsInput) details() domain.EventDetails {
+	return domain.EventDetails{
+		Title:       i.Title,
+		Description: i.Description,
+		Venue:       i.Venue,
+		StartsAt:    i.StartsAt,
+		EndsAt:      i.EndsAt,
+		Timezone:    i.Timezone,
+		Capacity:    i.Capacity,
+		Visibility:  domain.Visibility(i.Visibility),
+	}
+}
+
+type changeStateInput struct {
+	State string `json:"state" binding:"required"`
+}
+
+// GetEvents возвращает мероприятия, доступные текущему пользователю
 func (h *EventHandler) GetEvents(c *gin.Context) {
-	events, err := h.eventService.GetAllEvents(c.Request.Context())
+	events, err := h.eventService.ListEvents(c.Request.Context(), c.GetString("userId"))
+	if err != nil {
+		eventError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toProto(events))
+}
+
+func (h *EventHandler) GetEvent(c *gin.Context) {
+	event, err := h.eventService.GetEvent(c.Request.Context(), c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		eventError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, services.EventToProto(event))
+}
+
+// CreateEvent создаёт черновик, организатором становится текущий пользователь
+func (h *EventHandler) CreateEvent(c *gin.Context) {
+	var input eventDetailsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.eventService.CreateEvent(c.Request.Context(), c.GetString("userId"), input.details())
+	if err != nil {
+		eventError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, services.EventToProto(event))
+}
+
+func (h *EventHandler) UpdateEvent(c *gin.Context) {
+	var input eventDetailsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.eventService.UpdateEvent(c.Request.Context(), c.Param("id"), c.GetString("userId"), input.details())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		eventError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, events)
+	c.JSON(http.StatusOK, services.EventToProto(event))
+}
+
+func (h *EventHandler) ChangeState(c *gin.Context) {
+	var input changeStateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.eventService.ChangeEventState(c.Request.Context(), c.Param("id"), c.GetString("userId"), domain.EventState(input.State))
+	if err != nil {
+		eventError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, services.EventToProto(event))
+}
+
+func toProto(events []*domain.Event) []*gen.Event {
+	result := make([]*gen.Event, len(events))
+	for i, event := range events {
+		result[i] = services.EventToProto(event)
+	}
+	return result
+}
+
+func eventError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrEventNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidEvent):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, domain.ErrEventClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrNotOrganizer):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Event error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
 }
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain/services"
+	"github.com/XRS0/ToTalkB/event_manager/internal/infrastructure/qr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPhotoSize ограничивает размер загружаемой фотографии QR-кода
+const maxPhotoSize = 10 << 20
+
+type QueueHandler struct {
+	queueService *services.EventQueueService
+}
+
+func NewQueueHandler(queueService *services.EventQueueService) *QueueHandler {
+	return &QueueHandler{
+		queueService: queueService,
+	}
+}
+
+type checkInInput struct {
+	Token     string `json:"token" binding:"required"`
+	CounterID string `json:"counter_id"`
+}
+
+// CheckInQR отдаёт QR-код с токеном отметки о прибытии для записи вызывающего пользователя
+func (h *QueueHandler) CheckInQR(c *gin.Context) {
+	token, err := h.queueService.CheckInToken(c.Request.Context(), c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		queueError(c, err)
+		return
+	}
+
+	image, err := qr.Render(token)
+	if err != nil {
+		queueError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, qr.ContentType, image)
+}
+
+// CheckIn отмечает прибытие по токену в JSON или по фотографии QR-кода в поле photo формы;
+// стойка передаётся в counter_id. Отмечать может только организатор мероприятия
+func (h *QueueHandler) CheckIn(c *gin.Context) {
+	input, err := h.checkInInput(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	queue, err := h.queueService.CheckIn(c.Request.Context(), input.Token, c.GetString("userId"), input.CounterID)
+	if err != nil {
+		queueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+func (h *QueueHandler) checkInInput(c *gin.Context) (*checkInInput, error) {
+	if c.ContentType() != gin.MIMEMultipartPOSTForm {
+		var input checkInInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			return nil, err
+		}
+		return &input, nil
+	}
+
+	header, err := c.FormFile("photo")
+	if err != nil {
+		return nil, err
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	photo, err := io.ReadAll(io.LimitReader(file, maxPhotoSize))
+	if err != nil {
+		return nil, err
+	}
+	token, err := qr.Scan(photo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidCheckIn, err)
+	}
+	return &checkInInput{Token: token, CounterID: c.PostForm("counter_id")}, nil
+}
+
+func queueError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrEventNotFound), errors.Is(err, domain.ErrNotInQueue):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidCheckIn), errors.Is(err, domain.ErrInvalidEvent):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrNotOrganizer):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrNotYourTurn), errors.Is(err, domain.ErrNotActive), errors.Is(err, domain.ErrCounterBusy),
+		errors.Is(err, domain.ErrQueueClosed), errors.Is(err, domain.ErrEventClosed),
+		errors.Is(err, domain.ErrQueueArchived), errors.Is(err, domain.ErrInvalidQueueTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrQueuePaused):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Queue error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
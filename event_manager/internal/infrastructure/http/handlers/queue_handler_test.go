@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestQueueError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{domain.ErrEventNotFound, http.StatusNotFound},
+		{domain.ErrNotInQueue, http.StatusNotFound},
+		{domain.ErrInvalidCheckIn, http.StatusBadRequest},
+		{fmt.Errorf("%w: counter is required", domain.ErrInvalidEvent), http.StatusBadRequest},
+		{domain.ErrNotOrganizer, http.StatusForbidden},
+		{domain.ErrNotYourTurn, http.StatusConflict},
+		{domain.ErrQueueClosed, http.StatusConflict},
+		{domain.ErrEventClosed, http.StatusConflict},
+		{domain.ErrQueueArchived, http.StatusConflict},
+		{fmt.Errorf("%w: open -> drained", domain.ErrInvalidQueueTransition), http.StatusConflict},
+		{domain.ErrQueuePaused, http.StatusServiceUnavailable},
+		{errors.New("connection refused"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			queueError(c, tt.err)
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
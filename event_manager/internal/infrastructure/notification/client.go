@@ -39,6 +39,18 @@ func (c *Client) SendNotification(ctx context.Context, notificationType string,
 	return resp, nil
 }
 
+func (c *Client) SendToUser(ctx context.Context, userID int32, notificationType string, payload []byte) (*gen.SendNotificationResponse, error) {
+	resp, err := c.client.SendNotification(ctx, &gen.SendNotificationRequest{
+		UserId:  userID,
+		Type:    notificationType,
+		Payload: payload,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send notification: %w", err)
+	}
+	return resp, nil
+}
+
 func (c *Client) Close() error {
 	return c.conn.Close()
 }
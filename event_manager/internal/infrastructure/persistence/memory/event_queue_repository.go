@@ -0,0 +1,407 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type InMemoryEventQueueRepository struct {
+	entries map[string]map[string]*domain.EventQueue
+	// joins — сколько раз вставала в очередь каждая запись, по id записи
+	joins map[string]int
+	// cursors — где остановился обход полос, по id события
+	cursors map[string]domain.LaneCursor
+	// services — завершённые обслуживания по id события, от старых к новым
+	services map[string][]domain.ServiceRecord
+	tickets  int64
+	mu       sync.RWMutex
+}
+
+func NewInMemoryEventQueueRepository() *InMemoryEventQueueRepository {
+	return &InMemoryEventQueueRepository{
+		entries:  make(map[string]map[string]*domain.EventQueue),
+		joins:    make(map[string]int),
+		cursors:  make(map[string]domain.LaneCursor),
+		services: make(map[string][]domain.ServiceRecord),
+	}
+}
+
+func (r *InMemoryEventQueueRepository) Join(ctx context.Context, eventID, userID, lane string, capacity int, settings domain.QueueSettings) (*domain.EventQueue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, exists := r.entries[eventID]
+	if !exists {
+		entries = make(map[string]*domain.EventQueue)
+		r.entries[eventID] = entries
+	}
+
+	now := time.Now()
+	entry, exists := entries[userID]
+	if exists && entry.InQueue() {
+		return nil, domain.ErrAlreadyInQueue
+	}
+	if exists && settings.MaxPerUser > 0 && r.joins[entry.ID] >= settings.MaxPerUser {
+		return nil, domain.ErrJoinLimit
+	}
+	if capacity > 0 && r.seats(eventID, userID) >= capacity {
+		return nil, domain.ErrEventFull
+	}
+
+	waiting := r.count(eventID, domain.QueueStatusWaiting)
+	waitlisted := r.count(eventID, domain.QueueStatusWaitlisted)
+	if settings.MaxTotal > 0 && waiting+waitlisted >= settings.MaxTotal {
+		return nil, domain.ErrQueueFull
+	}
+	status := domain.QueueStatusWaiting
+	if waitlisted > 0 || (settings.MaxActive > 0 && waiting >= settings.MaxActive) {
+		status = domain.QueueStatusWaitlisted
+	}
+
+	if !exists {
+		entry = &domain.EventQueue{
+			ID:        uuid.New().String(),
+			EventID:   eventID,
+			UserID:    userID,
+			CreatedAt: now,
+		}
+		entries[userID] = entry
+	}
+	r.tickets++
+	r.joins[entry.ID]++
+	entry.Status = string(status)
+	entry.Lane = lane
+	entry.Counter = ""
+	entry.CalledAt = nil
+	entry.ExpiresAt = nil
+	entry.Ticket = r.tickets
+	entry.UpdatedAt = now
+
+	return r.snapshot(entry), nil
+}
+
+func (r *InMemoryEventQueueRepository) SetLane(ctx context.Context, eventID, userID, lane string) (*domain.EventQueue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.entries[eventID][userID]
+	if !exists {
+		return nil, domain.ErrNotInQueue
+	}
+	if !entry.Waiting() {
+		return nil, domain.ErrNotWaiting
+	}
+	entry.Lane = lane
+	entry.UpdatedAt = time.Now()
+	return r.snapshot(entry), nil
+}
+
+func (r *InMemoryEventQueueRepository) Leave(ctx context.Context, eventID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.entries[eventID][userID]
+	if !exists || !entry.InQueue() {
+		return domain.ErrNotInQueue
+	}
+
+	entry.Status = string(domain.QueueStatusCancelled)
+	entry.ExpiresAt = nil
+	entry.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *InMemoryEventQueueRepository) GetByEventID(ctx context.Context, eventID string) ([]*domain.EventQueue, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	queues := make([]*domain.EventQueue, 0, len(r.entries[eventID]))
+	for _, entry := range r.entries[eventID] {
+		queues = append(queues, r.snapshot(entry))
+	}
+	// сначала живая очередь, затем лист ожидания, затем остальные записи
+	rank := func(q *domain.EventQueue) int {
+		switch q.Status {
+		case string(domain.QueueStatusWaiting):
+			return 0
+		case string(domain.QueueStatusWaitlisted):
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(queues, func(i, j int) bool {
+		a, b := queues[i], queues[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		if a.Waiting() {
+			return a.Ticket < b.Ticket
+		}
+		return a.UpdatedAt.Before(b.UpdatedAt)
+	})
+
+	return queues, nil
+}
+
+func (r *InMemoryEventQueueRepository) GetEntry(ctx context.Context, eventID, userID string) (*domain.EventQueue, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.entries[eventID][userID]
+	if !exists {
+		return nil, domain.ErrNotInQueue
+	}
+	return r.snapshot(entry), nil
+}
+
+func (r *InMemoryEventQueueRepository) ProcessNext(ctx context.Context, eventID, counter string, settings domain.QueueSettings) (*domain.EventQueue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.busy(eventID, counter) {
+		return nil, domain.ErrCounterBusy
+	}
+	next, cursor := r.next(eventID, settings)
+	if next == nil {
+		return nil, domain.ErrQueueEmpty
+	}
+	return r.activate(next, counter, cursor, settings.TurnTimeout), nil
+}
+
+func (r *InMemoryEventQueueRepository) Activate(ctx context.Context, eventID, userID, counter string, settings domain.QueueSettings) (*domain.EventQueue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.busy(eventID, counter) {
+		return nil, domain.ErrCounterBusy
+	}
+	next, cursor := r.next(eventID, settings)
+	if next == nil || next.UserID != userID {
+		return nil, domain.ErrNotYourTurn
+	}
+	return r.activate(next, counter, cursor, settings.TurnTimeout), nil
+}
+
+// busy сообщает, что стойка уже обслуживает вызванного пользователя
+func (r *InMemoryEventQueueRepository) busy(eventID, counter string) bool {
+	if counter == "" {
+		return false
+	}
+	for _, entry := range r.entries[eventID] {
+		if entry.Status == string(domain.QueueStatusActive) && entry.Counter == counter {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *InMemoryEventQueueRepository) CounterStats(ctx context.Context, eventID string) ([]*domain.CounterStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counters := make(map[string]*domain.CounterStats)
+	service := make(map[string]time.Duration)
+	for _, entry := range r.entries[eventID] {
+		if entry.Counter == "" {
+			continue
+		}
+		stats, exists := counters[entry.Counter]
+		if !exists {
+			stats = &domain.CounterStats{Counter: entry.Counter}
+			counters[entry.Counter] = stats
+		}
+		switch domain.EventQueueStatus(entry.Status) {
+		case domain.QueueStatusActive:
+			stats.Serving = entry.UserID
+		case domain.QueueStatusCompleted:
+			stats.Served++
+			if entry.CalledAt != nil {
+				service[entry.Counter] += entry.UpdatedAt.Sub(*entry.CalledAt)
+			}
+		case domain.QueueStatusNoShow:
+			stats.NoShows++
+		}
+	}
+
+	result := make([]*domain.CounterStats, 0, len(counters))
+	for _, stats := range counters {
+		if stats.Served > 0 {
+			stats.AverageService = service[stats.Counter] / time.Duration(stats.Served)
+		}
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Counter < result[j].Counter })
+	return result, nil
+}
+
+func (r *InMemoryEventQueueRepository) LaneState(ctx context.Context, eventID string) (*domain.LaneState, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.laneState(eventID), nil
+}
+
+func (r *InMemoryEventQueueRepository) laneState(eventID string) *domain.LaneState {
+	state := &domain.LaneState{Cursor: r.cursors[eventID], Waiting: make(map[string]int)}
+	for _, entry := range r.entries[eventID] {
+		if entry.Status == string(domain.QueueStatusWaiting) {
+			state.Waiting[entry.Lane]++
+		}
+	}
+	return state
+}
+
+// next возвращает запись, которую политика очереди вызовет следующей, и обход полос после её вызова
+func (r *InMemoryEventQueueRepository) next(eventID string, settings domain.QueueSettings) (*domain.EventQueue, domain.LaneCursor) {
+	state := r.laneState(eventID)
+	lane, cursor, ok := settings.NextLane(state.Cursor, state.Waiting)
+	if !ok {
+		return nil, state.Cursor
+	}
+	var next *domain.EventQueue
+	for _, entry := range r.entries[eventID] {
+		if entry.Status == string(domain.QueueStatusWaiting) && entry.Lane == lane && (next == nil || entry.Ticket < next.Ticket) {
+			next = entry
+		}
+	}
+	return next, cursor
+}
+
+func (r *InMemoryEventQueueRepository) activate(entry *domain.EventQueue, counter string, cursor domain.LaneCursor, turnTimeout time.Duration) *domain.EventQueue {
+	now := time.Now()
+	r.cursors[entry.EventID] = cursor
+	entry.Status = string(domain.QueueStatusActive)
+	entry.Counter = counter
+	entry.CalledAt = &now
+	entry.UpdatedAt = now
+	if turnTimeout > 0 {
+		deadline := now.Add(turnTimeout)
+		entry.ExpiresAt = &deadline
+	}
+	return r.snapshot(entry)
+}
+
+func (r *InMemoryEventQueueRepository) Finish(ctx context.Context, eventID, userID string, status domain.EventQueueStatus) (*domain.EventQueue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.entries[eventID][userID]
+	if !exists || entry.Status != string(domain.QueueStatusActive) {
+		return nil, domain.ErrNotActive
+	}
+	now := time.Now()
+	entry.Status = string(status)
+	entry.ExpiresAt = nil
+	entry.UpdatedAt = now
+	if status == domain.QueueStatusCompleted && entry.CalledAt != nil {
+		r.services[eventID] = append(r.services[eventID], domain.ServiceRecord{
+			Counter:     entry.Counter,
+			Duration:    now.Sub(*entry.CalledAt),
+			CompletedAt: now,
+		})
+	}
+	return r.snapshot(entry), nil
+}
+
+func (r *InMemoryEventQueueRepository) RecentServices(ctx context.Context, eventID string, limit int) ([]domain.ServiceRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	services := r.services[eventID]
+	services = services[max(len(services)-limit, 0):]
+	return append([]domain.ServiceRecord(nil), services...), nil
+}
+
+func (r *InMemoryEventQueueRepository) ExpireTurns(ctx context.Context, now time.Time, limit int) ([]*domain.EventQueue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired []*domain.EventQueue
+	for _, entries := range r.entries {
+		for _, entry := range entries {
+			if len(expired) == limit {
+				return expired, nil
+			}
+			if entry.Status != string(domain.QueueStatusActive) || entry.ExpiresAt == nil || entry.ExpiresAt.After(now) {
+				continue
+			}
+			entry.Status = string(domain.QueueStatusNoShow)
+			entry.ExpiresAt = nil
+			entry.UpdatedAt = now
+			expired = append(expired, r.snapshot(entry))
+		}
+	}
+	return expired, nil
+}
+
+func (r *InMemoryEventQueueRepository) Promote(ctx context.Context, eventID string, maxActive int) ([]*domain.EventQueue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var waitlist []*domain.EventQueue
+	for _, entry := range r.entries[eventID] {
+		if entry.Status == string(domain.QueueStatusWaitlisted) {
+			waitlist = append(waitlist, entry)
+		}
+	}
+	sort.Slice(waitlist, func(i, j int) bool { return waitlist[i].Ticket < waitlist[j].Ticket })
+	if maxActive > 0 {
+		free := max(maxActive-r.count(eventID, domain.QueueStatusWaiting), 0)
+		waitlist = waitlist[:min(free, len(waitlist))]
+	}
+
+	now := time.Now()
+	for _, entry := range waitlist {
+		entry.Status = string(domain.QueueStatusWaiting)
+		entry.UpdatedAt = now
+	}
+	promoted := make([]*domain.EventQueue, len(waitlist))
+	for i, entry := range waitlist {
+		promoted[i] = r.snapshot(entry)
+	}
+	return promoted, nil
+}
+
+// seats считает места мероприятия, занятые другими пользователями: стоящими в очереди и обслуженными
+func (r *InMemoryEventQueueRepository) seats(eventID, userID string) int {
+	seats := 0
+	for _, entry := range r.entries[eventID] {
+		if entry.UserID != userID && (entry.InQueue() || entry.Status == string(domain.QueueStatusCompleted)) {
+			seats++
+		}
+	}
+	return seats
+}
+
+func (r *InMemoryEventQueueRepository) count(eventID string, status domain.EventQueueStatus) int {
+	count := 0
+	for _, entry := range r.entries[eventID] {
+		if entry.Status == string(status) {
+			count++
+		}
+	}
+	return count
+}
+
+// snapshot копирует запись и вычисляет её позицию по талону в своём списке:
+// в полосе живой очереди или в листе ожидания
+func (r *InMemoryEventQueueRepository) snapshot(entry *domain.EventQueue) *domain.EventQueue {
+	copied := *entry
+	copied.Position = 0
+	if !entry.Waiting() {
+		return &copied
+	}
+	for _, other := range r.entries[entry.EventID] {
+		sameList := entry.Status == string(domain.QueueStatusWaitlisted) || other.Lane == entry.Lane
+		if other.Status == entry.Status && sameList && other.Ticket <= entry.Ticket {
+			copied.Position++
+		}
+	}
+	return &copied
+}
@@ -3,7 +3,9 @@ package memory
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
 )
@@ -27,7 +29,8 @@ func (r *InMemoryEventRepository) Save(ctx context.Context, event *domain.Event)
 		return fmt.Errorf("event ID is required")
 	}
 
-	r.events[event.ID] = event
+	stored := *event
+	r.events[event.ID] = &stored
 	return nil
 }
 
@@ -37,10 +40,12 @@ func (r *InMemoryEventRepository) GetByID(ctx context.Context, id string) (*doma
 
 	event, exists := r.events[id]
 	if !exists {
-		return nil, fmt.Errorf("event not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", domain.ErrEventNotFound, id)
 	}
 
-	return event, nil
+	// отдаём копию, как и база: изменения вызывающего не должны попадать в хранилище без Update
+	copied := *event
+	return &copied, nil
 }
 
 func (r *InMemoryEventRepository) Update(ctx context.Context, event *domain.Event) error {
@@ -51,11 +56,64 @@ func (r *InMemoryEventRepository) Update(ctx context.Context, event *domain.Even
 		return fmt.Errorf("event ID is required")
 	}
 
-	if _, exists := r.events[event.ID]; !exists {
-		return fmt.Errorf("event not found: %s", event.ID)
+	stored, exists := r.events[event.ID]
+	if !exists {
+		return fmt.Errorf("%w: %s", domain.ErrEventNotFound, event.ID)
 	}
 
-	r.events[event.ID] = event
+	// этап мероприятия, состояние и настройки очереди меняются только своими методами
+	updated := *event
+	updated.State = stored.State
+	updated.QueueState = stored.QueueState
+	updated.QueueSettings = stored.QueueSettings
+	r.events[event.ID] = &updated
+	return nil
+}
+
+func (r *InMemoryEventRepository) TransitionEvent(ctx context.Context, eventID string, to domain.EventState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, exists := r.events[eventID]
+	if !exists {
+		return fmt.Errorf("%w: %s", domain.ErrEventNotFound, eventID)
+	}
+	if err := domain.CheckTransition(event.State, to); err != nil {
+		return err
+	}
+	event.State = to
+	event.QueueState = domain.QueueAfter(event.QueueState, to)
+	event.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *InMemoryEventRepository) TransitionQueue(ctx context.Context, eventID string, transition domain.QueueTransition) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, exists := r.events[eventID]
+	if !exists {
+		return false, fmt.Errorf("%w: %s", domain.ErrEventNotFound, eventID)
+	}
+	changed, err := transition.Check(event.QueueState)
+	if err != nil || !changed {
+		return false, err
+	}
+	event.QueueState = transition.To
+	event.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (r *InMemoryEventRepository) UpdateQueueSettings(ctx context.Context, eventID string, settings domain.QueueSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, exists := r.events[eventID]
+	if !exists {
+		return fmt.Errorf("%w: %s", domain.ErrEventNotFound, eventID)
+	}
+	event.QueueSettings = settings
+	event.UpdatedAt = time.Now()
 	return nil
 }
 
@@ -64,7 +122,7 @@ func (r *InMemoryEventRepository) Delete(ctx context.Context, id string) error {
 	defer r.mu.Unlock()
 
 	if _, exists := r.events[id]; !exists {
-		return fmt.Errorf("event not found: %s", id)
+		return fmt.Errorf("%w: %s", domain.ErrEventNotFound, id)
 	}
 
 	delete(r.events, id)
@@ -77,8 +135,29 @@ func (r *InMemoryEventRepository) List(ctx context.Context) ([]*domain.Event, er
 
 	events := make([]*domain.Event, 0, len(r.events))
 	for _, event := range r.events {
-		events = append(events, event)
+		copied := *event
+		events = append(events, &copied)
+	}
+
+	return events, nil
+}
+
+func (r *InMemoryEventRepository) GetAll(ctx context.Context) ([]*domain.Event, error) {
+	return r.List(ctx)
+}
+
+func (r *InMemoryEventRepository) ListVisible(ctx context.Context, userID string) ([]*domain.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]*domain.Event, 0)
+	for _, event := range r.events {
+		if event.Type == domain.EventTypeScheduled && event.VisibleTo(userID) {
+			copied := *event
+			events = append(events, &copied)
+		}
 	}
+	sort.Slice(events, func(i, j int) bool { return events[i].StartsAt.Before(events[j].StartsAt) })
 
 	return events, nil
 }
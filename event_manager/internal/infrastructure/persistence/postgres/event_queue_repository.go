@@ -3,14 +3,25 @@ package postgres
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 	"time"
 
 	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// queueColumns вычисляет позицию по талону: место записи — число записей того же события
+// в том же списке (полоса живой очереди или лист ожидания) с талоном не больше её собственного
+const queueColumns = `q.id, q.event_id, q.user_id, q.status, q.ticket, q.lane,
+	CASE WHEN q.status IN ('` + string(domain.QueueStatusWaiting) + `', '` + string(domain.QueueStatusWaitlisted) + `') THEN (
+		SELECT COUNT(*) FROM event_queues w
+		WHERE w.event_id = q.event_id AND w.status = q.status AND w.ticket <= q.ticket
+		  AND (q.status = '` + string(domain.QueueStatusWaitlisted) + `' OR w.lane = q.lane)
+	) ELSE 0 END AS position,
+	q.counter, q.called_at, q.expires_at, q.created_at, q.updated_at`
+
 type EventQueueRepository struct {
 	db *sql.DB
 }
@@ -19,75 +30,198 @@ func NewEventQueueRepository(db *sql.DB) *EventQueueRepository {
 	return &EventQueueRepository{db: db}
 }
 
-func (r *EventQueueRepository) Save(queue *domain.EventQueue) error {
-	if queue.ID == "" {
-		queue.ID = uuid.New().String()
+func scanQueue(row rowScanner) (*domain.EventQueue, error) {
+	queue := &domain.EventQueue{}
+	err := row.Scan(
+		&queue.ID,
+		&queue.EventID,
+		&queue.UserID,
+		&queue.Status,
+		&queue.Ticket,
+		&queue.Lane,
+		&queue.Position,
+		&queue.Counter,
+		&queue.CalledAt,
+		&queue.ExpiresAt,
+		&queue.CreatedAt,
+		&queue.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
 	}
-	queue.CreatedAt = time.Now()
-	queue.UpdatedAt = time.Now()
+	return queue, nil
+}
 
-	query := `
-		INSERT INTO event_queues (id, event_id, user_id, status, position, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`
+func (r *EventQueueRepository) Join(ctx context.Context, eventID, userID, lane string, capacity int, settings domain.QueueSettings) (*domain.EventQueue, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	_, err := r.db.Exec(query,
-		queue.ID,
-		queue.EventID,
-		queue.UserID,
-		queue.Status,
-		queue.Position,
-		queue.CreatedAt,
-		queue.UpdatedAt,
+	state, err := lockEvent(ctx, tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	// под той же блокировкой перепроверяем, что очередь не закрыли и не приостановили после проверки в сервисе
+	if err := state.AcceptsJoins(); err != nil {
+		return nil, err
+	}
+
+	var (
+		status string
+		joins  int
 	)
+	err = tx.QueryRowContext(ctx,
+		"SELECT status, joins FROM event_queues WHERE event_id = $1 AND user_id = $2",
+		eventID, userID,
+	).Scan(&status, &joins)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	switch domain.EventQueueStatus(status) {
+	case domain.QueueStatusWaiting, domain.QueueStatusWaitlisted, domain.QueueStatusActive:
+		return nil, domain.ErrAlreadyInQueue
+	}
+	if settings.MaxPerUser > 0 && joins >= settings.MaxPerUser {
+		return nil, domain.ErrJoinLimit
+	}
+	if capacity > 0 {
+		// место занимают стоящие в очереди и уже обслуженные, кроме самого пользователя
+		var seats int
+		err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM event_queues
+			WHERE event_id = $1 AND user_id <> $2 AND status IN ($3, $4, $5, $6)`,
+			eventID, userID, domain.QueueStatusWaiting, domain.QueueStatusWaitlisted,
+			domain.QueueStatusActive, domain.QueueStatusCompleted,
+		).Scan(&seats)
+		if err != nil {
+			return nil, err
+		}
+		if seats >= capacity {
+			return nil, domain.ErrEventFull
+		}
+	}
+
+	var waiting, waitlisted int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE status = $2), COUNT(*) FILTER (WHERE status = $3)
+		FROM event_queues
+		WHERE event_id = $1`,
+		eventID, domain.QueueStatusWaiting, domain.QueueStatusWaitlisted,
+	).Scan(&waiting, &waitlisted)
+	if err != nil {
+		return nil, err
+	}
+	if settings.MaxTotal > 0 && waiting+waitlisted >= settings.MaxTotal {
+		return nil, domain.ErrQueueFull
+	}
+	// пока кто-то ждёт в листе ожидания, новые пользователи встают за ним, а не обгоняют его
+	status = string(domain.QueueStatusWaiting)
+	if waitlisted > 0 || (settings.MaxActive > 0 && waiting >= settings.MaxActive) {
+		status = string(domain.QueueStatusWaitlisted)
+	}
+
+	// Повторное вступление переиспользует строку: на (event_id, user_id) стоит уникальный индекс,
+	// а EXCLUDED.ticket — свежий номер из последовательности
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO event_queues (id, event_id, user_id, status, lane, joins, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, 1, $6, $6)
+		ON CONFLICT (event_id, user_id) DO UPDATE
+		SET status = EXCLUDED.status, lane = EXCLUDED.lane, ticket = EXCLUDED.ticket, joins = event_queues.joins + 1,
+		    counter = '', called_at = NULL, expires_at = NULL, updated_at = EXCLUDED.updated_at
+		RETURNING id`,
+		uuid.New().String(), eventID, userID, status, lane, time.Now(),
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
 
-	return err
+	queue, err := scanQueue(tx.QueryRowContext(ctx, "SELECT "+queueColumns+" FROM event_queues q WHERE q.id = $1", id))
+	if err != nil {
+		return nil, err
+	}
+	return queue, tx.Commit()
 }
 
-func (r *EventQueueRepository) FindByEventID(eventID string) ([]*domain.EventQueue, error) {
-	query := `
-		SELECT id, event_id, user_id, status, position, created_at, updated_at
-		FROM event_queues
-		WHERE event_id = $1
-		ORDER BY position ASC
-	`
+func (r *EventQueueRepository) SetLane(ctx context.Context, eventID, userID, lane string) (*domain.EventQueue, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	rows, err := r.db.Query(query, eventID)
+	// вызовы идут под блокировкой события, поэтому перевод не разойдётся с выбором следующего
+	if _, err := lockEvent(ctx, tx, eventID); err != nil {
+		return nil, err
+	}
+	var status string
+	err = tx.QueryRowContext(ctx,
+		"SELECT status FROM event_queues WHERE event_id = $1 AND user_id = $2 FOR UPDATE",
+		eventID, userID,
+	).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotInQueue
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != string(domain.QueueStatusWaiting) && status != string(domain.QueueStatusWaitlisted) {
+		return nil, domain.ErrNotWaiting
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE event_queues SET lane = $3, updated_at = $4 WHERE event_id = $1 AND user_id = $2",
+		eventID, userID, lane, time.Now(),
+	)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var queues []*domain.EventQueue
-	for rows.Next() {
-		queue := &domain.EventQueue{}
-		err := rows.Scan(
-			&queue.ID,
-			&queue.EventID,
-			&queue.UserID,
-			&queue.Status,
-			&queue.Position,
-			&queue.CreatedAt,
-			&queue.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		queues = append(queues, queue)
+	// позиция перечитывается после перевода: в RETURNING подзапрос видит полосы до изменения
+	queue, err := scanQueue(tx.QueryRowContext(ctx,
+		"SELECT "+queueColumns+" FROM event_queues q WHERE q.event_id = $1 AND q.user_id = $2",
+		eventID, userID,
+	))
+	if err != nil {
+		return nil, err
 	}
+	return queue, tx.Commit()
+}
 
-	return queues, nil
+func (r *EventQueueRepository) Leave(ctx context.Context, eventID, userID string) error {
+	// Позиции остальных считаются по талонам, поэтому сдвигать никого не нужно
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE event_queues
+		SET status = $3, expires_at = NULL, updated_at = $4
+		WHERE event_id = $1 AND user_id = $2 AND status IN ($5, $6, $7)`,
+		eventID, userID, domain.QueueStatusCancelled, time.Now(),
+		domain.QueueStatusWaiting, domain.QueueStatusWaitlisted, domain.QueueStatusActive,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotInQueue
+	}
+	return nil
 }
 
-func (r *EventQueueRepository) FindByUserID(userID string) ([]*domain.EventQueue, error) {
+func (r *EventQueueRepository) GetByEventID(ctx context.Context, eventID string) ([]*domain.EventQueue, error) {
 	query := `
-		SELECT id, event_id, user_id, status, position, created_at, updated_at
-		FROM event_queues
-		WHERE user_id = $1
-		ORDER BY created_at DESC
+		SELECT ` + queueColumns + `
+		FROM event_queues q
+		WHERE q.event_id = $1
+		ORDER BY CASE q.status WHEN $2 THEN 0 WHEN $3 THEN 1 ELSE 2 END,
+		         CASE WHEN q.status IN ($2, $3) THEN q.ticket END,
+		         q.updated_at
 	`
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryContext(ctx, query, eventID, domain.QueueStatusWaiting, domain.QueueStatusWaitlisted)
 	if err != nil {
 		return nil, err
 	}
@@ -95,193 +229,407 @@ func (r *EventQueueRepository) FindByUserID(userID string) ([]*domain.EventQueue
 
 	var queues []*domain.EventQueue
 	for rows.Next() {
-		queue := &domain.EventQueue{}
-		err := rows.Scan(
-			&queue.ID,
-			&queue.EventID,
-			&queue.UserID,
-			&queue.Status,
-			&queue.Position,
-			&queue.CreatedAt,
-			&queue.UpdatedAt,
-		)
+		queue, err := scanQueue(rows)
 		if err != nil {
 			return nil, err
 		}
 		queues = append(queues, queue)
 	}
 
-	return queues, nil
+	return queues, rows.Err()
 }
 
-func (r *EventQueueRepository) Update(queue *domain.EventQueue) error {
-	queue.UpdatedAt = time.Now()
-
+func (r *EventQueueRepository) GetEntry(ctx context.Context, eventID, userID string) (*domain.EventQueue, error) {
 	query := `
-		UPDATE event_queues
-		SET status = $1, position = $2, updated_at = $3
-		WHERE id = $4
+		SELECT ` + queueColumns + `
+		FROM event_queues q
+		WHERE q.event_id = $1 AND q.user_id = $2
 	`
 
-	_, err := r.db.Exec(query,
-		queue.Status,
-		queue.Position,
-		queue.UpdatedAt,
-		queue.ID,
-	)
+	queue, err := scanQueue(r.db.QueryRowContext(ctx, query, eventID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotInQueue
+	}
+	return queue, err
+}
 
-	return err
+func (r *EventQueueRepository) ProcessNext(ctx context.Context, eventID, counter string, settings domain.QueueSettings) (*domain.EventQueue, error) {
+	return r.activate(ctx, eventID, "", counter, settings)
 }
 
-func (r *EventQueueRepository) Delete(id string) error {
-	query := `DELETE FROM event_queues WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+func (r *EventQueueRepository) Activate(ctx context.Context, eventID, userID, counter string, settings domain.QueueSettings) (*domain.EventQueue, error) {
+	return r.activate(ctx, eventID, userID, counter, settings)
 }
 
-func (r *EventQueueRepository) GetNext(eventID string) (*domain.EventQueue, error) {
-	query := `
-		SELECT id, event_id, user_id, status, position, created_at, updated_at
+func (r *EventQueueRepository) CounterStats(ctx context.Context, eventID string) ([]*domain.CounterStats, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT counter,
+		       COALESCE(MAX(user_id) FILTER (WHERE status = $2), ''),
+		       COUNT(*) FILTER (WHERE status = $3),
+		       COUNT(*) FILTER (WHERE status = $4),
+		       COALESCE(EXTRACT(EPOCH FROM AVG(updated_at - called_at) FILTER (WHERE status = $3)), 0)
 		FROM event_queues
-		WHERE event_id = $1 AND status = $2
-		ORDER BY position ASC
-		LIMIT 1
-	`
-
-	queue := &domain.EventQueue{}
-	err := r.db.QueryRow(query, eventID, domain.QueueStatusWaiting).Scan(
-		&queue.ID,
-		&queue.EventID,
-		&queue.UserID,
-		&queue.Status,
-		&queue.Position,
-		&queue.CreatedAt,
-		&queue.UpdatedAt,
+		WHERE event_id = $1 AND counter <> ''
+		GROUP BY counter
+		ORDER BY counter`,
+		eventID, domain.QueueStatusActive, domain.QueueStatusCompleted, domain.QueueStatusNoShow,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	if err == sql.ErrNoRows {
-		return nil, nil
+	var counters []*domain.CounterStats
+	for rows.Next() {
+		var (
+			stats   domain.CounterStats
+			average float64
+		)
+		if err := rows.Scan(&stats.Counter, &stats.Serving, &stats.Served, &stats.NoShows, &average); err != nil {
+			return nil, err
+		}
+		stats.AverageService = time.Duration(average * float64(time.Second))
+		counters = append(counters, &stats)
+	}
+	return counters, rows.Err()
+}
+
+func (r *EventQueueRepository) LaneState(ctx context.Context, eventID string) (*domain.LaneState, error) {
+	var state domain.LaneState
+	err := r.db.QueryRowContext(ctx,
+		"SELECT queue_lane, queue_lane_calls FROM events WHERE id = $1", eventID,
+	).Scan(&state.Cursor.Lane, &state.Cursor.Calls)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrEventNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
-
-	return queue, nil
+	if state.Waiting, err = waitingByLane(ctx, r.db, eventID); err != nil {
+		return nil, err
+	}
+	return &state, nil
 }
 
-func (r *EventQueueRepository) GetPosition(eventID string, userID string) (int, error) {
-	query := `
-		SELECT position
-		FROM event_queues
-		WHERE event_id = $1 AND user_id = $2
-	`
+// activate вызывает следующего по политике очереди к стойке counter;
+// с непустым userID — только если следующий именно он
+func (r *EventQueueRepository) activate(ctx context.Context, eventID, userID, counter string, settings domain.QueueSettings) (*domain.EventQueue, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	var position int
-	err := r.db.QueryRow(query, eventID, userID).Scan(&position)
-	if err == sql.ErrNoRows {
-		return 0, fmt.Errorf("user not found in queue")
+	// обход полос хранится в строке события, поэтому вызовы идут по одному под её блокировкой
+	var (
+		cursor domain.LaneCursor
+		event  domain.Event
+	)
+	err = tx.QueryRowContext(ctx,
+		"SELECT queue_lane, queue_lane_calls, state, queue_state FROM events WHERE id = $1 FOR NO KEY UPDATE", eventID,
+	).Scan(&cursor.Lane, &cursor.Calls, &event.State, &event.QueueState)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := event.AcceptsCalls(); err != nil {
+		return nil, err
+	}
+	if counter != "" {
+		var busy bool
+		err := tx.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM event_queues WHERE event_id = $1 AND status = $2 AND counter = $3)",
+			eventID, domain.QueueStatusActive, counter,
+		).Scan(&busy)
+		if err != nil {
+			return nil, err
+		}
+		if busy {
+			return nil, domain.ErrCounterBusy
+		}
+	}
+	waiting, err := waitingByLane(ctx, tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	lane, next, ok := settings.NextLane(cursor, waiting)
+
+	// SKIP LOCKED: запись, которую прямо сейчас забирает Leave, не задерживает вызов
+	var id, first string
+	if ok {
+		err = tx.QueryRowContext(ctx, `
+			SELECT id, user_id FROM event_queues
+			WHERE event_id = $1 AND status = $2 AND lane = $3
+			ORDER BY ticket
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED`,
+			eventID, domain.QueueStatusWaiting, lane,
+		).Scan(&id, &first)
+	}
+	if !ok || errors.Is(err, sql.ErrNoRows) {
+		if userID != "" {
+			return nil, domain.ErrNotYourTurn
+		}
+		return nil, domain.ErrQueueEmpty
 	}
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	if userID != "" && first != userID {
+		return nil, domain.ErrNotYourTurn
+	}
+
+	if next != cursor {
+		_, err := tx.ExecContext(ctx,
+			"UPDATE events SET queue_lane = $2, queue_lane_calls = $3 WHERE id = $1",
+			eventID, next.Lane, next.Calls,
+		)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return position, nil
+	now := time.Now()
+	var expiresAt *time.Time
+	if settings.TurnTimeout > 0 {
+		deadline := now.Add(settings.TurnTimeout)
+		expiresAt = &deadline
+	}
+	queue, err := scanQueue(tx.QueryRowContext(ctx, `
+		UPDATE event_queues AS q
+		SET status = $2, counter = $3, called_at = $4, expires_at = $5, updated_at = $4
+		WHERE q.id = $1
+		RETURNING `+queueColumns,
+		id, domain.QueueStatusActive, counter, now, expiresAt,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return queue, tx.Commit()
 }
 
-func (r *EventQueueRepository) GetByEventID(ctx context.Context, eventID string) ([]*domain.EventQueue, error) {
-	query := `
-		SELECT id, event_id, user_id, status, position, created_at, updated_at
-		FROM event_queues
-		WHERE event_id = $1
-		ORDER BY position ASC
-	`
+func (r *EventQueueRepository) Finish(ctx context.Context, eventID, userID string, status domain.EventQueueStatus) (*domain.EventQueue, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	queue, err := scanQueue(tx.QueryRowContext(ctx, `
+		UPDATE event_queues AS q
+		SET status = $4, expires_at = NULL, updated_at = $5
+		WHERE q.event_id = $1 AND q.user_id = $2 AND q.status = $3
+		RETURNING `+queueColumns,
+		eventID, userID, domain.QueueStatusActive, status, time.Now(),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotActive
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// строка записи переиспользуется при повторном вступлении, поэтому длительность
+	// обслуживания сохраняется отдельно
+	if status == domain.QueueStatusCompleted && queue.CalledAt != nil {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO event_queue_services (event_id, counter, duration_ms, completed_at)
+			VALUES ($1, $2, $3, $4)`,
+			eventID, queue.Counter, queue.UpdatedAt.Sub(*queue.CalledAt).Milliseconds(), queue.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return queue, tx.Commit()
+}
 
-	rows, err := r.db.QueryContext(ctx, query, eventID)
+func (r *EventQueueRepository) RecentServices(ctx context.Context, eventID string, limit int) ([]domain.ServiceRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT counter, duration_ms, completed_at FROM (
+			SELECT id, counter, duration_ms, completed_at FROM event_queue_services
+			WHERE event_id = $1
+			ORDER BY id DESC
+			LIMIT $2
+		) AS recent
+		ORDER BY id`,
+		eventID, limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var queues []*domain.EventQueue
+	var records []domain.ServiceRecord
 	for rows.Next() {
-		queue := &domain.EventQueue{}
-		err := rows.Scan(
-			&queue.ID,
-			&queue.EventID,
-			&queue.UserID,
-			&queue.Status,
-			&queue.Position,
-			&queue.CreatedAt,
-			&queue.UpdatedAt,
+		var (
+			record   domain.ServiceRecord
+			duration int64
 		)
-		if err != nil {
+		if err := rows.Scan(&record.Counter, &duration, &record.CompletedAt); err != nil {
 			return nil, err
 		}
-		queues = append(queues, queue)
+		record.Duration = time.Duration(duration) * time.Millisecond
+		records = append(records, record)
 	}
-
-	return queues, nil
+	return records, rows.Err()
 }
 
-func (r *EventQueueRepository) GetUserPosition(ctx context.Context, eventID, userID string) (int, error) {
-	query := `
-		SELECT position
-		FROM event_queues
-		WHERE event_id = $1 AND user_id = $2
-	`
+func (r *EventQueueRepository) ExpireTurns(ctx context.Context, now time.Time, limit int) ([]*domain.EventQueue, error) {
+	// SKIP LOCKED позволяет нескольким экземплярам сервиса разбирать просрочки без двойной обработки
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE event_queues AS q
+		SET status = $3, expires_at = NULL, updated_at = $1
+		WHERE q.id IN (
+			SELECT id FROM event_queues
+			WHERE status = $2 AND expires_at <= $1
+			ORDER BY expires_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+queueColumns,
+		now, domain.QueueStatusActive, domain.QueueStatusNoShow, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	var position int
-	err := r.db.QueryRowContext(ctx, query, eventID, userID).Scan(&position)
-	if err == sql.ErrNoRows {
-		return 0, fmt.Errorf("user not found in queue")
+	var expired []*domain.EventQueue
+	for rows.Next() {
+		queue, err := scanQueue(rows)
+		if err != nil {
+			return nil, err
+		}
+		expired = append(expired, queue)
 	}
+	return expired, rows.Err()
+}
+
+func (r *EventQueueRepository) Promote(ctx context.Context, eventID string, maxActive int) ([]*domain.EventQueue, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	return position, nil
-}
+	// блокировка события не даёт новым пользователям встать в живую очередь в обход листа ожидания
+	if _, err := lockEvent(ctx, tx, eventID); err != nil {
+		return nil, err
+	}
 
-func (r *EventQueueRepository) ProcessNext(ctx context.Context, eventID string) (*domain.EventQueue, error) {
-	query := `
-		SELECT id, event_id, user_id, status, position, created_at, updated_at
-		FROM event_queues
-		WHERE event_id = $1 AND status = $2
-		ORDER BY position ASC
-		LIMIT 1
-	`
+	// LIMIT NULL снимает ограничение: без MaxActive переводятся все
+	var limit any
+	if maxActive > 0 {
+		var waiting int
+		err := tx.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM event_queues WHERE event_id = $1 AND status = $2",
+			eventID, domain.QueueStatusWaiting,
+		).Scan(&waiting)
+		if err != nil {
+			return nil, err
+		}
+		if waiting >= maxActive {
+			return nil, nil
+		}
+		limit = maxActive - waiting
+	}
 
-	queue := &domain.EventQueue{}
-	err := r.db.QueryRowContext(ctx, query, eventID, domain.QueueStatusWaiting).Scan(
-		&queue.ID,
-		&queue.EventID,
-		&queue.UserID,
-		&queue.Status,
-		&queue.Position,
-		&queue.CreatedAt,
-		&queue.UpdatedAt,
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE event_queues
+		SET status = $2, updated_at = $3
+		WHERE id IN (
+			SELECT id FROM event_queues
+			WHERE event_id = $1 AND status = $4
+			ORDER BY ticket
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`,
+		eventID, domain.QueueStatusWaiting, time.Now(), domain.QueueStatusWaitlisted, limit,
 	)
-
-	if err == sql.ErrNoRows {
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
 		return nil, nil
 	}
+
+	// позиции перечитываются после перевода: RETURNING видит очередь до изменения
+	rows, err = tx.QueryContext(ctx,
+		"SELECT "+queueColumns+" FROM event_queues q WHERE q.id = ANY($1) ORDER BY q.ticket",
+		pq.Array(ids),
+	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	var promoted []*domain.EventQueue
+	for rows.Next() {
+		queue, err := scanQueue(rows)
+		if err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, queue)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return promoted, tx.Commit()
+}
 
-	// Update status to active
-	queue.Status = string(domain.QueueStatusActive)
-	queue.UpdatedAt = time.Now()
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
 
-	updateQuery := `
-		UPDATE event_queues
-		SET status = $1, updated_at = $2
-		WHERE id = $3
-	`
-	_, err = r.db.ExecContext(ctx, updateQuery, queue.Status, queue.UpdatedAt, queue.ID)
+// waitingByLane считает ожидающих в каждой полосе живой очереди
+func waitingByLane(ctx context.Context, db queryer, eventID string) (map[string]int, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT lane, COUNT(*) FROM event_queues WHERE event_id = $1 AND status = $2 GROUP BY lane",
+		eventID, domain.QueueStatusWaiting,
+	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return queue, nil
+	waiting := make(map[string]int)
+	for rows.Next() {
+		var (
+			lane  string
+			count int
+		)
+		if err := rows.Scan(&lane, &count); err != nil {
+			return nil, err
+		}
+		waiting[lane] = count
+	}
+	return waiting, rows.Err()
+}
+
+// lockEvent блокирует строку события до конца транзакции: вступления в очередь и перевод
+// из листа ожидания идут по одному, иначе талон, выданный раньше, мог бы зафиксироваться
+// позже и отодвинуть уже ответившего клиента назад
+func lockEvent(ctx context.Context, tx *sql.Tx, eventID string) (state domain.QueueState, err error) {
+	err = tx.QueryRowContext(ctx, "SELECT queue_state FROM events WHERE id = $1 FOR NO KEY UPDATE", eventID).Scan(&state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", domain.ErrEventNotFound
+	}
+	return state, err
 }
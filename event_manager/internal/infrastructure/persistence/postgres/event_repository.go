@@ -3,11 +3,18 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/XRS0/ToTalkB/event_manager/internal/domain"
 )
 
+const eventColumns = `id, type, source, payload, status,
+		title, description, venue, starts_at, ends_at, timezone, capacity, organizer_id, visibility, state,
+		queue_state, queue_max_active, queue_max_total, queue_max_per_user, queue_turn_timeout,
+		queue_policy, queue_lanes, queue_counters, created_at, updated_at`
+
 type EventRepository struct {
 	db *sql.DB
 }
@@ -16,9 +23,55 @@ func NewEventRepository(db *sql.DB) *EventRepository {
 	return &EventRepository{db: db}
 }
 
-func (r *EventRepository) GetAll(ctx context.Context) ([]*domain.Event, error) {
-	query := `SELECT id, type, source, payload, status, created_at, updated_at FROM events`
-	rows, err := r.db.QueryContext(ctx, query)
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEvent(row rowScanner) (*domain.Event, error) {
+	var (
+		event       domain.Event
+		turnTimeout int64
+		lanes       []byte
+		counters    []byte
+	)
+	err := row.Scan(
+		&event.ID, &event.Type, &event.Source, &event.Payload, &event.Status,
+		&event.Title, &event.Description, &event.Venue, &event.StartsAt, &event.EndsAt,
+		&event.Timezone, &event.Capacity, &event.OrganizerID, &event.Visibility, &event.State,
+		&event.QueueState, &event.QueueSettings.MaxActive, &event.QueueSettings.MaxTotal, &event.QueueSettings.MaxPerUser,
+		&turnTimeout, &event.QueueSettings.Policy, &lanes, &counters, &event.CreatedAt, &event.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	event.QueueSettings.TurnTimeout = time.Duration(turnTimeout) * time.Second
+	if err := json.Unmarshal(lanes, &event.QueueSettings.Lanes); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(counters, &event.QueueSettings.Counters); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// marshalLanes сохраняет полосы очереди как JSON-массив; без полос это пустой массив
+func marshalLanes(lanes []domain.QueueLane) ([]byte, error) {
+	if lanes == nil {
+		lanes = []domain.QueueLane{}
+	}
+	return json.Marshal(lanes)
+}
+
+// marshalCounters сохраняет стойки очереди как JSON-массив; без стоек это пустой массив
+func marshalCounters(counters []string) ([]byte, error) {
+	if counters == nil {
+		counters = []string{}
+	}
+	return json.Marshal(counters)
+}
+
+func (r *EventRepository) queryEvents(ctx context.Context, query string, args ...any) ([]*domain.Event, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -26,33 +79,37 @@ func (r *EventRepository) GetAll(ctx context.Context) ([]*domain.Event, error) {
 
 	var events []*domain.Event
 	for rows.Next() {
-		var event domain.Event
-		var createdAt, updatedAt time.Time
-		if err := rows.Scan(&event.ID, &event.Type, &event.Source, &event.Payload, &event.Status, &createdAt, &updatedAt); err != nil {
+		event, err := scanEvent(rows)
+		if err != nil {
 			return nil, err
 		}
-		event.CreatedAt = createdAt
-		event.UpdatedAt = updatedAt
-		events = append(events, &event)
+		events = append(events, event)
 	}
-	return events, nil
+	return events, rows.Err()
+}
+
+func (r *EventRepository) GetAll(ctx context.Context) ([]*domain.Event, error) {
+	return r.queryEvents(ctx, `SELECT `+eventColumns+` FROM events`)
 }
 
 func (r *EventRepository) List(ctx context.Context) ([]*domain.Event, error) {
 	return r.GetAll(ctx)
 }
 
+func (r *EventRepository) ListVisible(ctx context.Context, userID string) ([]*domain.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events
+			  WHERE type = $1 AND ((visibility = $2 AND state <> $3) OR organizer_id = $4)
+			  ORDER BY starts_at ASC`
+	return r.queryEvents(ctx, query, domain.EventTypeScheduled, domain.VisibilityPublic, domain.StateDraft, userID)
+}
+
 func (r *EventRepository) GetByID(ctx context.Context, id string) (*domain.Event, error) {
-	query := `SELECT id, type, source, payload, status, created_at, updated_at FROM events WHERE id = $1`
-	var event domain.Event
-	var createdAt, updatedAt time.Time
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&event.ID, &event.Type, &event.Source, &event.Payload, &event.Status, &createdAt, &updatedAt)
-	if err != nil {
-		return nil, err
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id = $1`
+	event, err := scanEvent(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrEventNotFound
 	}
-	event.CreatedAt = createdAt
-	event.UpdatedAt = updatedAt
-	return &event, nil
+	return event, err
 }
 
 func (r *EventRepository) Delete(ctx context.Context, id string) error {
@@ -62,31 +119,168 @@ func (r *EventRepository) Delete(ctx context.Context, id string) error {
 }
 
 func (r *EventRepository) Save(ctx context.Context, event *domain.Event) error {
-	query := `INSERT INTO events (id, type, source, payload, status, created_at, updated_at) 
-			  VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := r.db.ExecContext(ctx, query,
+	lanes, err := marshalLanes(event.QueueSettings.Lanes)
+	if err != nil {
+		return err
+	}
+	counters, err := marshalCounters(event.QueueSettings.Counters)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO events (` + eventColumns + `)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25)`
+	_, err = r.db.ExecContext(ctx, query,
 		event.ID,
 		event.Type,
 		event.Source,
 		event.Payload,
 		event.Status,
+		event.Title,
+		event.Description,
+		event.Venue,
+		event.StartsAt,
+		event.EndsAt,
+		event.Timezone,
+		event.Capacity,
+		event.OrganizerID,
+		event.Visibility,
+		event.State,
+		event.QueueState,
+		event.QueueSettings.MaxActive,
+		event.QueueSettings.MaxTotal,
+		event.QueueSettings.MaxPerUser,
+		int64(event.QueueSettings.TurnTimeout/time.Second),
+		event.QueueSettings.Policy,
+		lanes,
+		counters,
 		event.CreatedAt,
 		event.UpdatedAt,
 	)
 	return err
 }
 
+// Update не трогает этап мероприятия, состояние и настройки очереди: их меняют TransitionEvent,
+// TransitionQueue и UpdateQueueSettings, иначе запись мероприятия затирала бы их параллельные изменения
 func (r *EventRepository) Update(ctx context.Context, event *domain.Event) error {
 	query := `UPDATE events 
-			  SET type = $1, source = $2, payload = $3, status = $4, updated_at = $5 
-			  WHERE id = $6`
-	_, err := r.db.ExecContext(ctx, query,
+			  SET type = $1, source = $2, payload = $3, status = $4,
+			      title = $5, description = $6, venue = $7, starts_at = $8, ends_at = $9,
+			      timezone = $10, capacity = $11, visibility = $12, updated_at = $13
+			  WHERE id = $14`
+	res, err := r.db.ExecContext(ctx, query,
 		event.Type,
 		event.Source,
 		event.Payload,
 		event.Status,
+		event.Title,
+		event.Description,
+		event.Venue,
+		event.StartsAt,
+		event.EndsAt,
+		event.Timezone,
+		event.Capacity,
+		event.Visibility,
 		event.UpdatedAt,
 		event.ID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrEventNotFound
+	}
+	return nil
+}
+
+// TransitionEvent проверяет переход по состоянию, прочитанному под блокировкой строки,
+// как условное обновление: параллельные переходы из одного состояния не пройдут оба
+func (r *EventRepository) TransitionEvent(ctx context.Context, eventID string, to domain.EventState) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var event domain.Event
+	err = tx.QueryRowContext(ctx,
+		"SELECT state, queue_state FROM events WHERE id = $1 FOR NO KEY UPDATE", eventID,
+	).Scan(&event.State, &event.QueueState)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrEventNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := domain.CheckTransition(event.State, to); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE events SET state = $1, queue_state = $2, updated_at = $3 WHERE id = $4",
+		to, domain.QueueAfter(event.QueueState, to), time.Now(), eventID,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// TransitionQueue читает состояние очереди под той же блокировкой, что и вступления,
+// поэтому переход не разойдётся с параллельной сменой состояния
+func (r *EventRepository) TransitionQueue(ctx context.Context, eventID string, transition domain.QueueTransition) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	state, err := lockEvent(ctx, tx, eventID)
+	if err != nil {
+		return false, err
+	}
+	changed, err := transition.Check(state)
+	if err != nil || !changed {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE events SET queue_state = $1, updated_at = $2 WHERE id = $3",
+		transition.To, time.Now(), eventID,
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *EventRepository) UpdateQueueSettings(ctx context.Context, eventID string, settings domain.QueueSettings) error {
+	lanes, err := marshalLanes(settings.Lanes)
+	if err != nil {
+		return err
+	}
+	counters, err := marshalCounters(settings.Counters)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE events 
+			  SET queue_max_active = $1, queue_max_total = $2, queue_max_per_user = $3,
+			      queue_turn_timeout = $4, queue_policy = $5, queue_lanes = $6, queue_counters = $7,
+			      updated_at = $8
+			  WHERE id = $9`
+	res, err := r.db.ExecContext(ctx, query,
+		settings.MaxActive,
+		settings.MaxTotal,
+		settings.MaxPerUser,
+		int64(settings.TurnTimeout/time.Second),
+		settings.Policy,
+		lanes,
+		counters,
+		time.Now(),
+		eventID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrEventNotFound
+	}
+	return nil
 }
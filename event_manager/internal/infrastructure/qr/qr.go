@@ -0,0 +1,38 @@
+package qr
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/XRS0/ToTalkB/codes"
+)
+
+// ContentType — формат изображений, которые рисует Render
+const ContentType = "image/png"
+
+// Render рисует QR-код с текстом и возвращает изображение в формате ContentType
+func Render(text string) ([]byte, error) {
+	image, err := codes.GeneratePNG(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render qr code: %w", err)
+	}
+	return image, nil
+}
+
+// Scan распознаёт QR-код на фотографии
+func Scan(photo []byte) (string, error) {
+	file, err := os.CreateTemp("", "event-qr-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(photo)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	return codes.ScanQRCode(file.Name())
+}
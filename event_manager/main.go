@@ -10,6 +10,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/XRS0/ToTalkB/auth/middleware"
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
 
@@ -48,9 +49,21 @@ func main() {
 		log.Fatalf("Failed to create notification client: %v", err)
 	}
 
+	// Initialize check-in signer
+	checkInSigner, err := services.NewCheckInSigner(cfg.CheckIn.Secret)
+	if err != nil {
+		log.Fatalf("Failed to create check-in signer: %v", err)
+	}
+
 	// Initialize services
 	eventService := services.NewEventService(eventRepo, notificationClient)
-	queueService := services.NewEventQueueService(queueRepo)
+	queueService := services.NewEventQueueService(queueRepo, eventRepo, notificationClient, checkInSigner)
+	eventService.OnEventClosed(queueService)
+
+	// Start background jobs
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go services.NewTurnScheduler(queueService).Run(ctx)
 
 	// Initialize gRPC server
 	server := grpc.NewServer()
@@ -73,8 +86,18 @@ func main() {
 
 	// Initialize HTTP server
 	router := gin.Default()
+	// действующий пользователь берётся только из токена, а не из тела или параметров запроса
+	api := router.Group("/api", middleware.UserIdentity)
 	eventHandler := handlers.NewEventHandler(eventService)
-	router.GET("/api/events", eventHandler.GetEvents)
+	api.GET("/events", eventHandler.GetEvents)
+	api.POST("/events", eventHandler.CreateEvent)
+	api.GET("/events/:id", eventHandler.GetEvent)
+	api.PUT("/events/:id", eventHandler.UpdateEvent)
+	api.POST("/events/:id/state", eventHandler.ChangeState)
+	queueHandler := handlers.NewQueueHandler(queueService)
+	// QR-код выдаётся только на собственную запись, а отмечает прибытие организатор
+	api.GET("/events/:id/queue/qr", queueHandler.CheckInQR)
+	api.POST("/events/check-in", queueHandler.CheckIn)
 
 	// Start HTTP server
 	httpServer := &http.Server{
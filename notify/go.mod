@@ -6,6 +6,7 @@ toolchain go1.24.2
 
 require (
 	github.com/XRS0/ToTalkB/auth v0.0.0-20250518072231-312b0dcf2dc7
+	github.com/XRS0/ToTalkB/wsqueue v0.0.0-00010101000000-000000000000
 	github.com/golang-jwt/jwt/v5 v5.2.2
 	github.com/golang-migrate/migrate/v4 v4.18.3
 	github.com/google/uuid v1.6.0
@@ -42,3 +43,5 @@ require (
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/XRS0/ToTalkB/wsqueue => ../wsqueue
@@ -26,6 +26,7 @@ func NewServer(service domain.NotificationService) *Server {
 func (s *Server) SendNotification(ctx context.Context, req *gen.SendNotificationRequest) (*gen.SendNotificationResponse, error) {
 	notification := &domain.Notification{
 		ID:      uuid.NewString(),
+		UserID:  int(req.UserId),
 		Type:    req.Type,
 		Payload: req.Payload,
 	}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/XRS0/ToTalkB/auth/pkg"
+	"github.com/XRS0/ToTalkB/wsqueue"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
 )
@@ -76,7 +77,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	wsUser := &WebSocketUser{
 		User:    user,
 		Conn:    conn,
-		Send:    make(chan []byte, 256),
+		Queue:   wsqueue.New(sendQueueSize, sendPolicies, sendMetrics),
 		Manager: h.manager,
 	}
 
@@ -121,22 +122,18 @@ func (u *WebSocketUser) writePump() {
 
 	for {
 		select {
-		case message, ok := <-u.Send:
-			u.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			if !ok {
-				u.Conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
-			}
-
-			w, err := u.Conn.NextWriter(websocket.TextMessage)
-			if err != nil {
-				return
+		case <-u.Queue.Ready():
+			for _, message := range u.Queue.Drain() {
+				u.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+				if err := u.Conn.WriteMessage(websocket.TextMessage, message.Data); err != nil {
+					return
+				}
 			}
-			w.Write(message)
 
-			if err := w.Close(); err != nil {
-				return
-			}
+		case <-u.Queue.Done():
+			u.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			u.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return
 
 		case <-ticker.C:
 			u.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
@@ -6,16 +6,25 @@ import (
 	"sync"
 
 	"github.com/XRS0/ToTalkB/auth/pkg"
+	"github.com/XRS0/ToTalkB/wsqueue"
 	"github.com/gorilla/websocket"
 )
 
-// WebSocketUser represents a connected WebSocket user
+const sendQueueSize = 256
+
+// sendPolicies: notifications are persisted, so when a client can't keep up
+// the oldest pending ones are dropped instead of disconnecting it
+var sendPolicies = wsqueue.Policies{Default: wsqueue.DropOldest}
+
+var sendMetrics = wsqueue.NewMetrics("notify_ws")
+
+// WebSocketUser represents a connected WebSocket user.
+// All writes to Conn go through writePump, other goroutines only push to Queue
 type WebSocketUser struct {
 	User    *pkg.User
 	Conn    *websocket.Conn
-	Send    chan []byte
+	Queue   *wsqueue.Queue
 	Manager *Manager
-	mu      sync.Mutex
 }
 
 // Manager handles all WebSocket connections
@@ -45,16 +54,19 @@ func (m *Manager) Start() {
 		select {
 		case user := <-m.register:
 			m.mu.Lock()
+			if old, ok := m.users[user.User.Id]; ok {
+				old.Queue.Close()
+			}
 			m.users[user.User.Id] = user
 			m.mu.Unlock()
 			log.Printf("User registered: %s", user.User.Login)
 
 		case user := <-m.unregister:
 			m.mu.Lock()
-			if _, ok := m.users[user.User.Id]; ok {
+			if m.users[user.User.Id] == user {
 				delete(m.users, user.User.Id)
-				close(user.Send)
 			}
+			user.Queue.Close()
 			m.mu.Unlock()
 			log.Printf("User unregistered: %s", user.User.Login)
 		}
@@ -86,8 +98,8 @@ func (m *Manager) SendToUser(userID int, messageType string, payload interface{}
 		return nil // User is not connected
 	}
 
-	user.mu.Lock()
-	defer user.mu.Unlock()
-
-	return user.Conn.WriteMessage(websocket.TextMessage, messageBytes)
+	if !user.Queue.Push(wsqueue.Message{Kind: messageType, Data: messageBytes}) {
+		m.unregister <- user
+	}
+	return nil
 }
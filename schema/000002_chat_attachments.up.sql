@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS attachments (
+    id            serial PRIMARY KEY,  -- integer
+    chat_id       integer NOT NULL REFERENCES chats(id) ON DELETE CASCADE,     -- integer
+    message_id    integer REFERENCES messages(id) ON DELETE CASCADE,           -- integer, NULL until sent
+    uploader_id   integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,     -- integer
+    file_name     varchar(255) NOT NULL,
+    mime_type     varchar(255) NOT NULL,
+    size          bigint NOT NULL,
+    storage_key   varchar(512) NOT NULL UNIQUE,
+    thumbnail_key varchar(512),
+    created_at    timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_message_id ON attachments(message_id);
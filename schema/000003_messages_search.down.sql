@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_messages_chat_id_created_at;
+
+DROP INDEX IF EXISTS idx_messages_search_vector;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,7 @@
+-- Конфигурация russian стеммит кириллицу русским стеммером, а латиницу английским,
+-- поэтому одного tsvector достаточно для смешанных русско-английских сообщений
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian', content)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_messages_search_vector ON messages USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_messages_chat_id_created_at ON messages(chat_id, created_at);
@@ -0,0 +1,9 @@
+DROP TABLE moderation_actions;
+
+DROP TABLE message_reports;
+
+DROP TABLE chat_bans;
+
+ALTER TABLE chat_members DROP COLUMN muted_until;
+
+ALTER TABLE chat_members DROP COLUMN role;
@@ -0,0 +1,44 @@
+ALTER TABLE chat_members ADD COLUMN IF NOT EXISTS role varchar(20) NOT NULL DEFAULT 'member';
+ALTER TABLE chat_members ADD COLUMN IF NOT EXISTS muted_until timestamp;
+
+UPDATE chat_members cm SET role = 'owner'
+FROM chats c
+WHERE c.id = cm.chat_id AND c.created_by = cm.user_id;
+
+CREATE TABLE IF NOT EXISTS chat_bans (
+    chat_id    integer NOT NULL REFERENCES chats(id) ON DELETE CASCADE,  -- integer
+    user_id    integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,  -- integer
+    banned_by  integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,  -- integer
+    reason     text NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL DEFAULT now(),
+    PRIMARY KEY (chat_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS message_reports (
+    id          serial PRIMARY KEY,  -- integer
+    chat_id     integer NOT NULL REFERENCES chats(id) ON DELETE CASCADE,     -- integer
+    message_id  integer NOT NULL REFERENCES messages(id) ON DELETE CASCADE,  -- integer
+    reporter_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,     -- integer
+    reason      text NOT NULL,
+    status      varchar(20) NOT NULL DEFAULT 'open',
+    resolved_by integer REFERENCES users(id) ON DELETE SET NULL,             -- integer
+    resolved_at timestamp,
+    created_at  timestamp NOT NULL DEFAULT now(),
+    UNIQUE (message_id, reporter_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_reports_chat_status ON message_reports(chat_id, status);
+
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    id             serial PRIMARY KEY,  -- integer
+    chat_id        integer NOT NULL REFERENCES chats(id) ON DELETE CASCADE,  -- integer
+    actor_id       integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,  -- integer
+    target_user_id integer REFERENCES users(id) ON DELETE SET NULL,          -- integer
+    report_id      integer REFERENCES message_reports(id) ON DELETE SET NULL, -- integer
+    action         varchar(50) NOT NULL,
+    reason         text NOT NULL DEFAULT '',
+    expires_at     timestamp,
+    created_at     timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_chat_id ON moderation_actions(chat_id, created_at);
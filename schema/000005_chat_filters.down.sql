@@ -0,0 +1,7 @@
+ALTER TABLE message_reports DROP COLUMN source;
+
+DELETE FROM message_reports WHERE reporter_id IS NULL;
+
+ALTER TABLE message_reports ALTER COLUMN reporter_id SET NOT NULL;
+
+DROP TABLE chat_filter_settings;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS chat_filter_settings (
+    chat_id integer NOT NULL REFERENCES chats(id) ON DELETE CASCADE,  -- integer
+    filter  varchar(50) NOT NULL,
+    action  varchar(20) NOT NULL,
+    PRIMARY KEY (chat_id, filter)
+);
+
+-- Жалобы от фильтров контента попадают в ту же очередь модерации без автора
+ALTER TABLE message_reports ALTER COLUMN reporter_id DROP NOT NULL;
+ALTER TABLE message_reports ADD COLUMN IF NOT EXISTS source varchar(20) NOT NULL DEFAULT 'user';
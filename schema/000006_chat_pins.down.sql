@@ -0,0 +1 @@
+DROP TABLE pinned_messages;
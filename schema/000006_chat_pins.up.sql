@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS pinned_messages (
+    chat_id    integer NOT NULL REFERENCES chats(id) ON DELETE CASCADE,     -- integer
+    message_id integer NOT NULL REFERENCES messages(id) ON DELETE CASCADE,  -- integer
+    pinned_by  integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,     -- integer
+    pinned_at  timestamp NOT NULL DEFAULT now(),
+    PRIMARY KEY (chat_id, message_id)
+);
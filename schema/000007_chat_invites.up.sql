@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS chat_invites (
+    id         serial PRIMARY KEY,  -- integer
+    chat_id    integer NOT NULL REFERENCES chats(id) ON DELETE CASCADE,  -- integer
+    token      varchar(64) NOT NULL UNIQUE,
+    created_by integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,  -- integer
+    expires_at timestamp,
+    max_uses   integer,
+    uses       integer NOT NULL DEFAULT 0,
+    revoked_at timestamp,
+    created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_invites_chat_id ON chat_invites(chat_id);
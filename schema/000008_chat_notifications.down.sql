@@ -0,0 +1,3 @@
+ALTER TABLE chat_members DROP COLUMN notifications_muted_until;
+
+ALTER TABLE chat_members DROP COLUMN notifications_muted;
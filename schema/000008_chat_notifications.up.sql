@@ -0,0 +1,2 @@
+ALTER TABLE chat_members ADD COLUMN IF NOT EXISTS notifications_muted boolean NOT NULL DEFAULT false;
+ALTER TABLE chat_members ADD COLUMN IF NOT EXISTS notifications_muted_until timestamp;
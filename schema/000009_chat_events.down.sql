@@ -0,0 +1 @@
+ALTER TABLE chats DROP COLUMN event_id;
@@ -0,0 +1 @@
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS event_id varchar(64);
@@ -0,0 +1,7 @@
+DROP TABLE poll_votes;
+
+DROP TABLE poll_ballots;
+
+DROP TABLE poll_options;
+
+DROP TABLE polls;
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS polls (
+    id         serial PRIMARY KEY,  -- integer
+    chat_id    integer NOT NULL REFERENCES chats(id) ON DELETE CASCADE,  -- integer
+    created_by integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,  -- integer
+    question   text NOT NULL,
+    multiple   boolean NOT NULL DEFAULT false,
+    anonymous  boolean NOT NULL DEFAULT false,
+    closes_at  timestamp,
+    closed_at  timestamp,
+    created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_polls_chat_id ON polls(chat_id);
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    id       serial PRIMARY KEY,  -- integer
+    poll_id  integer NOT NULL REFERENCES polls(id) ON DELETE CASCADE,  -- integer
+    position integer NOT NULL,
+    text     varchar(200) NOT NULL,
+    UNIQUE (poll_id, position)
+);
+
+-- Один бюллетень на участника: повторное голосование отклоняется на уровне базы
+CREATE TABLE IF NOT EXISTS poll_ballots (
+    poll_id    integer NOT NULL REFERENCES polls(id) ON DELETE CASCADE,  -- integer
+    user_id    integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,  -- integer
+    created_at timestamp NOT NULL DEFAULT now(),
+    PRIMARY KEY (poll_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS poll_votes (
+    poll_id   integer NOT NULL,  -- integer
+    user_id   integer NOT NULL,  -- integer
+    option_id integer NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,  -- integer
+    PRIMARY KEY (poll_id, user_id, option_id),
+    FOREIGN KEY (poll_id, user_id) REFERENCES poll_ballots(poll_id, user_id) ON DELETE CASCADE
+);
@@ -0,0 +1,7 @@
+DROP TABLE message_receipts;
+
+DROP INDEX idx_messages_chat_id_seq;
+
+ALTER TABLE messages DROP COLUMN seq;
+
+ALTER TABLE chats DROP COLUMN last_seq;
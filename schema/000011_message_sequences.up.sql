@@ -0,0 +1,22 @@
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS last_seq bigint NOT NULL DEFAULT 0;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS seq bigint;
+
+UPDATE messages m SET seq = s.rn
+FROM (SELECT id, row_number() OVER (PARTITION BY chat_id ORDER BY created_at, id) AS rn FROM messages) s
+WHERE s.id = m.id;
+
+UPDATE chats c SET last_seq = COALESCE((SELECT MAX(seq) FROM messages m WHERE m.chat_id = c.id), 0);
+
+ALTER TABLE messages ALTER COLUMN seq SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_chat_id_seq ON messages(chat_id, seq);
+
+-- Отметки доставки и прочтения: участник получил/прочитал все сообщения чата до seq включительно
+CREATE TABLE IF NOT EXISTS message_receipts (
+    chat_id       integer NOT NULL REFERENCES chats(id) ON DELETE CASCADE,  -- integer
+    user_id       integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,  -- integer
+    delivered_seq bigint NOT NULL DEFAULT 0,
+    read_seq      bigint NOT NULL DEFAULT 0,
+    updated_at    timestamp NOT NULL DEFAULT now(),
+    PRIMARY KEY (chat_id, user_id)
+);
@@ -0,0 +1,5 @@
+DROP TABLE scheduled_messages;
+
+DROP INDEX idx_messages_expires_at;
+
+ALTER TABLE messages DROP COLUMN expires_at;
@@ -0,0 +1,18 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS expires_at timestamp;
+
+CREATE INDEX IF NOT EXISTS idx_messages_expires_at ON messages(expires_at) WHERE expires_at IS NOT NULL;
+
+-- Отложенные сообщения: при наступлении send_at переносятся в messages и получают seq
+CREATE TABLE IF NOT EXISTS scheduled_messages (
+    id         serial PRIMARY KEY,  -- integer
+    chat_id    integer NOT NULL REFERENCES chats(id) ON DELETE CASCADE,  -- integer
+    sender_id  integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,  -- integer
+    content    text NOT NULL,
+    flags      text[] NOT NULL DEFAULT '{}',
+    send_at    timestamp NOT NULL,
+    expires_at timestamp,
+    created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_send_at ON scheduled_messages(send_at);
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_chat_id ON scheduled_messages(chat_id);
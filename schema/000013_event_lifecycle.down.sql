@@ -0,0 +1,15 @@
+DROP INDEX idx_events_state_starts_at;
+
+DROP INDEX idx_events_organizer_id;
+
+ALTER TABLE events
+    DROP COLUMN state,
+    DROP COLUMN visibility,
+    DROP COLUMN organizer_id,
+    DROP COLUMN capacity,
+    DROP COLUMN timezone,
+    DROP COLUMN ends_at,
+    DROP COLUMN starts_at,
+    DROP COLUMN venue,
+    DROP COLUMN description,
+    DROP COLUMN title;
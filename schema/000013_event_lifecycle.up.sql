@@ -0,0 +1,17 @@
+-- Мероприятия, на которые записываются в очередь: описание, время, вместимость и жизненный цикл.
+-- Время хранится в UTC, часовой пояс площадки — в timezone
+ALTER TABLE events ADD COLUMN IF NOT EXISTS title        varchar(255) NOT NULL DEFAULT '';
+ALTER TABLE events ADD COLUMN IF NOT EXISTS description  text NOT NULL DEFAULT '';
+ALTER TABLE events ADD COLUMN IF NOT EXISTS venue        varchar(255) NOT NULL DEFAULT '';
+ALTER TABLE events ADD COLUMN IF NOT EXISTS starts_at    timestamp NOT NULL DEFAULT now();
+ALTER TABLE events ADD COLUMN IF NOT EXISTS ends_at      timestamp NOT NULL DEFAULT now();
+ALTER TABLE events ADD COLUMN IF NOT EXISTS timezone     varchar(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE events ADD COLUMN IF NOT EXISTS capacity     integer NOT NULL DEFAULT 0 CHECK (capacity >= 0);
+ALTER TABLE events ADD COLUMN IF NOT EXISTS organizer_id varchar(64) NOT NULL DEFAULT '';
+ALTER TABLE events ADD COLUMN IF NOT EXISTS visibility   varchar(20) NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'private'));
+ALTER TABLE events ADD COLUMN IF NOT EXISTS state        varchar(20) NOT NULL DEFAULT 'draft'
+    CHECK (state IN ('draft', 'published', 'ongoing', 'finished', 'cancelled'));
+
+CREATE INDEX IF NOT EXISTS idx_events_organizer_id ON events(organizer_id);
+CREATE INDEX IF NOT EXISTS idx_events_state_starts_at ON events(state, starts_at);
@@ -0,0 +1,3 @@
+ALTER TABLE events DROP COLUMN queue_closed;
+
+ALTER TABLE event_queues ALTER COLUMN user_id TYPE uuid USING user_id::uuid;
@@ -0,0 +1,4 @@
+-- Очереди принимают идентификаторы пользователей чата, а не только UUID
+ALTER TABLE event_queues ALTER COLUMN user_id TYPE varchar(64) USING user_id::text;
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS queue_closed boolean NOT NULL DEFAULT false;
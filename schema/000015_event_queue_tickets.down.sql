@@ -0,0 +1,16 @@
+DROP INDEX idx_event_queues_waiting;
+
+ALTER TABLE event_queues ADD COLUMN position integer NOT NULL DEFAULT 0;
+
+UPDATE event_queues AS q
+SET position = o.position
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY event_id ORDER BY ticket) AS position
+    FROM event_queues
+    WHERE status = 'waiting'
+) AS o
+WHERE q.id = o.id;
+
+ALTER TABLE event_queues ALTER COLUMN position DROP DEFAULT;
+
+ALTER TABLE event_queues DROP COLUMN ticket;
@@ -0,0 +1,25 @@
+-- Порядок в очереди задаёт талон из последовательности, а не хранимая позиция:
+-- выход из очереди и вызов следующего больше не перенумеровывают строки
+CREATE SEQUENCE IF NOT EXISTS event_queue_tickets;
+
+ALTER TABLE event_queues ADD COLUMN IF NOT EXISTS ticket bigint;
+
+-- Существующим записям талоны выдаются в прежнем порядке очереди
+UPDATE event_queues AS q
+SET ticket = o.ticket
+FROM (
+    SELECT id, row_number() OVER (ORDER BY position = 0, position, updated_at) AS ticket
+    FROM event_queues
+) AS o
+WHERE q.id = o.id;
+
+SELECT setval('event_queue_tickets', COALESCE((SELECT MAX(ticket) FROM event_queues), 0) + 1, false);
+
+ALTER TABLE event_queues
+    ALTER COLUMN ticket SET DEFAULT nextval('event_queue_tickets'),
+    ALTER COLUMN ticket SET NOT NULL,
+    DROP COLUMN position;
+
+ALTER SEQUENCE event_queue_tickets OWNED BY event_queues.ticket;
+
+CREATE INDEX IF NOT EXISTS idx_event_queues_waiting ON event_queues(event_id, ticket) WHERE status = 'waiting';
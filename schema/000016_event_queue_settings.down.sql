@@ -0,0 +1,10 @@
+DROP INDEX idx_event_queues_waitlisted;
+
+UPDATE event_queues SET status = 'waiting' WHERE status = 'waitlisted';
+
+ALTER TABLE event_queues DROP COLUMN joins;
+
+ALTER TABLE events
+    DROP COLUMN queue_max_per_user,
+    DROP COLUMN queue_max_total,
+    DROP COLUMN queue_max_active;
@@ -0,0 +1,9 @@
+-- Ограничения очереди мероприятия, 0 — без ограничения
+ALTER TABLE events ADD COLUMN IF NOT EXISTS queue_max_active   integer NOT NULL DEFAULT 0 CHECK (queue_max_active >= 0);
+ALTER TABLE events ADD COLUMN IF NOT EXISTS queue_max_total    integer NOT NULL DEFAULT 0 CHECK (queue_max_total >= 0);
+ALTER TABLE events ADD COLUMN IF NOT EXISTS queue_max_per_user integer NOT NULL DEFAULT 0 CHECK (queue_max_per_user >= 0);
+
+-- Сколько раз пользователь вставал в очередь мероприятия, включая повторные вступления
+ALTER TABLE event_queues ADD COLUMN IF NOT EXISTS joins integer NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_event_queues_waitlisted ON event_queues(event_id, ticket) WHERE status = 'waitlisted';
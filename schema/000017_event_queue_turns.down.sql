@@ -0,0 +1,5 @@
+DROP INDEX idx_event_queues_expires_at;
+
+ALTER TABLE event_queues DROP COLUMN expires_at;
+
+ALTER TABLE events DROP COLUMN queue_turn_timeout;
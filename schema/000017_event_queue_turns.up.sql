@@ -0,0 +1,7 @@
+-- Сколько секунд вызванный пользователь может не подходить, 0 — без ограничения
+ALTER TABLE events ADD COLUMN IF NOT EXISTS queue_turn_timeout integer NOT NULL DEFAULT 0 CHECK (queue_turn_timeout >= 0);
+
+-- Срок, до которого вызванный пользователь должен подойти, иначе он отмечается неявившимся
+ALTER TABLE event_queues ADD COLUMN IF NOT EXISTS expires_at timestamp;
+
+CREATE INDEX IF NOT EXISTS idx_event_queues_expires_at ON event_queues(expires_at) WHERE status = 'active';
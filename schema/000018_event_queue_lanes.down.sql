@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_event_queues_waiting;
+CREATE INDEX IF NOT EXISTS idx_event_queues_waiting ON event_queues(event_id, ticket) WHERE status = 'waiting';
+
+ALTER TABLE event_queues DROP COLUMN lane;
+
+ALTER TABLE events DROP COLUMN queue_lane_calls;
+ALTER TABLE events DROP COLUMN queue_lane;
+ALTER TABLE events DROP COLUMN queue_lanes;
+ALTER TABLE events DROP COLUMN queue_policy;
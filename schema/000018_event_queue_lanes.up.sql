@@ -0,0 +1,13 @@
+-- Полосы приоритета: политика чередования и полосы по убыванию приоритета
+ALTER TABLE events ADD COLUMN IF NOT EXISTS queue_policy text NOT NULL DEFAULT '';
+ALTER TABLE events ADD COLUMN IF NOT EXISTS queue_lanes  jsonb NOT NULL DEFAULT '[]';
+
+-- Где остановился взвешенный обход полос: полоса и сколько из неё вызвано подряд
+ALTER TABLE events ADD COLUMN IF NOT EXISTS queue_lane       text NOT NULL DEFAULT '';
+ALTER TABLE events ADD COLUMN IF NOT EXISTS queue_lane_calls integer NOT NULL DEFAULT 0;
+
+-- Полоса записи; пустая — единственная полоса очереди без настроенных приоритетов
+ALTER TABLE event_queues ADD COLUMN IF NOT EXISTS lane text NOT NULL DEFAULT '';
+
+DROP INDEX IF EXISTS idx_event_queues_waiting;
+CREATE INDEX IF NOT EXISTS idx_event_queues_waiting ON event_queues(event_id, lane, ticket) WHERE status = 'waiting';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_event_queues_counter;
+
+ALTER TABLE event_queues DROP COLUMN called_at;
+ALTER TABLE event_queues DROP COLUMN counter;
@@ -0,0 +1,5 @@
+-- Стойка, к которой вызван пользователь, и время вызова: по ним считается работа стоек
+ALTER TABLE event_queues ADD COLUMN IF NOT EXISTS counter   text NOT NULL DEFAULT '';
+ALTER TABLE event_queues ADD COLUMN IF NOT EXISTS called_at timestamp;
+
+CREATE INDEX IF NOT EXISTS idx_event_queues_counter ON event_queues(event_id, counter) WHERE status = 'active';
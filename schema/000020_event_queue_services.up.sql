@@ -0,0 +1,10 @@
+-- Длительность каждого завершённого обслуживания: по недавним из них оценивается ожидание
+CREATE TABLE IF NOT EXISTS event_queue_services (
+    id           bigserial PRIMARY KEY,
+    event_id     UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    counter      text NOT NULL DEFAULT '',
+    duration_ms  bigint NOT NULL CHECK (duration_ms >= 0),
+    completed_at timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_queue_services_event_id ON event_queue_services(event_id, id);
@@ -0,0 +1,5 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS queue_closed boolean NOT NULL DEFAULT false;
+
+UPDATE events SET queue_closed = queue_state <> 'open';
+
+ALTER TABLE events DROP COLUMN queue_state;
@@ -0,0 +1,7 @@
+-- Состояние очереди заменяет флаг закрытого набора
+ALTER TABLE events ADD COLUMN IF NOT EXISTS queue_state text NOT NULL DEFAULT 'open'
+    CHECK (queue_state IN ('open', 'paused', 'closed', 'drained', 'archived'));
+
+UPDATE events SET queue_state = 'closed' WHERE queue_closed;
+
+ALTER TABLE events DROP COLUMN queue_closed;
@@ -0,0 +1,4 @@
+-- Стойки, к которым организатор разрешил вызывать пользователей; пустой список — любая стойка.
+-- Список проверяют ProcessNext и отметка о прибытии. У существующих мероприятий он пустой,
+-- поэтому вызовы к любой стойке работают как раньше, пока организатор его не задаст
+ALTER TABLE events ADD COLUMN IF NOT EXISTS queue_counters jsonb NOT NULL DEFAULT '[]';
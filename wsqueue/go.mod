@@ -0,0 +1,3 @@
+module github.com/XRS0/ToTalkB/wsqueue
+
+go 1.22.0
@@ -0,0 +1,58 @@
+package wsqueue
+
+import (
+	"expvar"
+	"sync"
+)
+
+// Metrics считает потерянные, объединённые сообщения и отключения медленных клиентов
+// по типам сообщений. Значения публикуются через expvar и доступны на /debug/vars.
+type Metrics struct {
+	Dropped      *expvar.Map
+	Coalesced    *expvar.Map
+	Disconnected *expvar.Map
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]*Metrics)
+)
+
+// NewMetrics возвращает метрики с именем name, повторный вызов отдаёт те же счётчики
+func NewMetrics(name string) *Metrics {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if m, ok := registry[name]; ok {
+		return m
+	}
+
+	m := &Metrics{
+		Dropped:      new(expvar.Map).Init(),
+		Coalesced:    new(expvar.Map).Init(),
+		Disconnected: new(expvar.Map).Init(),
+	}
+	root := expvar.NewMap(name)
+	root.Set("dropped", m.Dropped)
+	root.Set("coalesced", m.Coalesced)
+	root.Set("disconnected", m.Disconnected)
+	registry[name] = m
+	return m
+}
+
+func (m *Metrics) dropped(kind string) {
+	if m != nil {
+		m.Dropped.Add(kind, 1)
+	}
+}
+
+func (m *Metrics) coalesced(kind string) {
+	if m != nil {
+		m.Coalesced.Add(kind, 1)
+	}
+}
+
+func (m *Metrics) disconnected(kind string) {
+	if m != nil {
+		m.Disconnected.Add(kind, 1)
+	}
+}
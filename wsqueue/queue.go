@@ -0,0 +1,200 @@
+package wsqueue
+
+import "sync"
+
+// Policy определяет, что делать с сообщением, если очередь соединения заполнена
+type Policy int
+
+const (
+	// Disconnect сообщение терять нельзя: в полной очереди ради него вытесняется самое старое
+	// сообщение, которое разрешено терять, а если таких нет, очередь закрывается —
+	// клиент не успевает читать и должен переподключиться
+	Disconnect Policy = iota
+	// DropNewest отбрасывает новое сообщение
+	DropNewest
+	// DropOldest вытесняет самое старое сообщение в очереди, которое разрешено терять
+	DropOldest
+	// Coalesce заменяет ещё не отправленное сообщение того же типа с тем же ключом
+	// (без ключа — любое того же типа); если такого нет и очередь полна, ведёт себя как DropOldest
+	Coalesce
+)
+
+// Message — сообщение в очереди соединения
+type Message struct {
+	// Kind — тип сообщения, по нему выбирается политика и ведутся метрики
+	Kind string
+	// Key — ключ объединения для политики Coalesce, например "pins:12"; пустой ключ объединяет по Kind
+	Key  string
+	Data []byte
+}
+
+// Policies сопоставляет типу сообщения политику; для остальных типов действует Default
+type Policies struct {
+	Default Policy
+	ByKind  map[string]Policy
+}
+
+func (p Policies) For(kind string) Policy {
+	if policy, ok := p.ByKind[kind]; ok {
+		return policy
+	}
+	return p.Default
+}
+
+// Queue — ограниченная очередь исходящих сообщений одного соединения.
+// Писать в неё можно из любых горутин, читает только write pump соединения.
+type Queue struct {
+	mu       sync.Mutex
+	items    []Message
+	capacity int
+	policies Policies
+	metrics  *Metrics
+	ready    chan struct{}
+	space    chan struct{}
+	done     chan struct{}
+	closed   bool
+}
+
+func New(capacity int, policies Policies, metrics *Metrics) *Queue {
+	return &Queue{
+		items:    make([]Message, 0, capacity),
+		capacity: capacity,
+		policies: policies,
+		metrics:  metrics,
+		ready:    make(chan struct{}, 1),
+		space:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// Push ставит сообщение в очередь, не блокируясь. Возвращает false, если очередь закрыта
+// или закрылась из-за политики Disconnect — такое соединение нужно отключить.
+func (q *Queue) Push(msg Message) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false
+	}
+
+	policy := q.policies.For(msg.Kind)
+	if policy == Coalesce {
+		for i := range q.items {
+			if q.items[i].Kind == msg.Kind && q.items[i].Key == msg.Key {
+				q.items[i] = msg
+				q.metrics.coalesced(msg.Kind)
+				return true
+			}
+		}
+	}
+
+	if len(q.items) >= q.capacity {
+		switch policy {
+		case DropNewest:
+			q.metrics.dropped(msg.Kind)
+			return true
+		case DropOldest, Coalesce, Disconnect:
+			if q.evictLocked() {
+				break
+			}
+			// в очереди остались только сообщения, которые терять нельзя
+			q.metrics.disconnected(msg.Kind)
+			q.closeLocked()
+			return false
+		}
+	}
+
+	q.items = append(q.items, msg)
+	q.signal(q.ready)
+	return true
+}
+
+// PushWait ставит сообщение в очередь, дожидаясь свободного места. Подходит для отправки
+// истории при подключении, когда терять сообщения нельзя. Возвращает false, если очередь закрыта.
+func (q *Queue) PushWait(msg Message) bool {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return false
+		}
+		if len(q.items) < q.capacity {
+			q.items = append(q.items, msg)
+			q.signal(q.ready)
+			q.mu.Unlock()
+			return true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.space:
+		case <-q.done:
+			return false
+		}
+	}
+}
+
+// Ready сигналит, что в очереди появились сообщения
+func (q *Queue) Ready() <-chan struct{} {
+	return q.ready
+}
+
+// Done закрывается вместе с очередью
+func (q *Queue) Done() <-chan struct{} {
+	return q.done
+}
+
+// Drain забирает все накопленные сообщения
+func (q *Queue) Drain() []Message {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return nil
+	}
+	items := q.items
+	q.items = make([]Message, 0, q.capacity)
+	q.signal(q.space)
+	return items
+}
+
+// Len возвращает число ожидающих отправки сообщений
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Close закрывает очередь; неотправленные сообщения остаются доступны через Drain
+func (q *Queue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closeLocked()
+}
+
+// evictLocked вытесняет самое старое сообщение, политика которого разрешает его терять.
+// Сообщения с политикой Disconnect не вытесняются никогда
+func (q *Queue) evictLocked() bool {
+	for i, item := range q.items {
+		if q.policies.For(item.Kind) == Disconnect {
+			continue
+		}
+		q.metrics.dropped(item.Kind)
+		q.items = append(q.items[:i], q.items[i+1:]...)
+		return true
+	}
+	return false
+}
+
+func (q *Queue) closeLocked() {
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.done)
+}
+
+func (q *Queue) signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,267 @@
+package wsqueue
+
+import (
+	"expvar"
+	"slices"
+	"testing"
+	"time"
+)
+
+var testPolicies = Policies{
+	Default: Disconnect,
+	ByKind: map[string]Policy{
+		"typing":  DropNewest,
+		"receipt": DropOldest,
+		"pins":    Coalesce,
+	},
+}
+
+func msg(kind, key, data string) Message {
+	return Message{Kind: kind, Key: key, Data: []byte(data)}
+}
+
+// contents возвращает данные сообщений очереди в порядке отправки
+func contents(q *Queue) []string {
+	var data []string
+	for _, item := range q.Drain() {
+		data = append(data, string(item.Data))
+	}
+	return data
+}
+
+func TestPushWhenFull(t *testing.T) {
+	tests := []struct {
+		name   string
+		queued []Message
+		push   Message
+		ok     bool
+		want   []string
+	}{
+		{
+			name:   "disconnect evicts the oldest droppable message",
+			queued: []Message{msg("receipt", "", "r1"), msg("receipt", "", "r2")},
+			push:   msg("message", "", "m1"),
+			ok:     true,
+			want:   []string{"r2", "m1"},
+		},
+		{
+			name:   "disconnect skips protected messages when evicting",
+			queued: []Message{msg("message", "", "m1"), msg("typing", "", "t1")},
+			push:   msg("message", "", "m2"),
+			ok:     true,
+			want:   []string{"m1", "m2"},
+		},
+		{
+			name:   "disconnect closes the queue when only protected messages are queued",
+			queued: []Message{msg("message", "", "m1"), msg("message", "", "m2")},
+			push:   msg("message", "", "m3"),
+			ok:     false,
+			want:   []string{"m1", "m2"},
+		},
+		{
+			name:   "drop newest discards the pushed message",
+			queued: []Message{msg("message", "", "m1"), msg("receipt", "", "r1")},
+			push:   msg("typing", "", "t1"),
+			ok:     true,
+			want:   []string{"m1", "r1"},
+		},
+		{
+			name:   "drop oldest evicts the oldest droppable message",
+			queued: []Message{msg("receipt", "", "r1"), msg("receipt", "", "r2")},
+			push:   msg("receipt", "", "r3"),
+			ok:     true,
+			want:   []string{"r2", "r3"},
+		},
+		{
+			name:   "drop oldest skips protected messages",
+			queued: []Message{msg("message", "", "m1"), msg("typing", "", "t1")},
+			push:   msg("receipt", "", "r1"),
+			ok:     true,
+			want:   []string{"m1", "r1"},
+		},
+		{
+			name:   "drop oldest disconnects when only protected messages are queued",
+			queued: []Message{msg("message", "", "m1"), msg("message", "", "m2")},
+			push:   msg("receipt", "", "r1"),
+			ok:     false,
+			want:   []string{"m1", "m2"},
+		},
+		{
+			name:   "coalesce without a match evicts like drop oldest",
+			queued: []Message{msg("message", "", "m1"), msg("receipt", "", "r1")},
+			push:   msg("pins", "pins:1", "p1"),
+			ok:     true,
+			want:   []string{"m1", "p1"},
+		},
+		{
+			name:   "coalesce disconnects when only protected messages are queued",
+			queued: []Message{msg("message", "", "m1"), msg("message", "", "m2")},
+			push:   msg("pins", "pins:1", "p1"),
+			ok:     false,
+			want:   []string{"m1", "m2"},
+		},
+		{
+			name:   "coalesce replaces in place even when full",
+			queued: []Message{msg("pins", "pins:1", "p1"), msg("message", "", "m1")},
+			push:   msg("pins", "pins:1", "p2"),
+			ok:     true,
+			want:   []string{"p2", "m1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := New(2, testPolicies, nil)
+			for _, queued := range tt.queued {
+				if !q.Push(queued) {
+					t.Fatalf("push %s into a queue with room failed", queued.Data)
+				}
+			}
+			if ok := q.Push(tt.push); ok != tt.ok {
+				t.Fatalf("expected push to return %v, got %v", tt.ok, ok)
+			}
+			if got := contents(q); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected queue %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	tests := []struct {
+		name string
+		push []Message
+		want []string
+	}{
+		{
+			name: "same key replaces the pending message",
+			push: []Message{msg("pins", "pins:1", "p1"), msg("message", "", "m1"), msg("pins", "pins:1", "p2")},
+			want: []string{"p2", "m1"},
+		},
+		{
+			name: "different keys are kept apart",
+			push: []Message{msg("pins", "pins:1", "p1"), msg("pins", "pins:2", "p2")},
+			want: []string{"p1", "p2"},
+		},
+		{
+			name: "empty key coalesces by kind",
+			push: []Message{msg("pins", "", "p1"), msg("pins", "", "p2")},
+			want: []string{"p2"},
+		},
+		{
+			name: "same key of another kind is not replaced",
+			push: []Message{msg("message", "pins:1", "m1"), msg("pins", "pins:1", "p1")},
+			want: []string{"m1", "p1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := New(4, testPolicies, nil)
+			for _, m := range tt.push {
+				if !q.Push(m) {
+					t.Fatalf("push %s failed", m.Data)
+				}
+			}
+			if got := contents(q); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected queue %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPushWait(t *testing.T) {
+	q := New(1, testPolicies, nil)
+	if !q.PushWait(msg("history", "", "h1")) {
+		t.Fatal("push into an empty queue failed")
+	}
+
+	// вторая запись ждёт, пока write pump не заберёт первую
+	pushed := make(chan bool)
+	go func() { pushed <- q.PushWait(msg("history", "", "h2")) }()
+	select {
+	case <-pushed:
+		t.Fatal("push into a full queue did not wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if got := contents(q); !slices.Equal(got, []string{"h1"}) {
+		t.Fatalf("expected h1, got %v", got)
+	}
+	if !<-pushed {
+		t.Fatal("push after drain failed")
+	}
+	if got := contents(q); !slices.Equal(got, []string{"h2"}) {
+		t.Fatalf("expected h2, got %v", got)
+	}
+
+	// закрытие будит ждущую запись
+	q.Push(msg("history", "", "h3"))
+	go func() { pushed <- q.PushWait(msg("history", "", "h4")) }()
+	q.Close()
+	select {
+	case ok := <-pushed:
+		if ok {
+			t.Fatal("expected push into a closed queue to fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("close did not wake the waiting push")
+	}
+}
+
+func TestClose(t *testing.T) {
+	q := New(2, testPolicies, nil)
+	q.Push(msg("message", "", "m1"))
+	q.Close()
+	q.Close()
+
+	select {
+	case <-q.Done():
+	default:
+		t.Fatal("expected done to be closed")
+	}
+	if q.Push(msg("message", "", "m2")) || q.PushWait(msg("message", "", "m3")) {
+		t.Fatal("expected pushes into a closed queue to fail")
+	}
+	// неотправленное остаётся доступным после закрытия
+	if got := contents(q); !slices.Equal(got, []string{"m1"}) {
+		t.Fatalf("expected m1, got %v", got)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	if NewMetrics("wsqueue_test") != NewMetrics("wsqueue_test") {
+		t.Fatal("expected the same metrics for the same name")
+	}
+
+	// счётчики из реестра живут весь процесс, поэтому проверяем на отдельных
+	metrics := &Metrics{
+		Dropped:      new(expvar.Map).Init(),
+		Coalesced:    new(expvar.Map).Init(),
+		Disconnected: new(expvar.Map).Init(),
+	}
+	q := New(1, testPolicies, metrics)
+	q.Push(msg("pins", "pins:1", "p1"))
+	q.Push(msg("pins", "pins:1", "p2"))
+	q.Push(msg("typing", "", "t1"))
+	q.Push(msg("receipt", "", "r1"))
+	q.Push(msg("message", "", "m1"))
+	q.Push(msg("message", "", "m2"))
+
+	counters := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"coalesced pins", metrics.Coalesced.Get("pins").String(), "1"},
+		{"dropped typing", metrics.Dropped.Get("typing").String(), "1"},
+		{"dropped pins", metrics.Dropped.Get("pins").String(), "1"},
+		{"dropped receipt", metrics.Dropped.Get("receipt").String(), "1"},
+		{"disconnected message", metrics.Disconnected.Get("message").String(), "1"},
+	}
+	for _, c := range counters {
+		if c.got != c.want {
+			t.Errorf("%s: expected %s, got %s", c.name, c.want, c.got)
+		}
+	}
+	if metrics.Dropped.Get("message") != nil {
+		t.Errorf("message was kept, not dropped")
+	}
+}